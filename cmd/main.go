@@ -1,10 +1,19 @@
 package main
 
 import (
+	"GitX"
+	"GitX/internal/editor"
+	"GitX/internal/hash"
+	"GitX/internal/merkle"
+	"GitX/internal/patch"
+	"GitX/models"
 	"GitX/utils/file_operations"
 	"GitX/utils/vcs_operations"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,36 +23,69 @@ import (
 // Staging area to hold files for the next commit
 var stagingArea = make(map[string]string)
 
+// Global options selecting the repository, set before the command name
+var (
+	gitxDirOption  = flag.String("gitx-dir", os.Getenv("GITX_DIR"), "Path to the .gitx directory")
+	workTreeOption = flag.String("work-tree", os.Getenv("GITX_WORK_TREE"), "Path to the working tree")
+)
+
 func main() {
+	// Like Git, each -C changes directory relative to the previous one
+	flag.Func("C", "Run as if gitx was started in `path`", func(path string) error {
+		return os.Chdir(path)
+	})
+
 	// Define flags
 	initCommand := flag.NewFlagSet("init", flag.ExitOnError)
+	initObjectFormat := initCommand.String("object-format", "sha1", "Hash algorithm of the repository: sha1 or sha256")
 
 	commitCommand := flag.NewFlagSet("commit", flag.ExitOnError)
 	commitMessage := commitCommand.String("message", "", "Commit message")
+	var commitFile string
+	commitCommand.StringVar(&commitFile, "F", "", "Read the commit message from a file, - for standard input")
+	commitCommand.StringVar(&commitFile, "file", "", "Read the commit message from a file, - for standard input")
+	commitNoEdit := commitCommand.Bool("no-edit", false, "Keep the message of the amended commit without opening an editor")
+	var commitOptions file_operations.CommitOptions
+	commitCommand.BoolVar(&commitOptions.Sign, "S", false, "Sign the commit with user.signingkey")
+	commitCommand.BoolVar(&commitOptions.Sign, "gpg-sign", false, "Sign the commit with user.signingkey")
+	commitCommand.BoolVar(&commitOptions.NoVerify, "n", false, "Skip the pre-commit and commit-msg hooks")
+	commitCommand.BoolVar(&commitOptions.NoVerify, "no-verify", false, "Skip the pre-commit and commit-msg hooks")
+	commitCommand.BoolVar(&commitOptions.All, "a", false, "Stage all changes to tracked files before committing")
+	commitCommand.BoolVar(&commitOptions.All, "all", false, "Stage all changes to tracked files before committing")
+	commitCommand.BoolVar(&commitOptions.AllowEmpty, "allow-empty", false, "Allow a commit that changes nothing")
+	commitCommand.BoolVar(&commitOptions.Amend, "amend", false, "Replace the tip of the current branch")
+	commitCommand.BoolVar(&commitOptions.ResetAuthor, "reset-author", false, "Make the current user the author of the amended commit")
+	commitCommand.StringVar(&commitOptions.Fixup, "fixup", "", "Make a commit that fixes the given commit")
 
 	branchCommand := flag.NewFlagSet("branch", flag.ExitOnError)
 	branchDelete := branchCommand.Bool("d", false, "Delete branch")
+	var branchUpstream string
+	branchCommand.StringVar(&branchUpstream, "u", "", "Set the upstream of the branch")
+	branchCommand.StringVar(&branchUpstream, "set-upstream-to", "", "Set the upstream of the branch")
+	branchUnsetUpstream := branchCommand.Bool("unset-upstream", false, "Remove the upstream of the branch")
 
 	checkoutCommand := flag.NewFlagSet("checkout", flag.ExitOnError)
 	checkoutBranch := checkoutCommand.String("b", "", "Switch to branch")
 
 	configCommand := flag.NewFlagSet("config", flag.ExitOnError)
 
-	// Parse command-line arguments
+	// Parse the global options that precede the command
 	flag.Parse()
 
 	// Ensure a command is provided
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: gitx <command> [options]")
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: gitx [-C <path>] [--gitx-dir=<path>] [--work-tree=<path>] <command> [options]")
 		os.Exit(1)
 	}
+	command := flag.Arg(0)
+	args := flag.Args()[1:]
 
 	// Execute the appropriate command
-	switch os.Args[1] {
+	switch command {
 	case "init":
-		initCommand.Parse(os.Args[2:])
+		initCommand.Parse(args)
 		if len(initCommand.Args()) != 1 {
-			fmt.Println("Usage: gitx init <repo-name>")
+			fmt.Println("Usage: gitx init [--object-format=<sha1|sha256>] <repo-name>")
 			os.Exit(1)
 		}
 		repoName := initCommand.Arg(0)
@@ -52,11 +94,18 @@ func main() {
 			log.Fatalf("Error getting current working directory: %v\n", err)
 		}
 		repoPath := filepath.Join(cwd, repoName)
-		file_operations.InitHandler(repoPath)
+		if _, err := gitx.InitWithOptions(repoPath, gitx.InitOptions{ObjectFormat: *initObjectFormat}); err != nil {
+			log.Fatalf("Error initializing repository: %v\n", err)
+		}
+
+		fmt.Printf("Initialized empty repository in %s\n", repoPath)
+		fmt.Println("Please configure your user information using the following commands:")
+		fmt.Println("  gitx config user.name 'Your Name'")
+		fmt.Println("  gitx config user.email 'your.email@example.com'")
 
 	case "config":
 		// Handle config command
-		configCommand.Parse(os.Args[2:])
+		configCommand.Parse(args)
 		if len(configCommand.Args()) != 2 {
 			fmt.Println("Usage: gitx config <key> <value>")
 			os.Exit(1)
@@ -64,42 +113,288 @@ func main() {
 		configKey := configCommand.Arg(0)
 		configValue := configCommand.Arg(1)
 
-		// Ensure the command looks for the correct config file
-		configFilePath := filepath.Join(".gitx", "config.toml")
-		file_operations.ConfigHandlerWithFilePath(configFilePath, configKey, configValue)
+		if err := file_operations.ConfigHandler(openRepository(), configKey, configValue); err != nil {
+			fmt.Println("Error updating config:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Config updated successfully!\n")
 
 	case "add":
 		// Handle add command
-		if len(os.Args) < 3 {
-			fmt.Println("Error: No file path provided for the 'add' command")
+		addCommand := flag.NewFlagSet("add", flag.ExitOnError)
+		var addOptions file_operations.AddOptions
+		addCommand.BoolVar(&addOptions.All, "A", false, "Add, modify and remove index entries to match the working tree")
+		addCommand.BoolVar(&addOptions.All, "all", false, "Add, modify and remove index entries to match the working tree")
+		addCommand.BoolVar(&addOptions.Update, "u", false, "Only update files that are already tracked")
+		addCommand.BoolVar(&addOptions.Update, "update", false, "Only update files that are already tracked")
+		addCommand.BoolVar(&addOptions.DryRun, "n", false, "Only show what would be added")
+		addCommand.BoolVar(&addOptions.DryRun, "dry-run", false, "Only show what would be added")
+		addCommand.BoolVar(&addOptions.Force, "f", false, "Allow adding ignored files")
+		addCommand.BoolVar(&addOptions.Force, "force", false, "Allow adding ignored files")
+		var addPatch bool
+		addCommand.BoolVar(&addPatch, "p", false, "Interactively pick hunks of tracked files to stage")
+		addCommand.BoolVar(&addPatch, "patch", false, "Interactively pick hunks of tracked files to stage")
+		addCommand.Parse(args)
+		if addCommand.NArg() == 0 && !addOptions.All && !addOptions.Update && !addPatch {
+			fmt.Println("Nothing specified, nothing added.")
+			fmt.Println("Usage: gitx add [-A | -u | -p] [-n] [-f] <pathspec>...")
 			os.Exit(1)
 		}
 
-		// Loop through all the provided file paths
-		for _, filePath := range os.Args[2:] {
+		repo := openRepository()
+
+		// Pathspecs are resolved against the current working directory
+		paths := make([]string, addCommand.NArg())
+		for i, filePath := range addCommand.Args() {
 			absFilePath, err := filepath.Abs(filePath)
 			if err != nil {
 				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
 				os.Exit(1)
 			}
-			err = file_operations.AddHandler(filepath.Join(".gitx", "INDEX"), absFilePath)
+			paths[i] = absFilePath
+		}
+
+		if addPatch {
+			selector := patch.NewSelector(os.Stdin, os.Stdout, "Stage")
+			selector.Edit = editor.Edit
+			if err := file_operations.AddPatch(repo, paths, selector); err != nil {
+				fmt.Println("Error adding hunks:", err)
+				os.Exit(1)
+			}
+			break
+		}
+
+		changes, err := file_operations.AddPathspecs(repo, paths, addOptions)
+		if errors.Is(err, models.ErrIgnored) {
+			fmt.Println("The following paths are ignored by one of your .gitxignore files:")
+			fmt.Println(strings.TrimPrefix(err.Error(), models.ErrIgnored.Error()+": "))
+			fmt.Println("Use -f if you really want to add them.")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Println("Error adding files:", err)
+			os.Exit(1)
+		}
+		if addOptions.DryRun {
+			for _, change := range changes {
+				if change.Removed {
+					fmt.Printf("remove '%s'\n", change.Path)
+				} else {
+					fmt.Printf("add '%s'\n", change.Path)
+				}
+			}
+		}
+
+	case "rm":
+		rmCommand := flag.NewFlagSet("rm", flag.ExitOnError)
+		var rmOptions file_operations.RemoveOptions
+		rmCommand.BoolVar(&rmOptions.Cached, "cached", false, "Only remove from the index")
+		rmCommand.BoolVar(&rmOptions.Recursive, "r", false, "Allow recursive removal")
+		rmCommand.BoolVar(&rmOptions.Force, "f", false, "Override the up-to-date check")
+		rmCommand.BoolVar(&rmOptions.Force, "force", false, "Override the up-to-date check")
+		rmCommand.BoolVar(&rmOptions.DryRun, "n", false, "Only show what would be removed")
+		rmCommand.BoolVar(&rmOptions.DryRun, "dry-run", false, "Only show what would be removed")
+		rmCommand.Parse(args)
+		if rmCommand.NArg() == 0 {
+			fmt.Println("Usage: gitx rm [--cached] [-r] [-f] [-n] <pathspec>...")
+			os.Exit(1)
+		}
+
+		paths := make([]string, rmCommand.NArg())
+		for i, filePath := range rmCommand.Args() {
+			absFilePath, err := filepath.Abs(filePath)
 			if err != nil {
-				fmt.Printf("Error adding file '%s': %v\n", filePath, err)
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
 				os.Exit(1)
 			}
+			paths[i] = absFilePath
+		}
+		removed, err := file_operations.RemoveHandler(openRepository(), paths, rmOptions)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		for _, filePath := range removed {
+			fmt.Printf("rm '%s'\n", filePath)
+		}
+
+	case "mv":
+		mvCommand := flag.NewFlagSet("mv", flag.ExitOnError)
+		mvForce := mvCommand.Bool("f", false, "Overwrite existing destination files")
+		mvCommand.Parse(args)
+		if mvCommand.NArg() < 2 {
+			fmt.Println("Usage: gitx mv [-f] <source>... <destination>")
+			os.Exit(1)
+		}
+
+		paths := make([]string, mvCommand.NArg())
+		for i, filePath := range mvCommand.Args() {
+			absFilePath, err := filepath.Abs(filePath)
+			if err != nil {
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			paths[i] = absFilePath
+		}
+		if err := file_operations.MoveHandler(openRepository(), paths[:len(paths)-1], paths[len(paths)-1], *mvForce); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+	case "reset":
+		resetCommand := flag.NewFlagSet("reset", flag.ExitOnError)
+		resetSoft := resetCommand.Bool("soft", false, "Only move the current branch")
+		resetMixed := resetCommand.Bool("mixed", false, "Reset the index but not the working tree (default)")
+		resetHard := resetCommand.Bool("hard", false, "Reset the index and the working tree")
+		var resetPatch bool
+		resetCommand.BoolVar(&resetPatch, "p", false, "Interactively pick hunks to unstage")
+		resetCommand.BoolVar(&resetPatch, "patch", false, "Interactively pick hunks to unstage")
+		resetCommand.Parse(args)
+
+		mode := file_operations.ResetMixed
+		switch {
+		case *resetSoft && !*resetMixed && !*resetHard:
+			mode = file_operations.ResetSoft
+		case *resetHard && !*resetSoft && !*resetMixed:
+			mode = file_operations.ResetHard
+		case *resetSoft || *resetHard:
+			fmt.Println("Error: --soft, --mixed and --hard are mutually exclusive")
+			os.Exit(1)
+		}
+
+		repo := openRepository()
+
+		// The revision comes first; "--" or a first argument that is not a revision starts the paths
+		rev := "HEAD"
+		var pathArgs []string
+		rest := resetCommand.Args()
+		separator := -1
+		for i, arg := range rest {
+			if arg == "--" {
+				separator = i
+				break
+			}
+		}
+		switch {
+		case separator > 1:
+			fmt.Println("Usage: gitx reset [--soft | --mixed | --hard | -p] [<rev>] [--] [<paths>...]")
+			os.Exit(1)
+		case separator >= 0:
+			if separator == 1 {
+				rev = rest[0]
+			}
+			pathArgs = rest[separator+1:]
+		case len(rest) > 0:
+			if _, err := vcs_operations.ResolveRevision(repo, rest[0]); err == nil {
+				rev, pathArgs = rest[0], rest[1:]
+			} else {
+				pathArgs = rest
+			}
+		}
+
+		paths := make([]string, len(pathArgs))
+		for i, filePath := range pathArgs {
+			absFilePath, err := filepath.Abs(filePath)
+			if err != nil {
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			paths[i] = absFilePath
+		}
+		if (len(paths) > 0 || resetPatch) && mode != file_operations.ResetMixed {
+			fmt.Printf("Error: cannot do a %s reset with paths\n", strings.TrimPrefix(mode.String(), "--"))
+			os.Exit(1)
+		}
+
+		switch {
+		case resetPatch:
+			selector := patch.NewSelector(os.Stdin, os.Stdout, "Unstage")
+			if err := file_operations.ResetPatch(repo, rev, paths, selector); err != nil {
+				fmt.Println("Error resetting hunks:", err)
+				os.Exit(1)
+			}
+		case len(paths) > 0:
+			if err := file_operations.ResetPaths(repo, rev, paths); err != nil {
+				fmt.Println("Error resetting paths:", err)
+				os.Exit(1)
+			}
+		default:
+			commit, err := file_operations.Reset(repo, rev, mode)
+			if err != nil {
+				fmt.Println("Error resetting:", err)
+				os.Exit(1)
+			}
+			if mode == file_operations.ResetHard {
+				subject, _, _ := strings.Cut(commit.Message, "\n")
+				fmt.Printf("HEAD is now at %s %s\n", commit.ID[:7], subject)
+			}
 		}
 
 	case "commit":
-		commitCommand.Parse(os.Args[2:])
-		if *commitMessage == "" {
-			fmt.Println("Error: Commit message is required for the 'commit' command")
+		commitCommand.Parse(args)
+		if commitCommand.NArg() > 0 || (*commitMessage != "" && commitFile != "") {
+			fmt.Println("Usage: gitx commit [-a] [--amend [--reset-author] [--no-edit]] [--allow-empty] [-n] [-S] [--fixup <commit>] [-message <msg> | -F <file>]")
 			os.Exit(1)
 		}
-		file_operations.CommitHandler(*commitMessage)
+		if commitOptions.ResetAuthor && !commitOptions.Amend {
+			fmt.Println("Error: --reset-author can only be used with --amend")
+			os.Exit(1)
+		}
+		if commitFile != "" {
+			var data []byte
+			var err error
+			if commitFile == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(commitFile)
+			}
+			if err != nil {
+				fmt.Println("Error reading commit message:", err)
+				os.Exit(1)
+			}
+			*commitMessage = strings.TrimRight(string(data), "\n")
+		}
+		// Without a message, one is written in the editor, except for fixups and amends that
+		// keep their message
+		if *commitMessage == "" && commitOptions.Fixup == "" && !(commitOptions.Amend && *commitNoEdit) {
+			commitOptions.Edit = editor.Edit
+		}
+		commit, err := file_operations.CommitHandler(openRepository(), *commitMessage, commitOptions)
+		if errors.Is(err, models.ErrNothingToCommit) && !commitOptions.Amend {
+			fmt.Println("Nothing to commit: the staging area matches the current commit")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Println("Error creating commit:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Commit created with ID: %s and message: %s\n", commit.ID, commit.Message)
 
 	case "branch":
 		// Parse the command line arguments starting from the second argument
-		branchCommand.Parse(os.Args[2:])
+		branchCommand.Parse(args)
+		repo := openRepository()
+
+		// Upstream changes apply to the named branch, or else the current one
+		if branchUpstream != "" || *branchUnsetUpstream {
+			branchName := branchCommand.Arg(0)
+			if branchName == "" {
+				current, err := vcs_operations.CurrentBranch(repo)
+				if err != nil {
+					fmt.Println("Error:", err)
+					os.Exit(1)
+				}
+				branchName = current
+			}
+			if err := file_operations.SetUpstream(repo, branchName, branchUpstream); err != nil {
+				fmt.Println("Error setting upstream:", err)
+				os.Exit(1)
+			}
+			if branchUpstream != "" {
+				fmt.Printf("branch '%s' set up to track '%s'.\n", branchName, branchUpstream)
+			}
+			break
+		}
 
 		// Check if the delete flag is set
 		if *branchDelete {
@@ -109,77 +404,758 @@ func main() {
 				os.Exit(1)
 			}
 			branchName := branchCommand.Arg(0)
-			err := vcs_operations.DeleteBranch(branchName)
+			err := vcs_operations.DeleteBranch(repo, branchName)
 			if err != nil {
 				fmt.Println("Error deleting branch:", err)
 				os.Exit(1)
 			}
+			fmt.Printf("Branch '%s' deleted successfully.\n", branchName)
 		} else {
 			// If the delete flag is not set, check for the branch name as a positional argument
 			if branchCommand.NArg() > 0 {
 				branchName := branchCommand.Arg(0)
 				// Create a new branch with the specified name
-				err := vcs_operations.CreateBranch(branchName)
+				err := vcs_operations.CreateBranch(repo, branchName)
 				if err != nil {
 					fmt.Println("Error creating branch:", err)
 					os.Exit(1)
 				}
+				fmt.Printf("Branch '%s' created successfully.\n", branchName)
 			} else {
 				// List all branches if no additional arguments are provided
-				vcs_operations.ListBranches()
+				if err := vcs_operations.ListBranches(repo, os.Stdout); err != nil {
+					fmt.Println("Error listing branches:", err)
+					os.Exit(1)
+				}
 			}
 		}
 
 	case "checkout":
-		checkoutCommand.Parse(os.Args[2:])
+		checkoutCommand.Parse(args)
+		repo := openRepository()
 		if *checkoutBranch != "" {
 			branchName := *checkoutBranch
 			// Try to create the branch if it does not exist
-			err := vcs_operations.CreateBranch(branchName)
-			if err != nil && !strings.Contains(err.Error(), "already exists") {
+			err := vcs_operations.CreateBranch(repo, branchName)
+			if err != nil && !errors.Is(err, models.ErrBranchExists) {
 				fmt.Println("Error creating branch:", err)
 				os.Exit(1)
 			}
 			// Switch to the branch
-			err = vcs_operations.SwitchBranch(branchName)
+			err = vcs_operations.SwitchBranch(repo, branchName)
 			if err != nil {
 				fmt.Println("Error switching to branch:", err)
 				os.Exit(1)
 			}
+			fmt.Printf("Switched to branch '%s'\n", branchName)
 		} else if len(checkoutCommand.Args()) != 1 {
 			fmt.Println("Usage: gitx checkout [-b] <branch-name>")
 			os.Exit(1)
 		} else {
 			branchName := checkoutCommand.Arg(0)
-			err := vcs_operations.SwitchBranch(branchName)
+			err := vcs_operations.SwitchBranch(repo, branchName)
 			if err != nil {
 				fmt.Println("Error switching to branch:", err)
 				os.Exit(1)
 			}
+			fmt.Printf("Switched to branch '%s'\n", branchName)
+		}
+
+	case "switch":
+		switchCommand := flag.NewFlagSet("switch", flag.ExitOnError)
+		var switchCreate bool
+		switchCommand.BoolVar(&switchCreate, "c", false, "Create the branch before switching to it")
+		switchCommand.BoolVar(&switchCreate, "create", false, "Create the branch before switching to it")
+		switchCommand.Parse(args)
+		if switchCommand.NArg() < 1 || switchCommand.NArg() > 2 || (switchCommand.NArg() == 2 && !switchCreate) {
+			fmt.Println("Usage: gitx switch [-c] <branch> [<start-point>]")
+			os.Exit(1)
+		}
+
+		branchName := switchCommand.Arg(0)
+		err := file_operations.SwitchHandler(openRepository(), branchName, switchCreate, switchCommand.Arg(1))
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if switchCreate {
+			fmt.Printf("Switched to a new branch '%s'\n", branchName)
+		} else {
+			fmt.Printf("Switched to branch '%s'\n", branchName)
+		}
+
+	case "restore":
+		restoreCommand := flag.NewFlagSet("restore", flag.ExitOnError)
+		var restoreOptions file_operations.RestoreOptions
+		restoreCommand.BoolVar(&restoreOptions.Staged, "staged", false, "Restore the index")
+		restoreCommand.BoolVar(&restoreOptions.Staged, "S", false, "Restore the index")
+		restoreCommand.BoolVar(&restoreOptions.Worktree, "worktree", false, "Restore the working tree (default)")
+		restoreCommand.BoolVar(&restoreOptions.Worktree, "W", false, "Restore the working tree (default)")
+		restoreCommand.StringVar(&restoreOptions.Source, "source", "", "Restore from the given revision")
+		restoreCommand.StringVar(&restoreOptions.Source, "s", "", "Restore from the given revision")
+		restoreCommand.Parse(args)
+		if restoreCommand.NArg() == 0 {
+			fmt.Println("Usage: gitx restore [--staged] [--worktree] [--source=<rev>] <pathspec>...")
+			os.Exit(1)
+		}
+
+		paths := make([]string, restoreCommand.NArg())
+		for i, filePath := range restoreCommand.Args() {
+			absFilePath, err := filepath.Abs(filePath)
+			if err != nil {
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			paths[i] = absFilePath
+		}
+		if err := file_operations.RestoreHandler(openRepository(), paths, restoreOptions); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
 		}
 
 	case "log":
-		vcs_operations.LogHandler()
+		logCommand := flag.NewFlagSet("log", flag.ExitOnError)
+		showSignature := logCommand.Bool("show-signature", false, "Check the signature of each signed commit")
+		logCommand.Parse(args)
+		repo := openRepository()
+		var logOptions vcs_operations.LogOptions
+		if *showSignature {
+			logOptions.Signature = func(commit *models.Commit) (string, error) {
+				check, err := file_operations.CheckCommitSignature(repo, commit)
+				if err != nil || check.Status == file_operations.SignatureNone {
+					return "", err
+				}
+				return check.String(), nil
+			}
+		}
+		if err := vcs_operations.LogHandler(repo, os.Stdout, logOptions); err != nil {
+			fmt.Println("Error reading commit history:", err)
+			os.Exit(1)
+		}
 
 	case "status":
-		file_operations.StatusHandler()
+		statusCommand := flag.NewFlagSet("status", flag.ExitOnError)
+		var short bool
+		var porcelain formatVersion
+		var statusOptions file_operations.StatusOptions
+		statusCommand.BoolVar(&short, "s", false, "Give the output in the short format")
+		statusCommand.BoolVar(&short, "short", false, "Give the output in the short format")
+		statusCommand.Var(&porcelain, "porcelain", "Give the output in a stable format for scripts, `v1` or v2")
+		statusCommand.BoolVar(&statusOptions.Branch, "b", false, "Show the branch and its upstream")
+		statusCommand.BoolVar(&statusOptions.Branch, "branch", false, "Show the branch and its upstream")
+		statusCommand.Parse(args)
+
+		switch {
+		case porcelain == "v2":
+			statusOptions.Format = file_operations.StatusPorcelainV2
+		case porcelain == "v1":
+			statusOptions.Format = file_operations.StatusPorcelain
+		case short:
+			statusOptions.Format = file_operations.StatusShort
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Println("Error getting current working directory:", err)
+			os.Exit(1)
+		}
+		if err := file_operations.StatusHandler(openRepository(), os.Stdout, cwd, statusOptions); err != nil {
+			fmt.Println("Error reading status:", err)
+			os.Exit(1)
+		}
+
+	case "clean":
+		cleanCommand := flag.NewFlagSet("clean", flag.ExitOnError)
+		var cleanOptions file_operations.CleanOptions
+		cleanCommand.BoolVar(&cleanOptions.DryRun, "n", false, "Only show what would be removed")
+		cleanCommand.BoolVar(&cleanOptions.DryRun, "dry-run", false, "Only show what would be removed")
+		cleanCommand.BoolVar(&cleanOptions.Force, "f", false, "Remove the untracked files")
+		cleanCommand.BoolVar(&cleanOptions.Force, "force", false, "Remove the untracked files")
+		cleanCommand.BoolVar(&cleanOptions.Directories, "d", false, "Also remove untracked directories")
+		cleanCommand.BoolVar(&cleanOptions.IncludeIgnored, "x", false, "Also remove ignored files")
+		cleanCommand.BoolVar(&cleanOptions.OnlyIgnored, "X", false, "Only remove ignored files")
+		cleanCommand.Parse(args)
+
+		paths := make([]string, cleanCommand.NArg())
+		for i, filePath := range cleanCommand.Args() {
+			absFilePath, err := filepath.Abs(filePath)
+			if err != nil {
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			paths[i] = absFilePath
+		}
+		removed, err := file_operations.CleanHandler(openRepository(), paths, cleanOptions)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		for _, filePath := range removed {
+			if cleanOptions.DryRun {
+				fmt.Printf("Would remove %s\n", filePath)
+			} else {
+				fmt.Printf("Removing %s\n", filePath)
+			}
+		}
+
+	case "check-ignore":
+		// Report which paths are ignored and, with -v, the pattern deciding it
+		checkIgnoreCommand := flag.NewFlagSet("check-ignore", flag.ExitOnError)
+		var verbose, nonMatching bool
+		checkIgnoreCommand.BoolVar(&verbose, "v", false, "Show the matching pattern for each path")
+		checkIgnoreCommand.BoolVar(&verbose, "verbose", false, "Show the matching pattern for each path")
+		checkIgnoreCommand.BoolVar(&nonMatching, "n", false, "Also show paths that match no pattern (with -v)")
+		checkIgnoreCommand.BoolVar(&nonMatching, "non-matching", false, "Also show paths that match no pattern (with -v)")
+		checkIgnoreCommand.Parse(args)
+		if checkIgnoreCommand.NArg() == 0 {
+			fmt.Println("Usage: gitx check-ignore [-v] [-n] <path>...")
+			os.Exit(1)
+		}
+
+		paths := make([]string, checkIgnoreCommand.NArg())
+		for i, filePath := range checkIgnoreCommand.Args() {
+			absFilePath, err := filepath.Abs(filePath)
+			if err != nil {
+				fmt.Printf("Error getting absolute path for file '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			paths[i] = absFilePath
+		}
+		matches, err := file_operations.CheckIgnore(openRepository(), paths)
+		if err != nil {
+			fmt.Println("Error checking ignore rules:", err)
+			os.Exit(1)
+		}
+
+		// Like Git, exit with 1 when none of the paths is ignored
+		ignored := false
+		for i, pattern := range matches {
+			filePath := checkIgnoreCommand.Arg(i)
+			switch {
+			case pattern != nil && verbose:
+				fmt.Printf("%s:%d:%s\t%s\n", pattern.Source, pattern.Line, pattern.Text, filePath)
+			case pattern != nil && !pattern.Negate:
+				fmt.Println(filePath)
+			case pattern == nil && verbose && nonMatching:
+				fmt.Printf("::\t%s\n", filePath)
+			}
+			if pattern != nil && !pattern.Negate {
+				ignored = true
+			}
+		}
+		if !ignored {
+			os.Exit(1)
+		}
 
 	case "merge":
 		// Define flags for merge command
 		mergeCommand := flag.NewFlagSet("merge", flag.ExitOnError)
 		mergeBranchName := mergeCommand.String("branch", "", "Branch name to merge")
+		mergeNoVerify := mergeCommand.Bool("no-verify", false, "Skip the pre-merge hook")
 
-		// Parse flags for merge command
-		mergeCommand.Parse(os.Args[2:])
-		if len(os.Args) != 4 {
-			fmt.Println("Usage: gitx merge -branch <branch-name>")
+		// Parse flags for merge command; the branch may also be given as an argument
+		mergeCommand.Parse(args)
+		if *mergeBranchName == "" && mergeCommand.NArg() == 1 {
+			*mergeBranchName = mergeCommand.Arg(0)
+		}
+		if *mergeBranchName == "" || mergeCommand.NArg() > 1 {
+			fmt.Println("Usage: gitx merge [--no-verify] <branch-name>")
 			os.Exit(1)
 		}
-		// Call MergeBranch function from the vcs_operations package
-		if err := vcs_operations.MergeBranch(*mergeBranchName); err != nil {
+		result, err := file_operations.MergeHandler(openRepository(), *mergeBranchName, file_operations.MergeOptions{NoVerify: *mergeNoVerify})
+		if err != nil {
+			if errors.Is(err, models.ErrConflict) {
+				fmt.Println("Merge conflicts detected. Please resolve them manually.")
+			}
 			fmt.Printf("Error merging branch: %v\n", err)
 			os.Exit(1)
 		}
+		switch {
+		case result.UpToDate:
+			fmt.Println("Already up to date.")
+		case result.FastForward:
+			fmt.Printf("Fast-forward to %s\n", result.Commit.ID[:7])
+		default:
+			fmt.Printf("Merged branch %s\n", *mergeBranchName)
+		}
+
+	case "cherry-pick", "revert":
+		pickCommand := flag.NewFlagSet(command, flag.ExitOnError)
+		pickContinue := pickCommand.Bool("continue", false, "Continue after resolving conflicts")
+		pickSkip := pickCommand.Bool("skip", false, "Skip the commit that stopped and continue with the rest")
+		pickAbort := pickCommand.Bool("abort", false, "Cancel and return to the commit before the sequence")
+		var pickOptions file_operations.PickOptions
+		pickCommand.IntVar(&pickOptions.Mainline, "m", 0, "Parent number of the mainline of a merge commit, starting from 1")
+		pickCommand.IntVar(&pickOptions.Mainline, "mainline", 0, "Parent number of the mainline of a merge commit, starting from 1")
+		if command == "cherry-pick" {
+			pickCommand.BoolVar(&pickOptions.RecordOrigin, "x", false, "Record the picked commit in the message")
+		}
+		pickCommand.Parse(args)
+		repo := openRepository()
+
+		var result *file_operations.PickResult
+		var err error
+		switch {
+		case *pickContinue:
+			result, err = file_operations.ContinueSequence(repo, command)
+		case *pickSkip:
+			result, err = file_operations.SkipSequence(repo, command)
+		case *pickAbort:
+			err = file_operations.AbortSequence(repo, command)
+		case pickCommand.NArg() == 0:
+			fmt.Printf("Usage: gitx %s [-m <parent>] [-x] <commit>...\n       gitx %s (--continue | --skip | --abort)\n", command, command)
+			os.Exit(1)
+		case command == "revert":
+			result, err = file_operations.Revert(repo, pickCommand.Args(), pickOptions)
+		default:
+			result, err = file_operations.CherryPick(repo, pickCommand.Args(), pickOptions)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if result != nil {
+			os.Exit(printPickResult(command, result))
+		}
+
+	case "rebase":
+		rebaseCommand := flag.NewFlagSet("rebase", flag.ExitOnError)
+		rebaseContinue := rebaseCommand.Bool("continue", false, "Continue after resolving conflicts or editing a commit")
+		rebaseSkip := rebaseCommand.Bool("skip", false, "Skip the commit that stopped and continue with the rest")
+		rebaseAbort := rebaseCommand.Bool("abort", false, "Cancel and return the branch to where it was before the rebase")
+		rebaseEditTodo := rebaseCommand.Bool("edit-todo", false, "Edit the instructions left to execute")
+		rebaseOptions := file_operations.RebaseOptions{Edit: editor.Edit, Stdout: os.Stdout, Stderr: os.Stderr}
+		rebaseCommand.BoolVar(&rebaseOptions.Interactive, "i", false, "Edit the list of commits to replay before rebasing")
+		rebaseCommand.BoolVar(&rebaseOptions.Interactive, "interactive", false, "Edit the list of commits to replay before rebasing")
+		rebaseCommand.BoolVar(&rebaseOptions.Autosquash, "autosquash", false, "Move \"fixup!\" and \"squash!\" commits after the commits they fix")
+		rebaseCommand.StringVar(&rebaseOptions.Onto, "onto", "", "Replay the commits on this commit instead of the upstream")
+		rebaseCommand.Parse(args)
+		if rebaseCommand.NArg() > 1 {
+			fmt.Println("Usage: gitx rebase [-i] [--autosquash] [--onto <newbase>] [<upstream>]\n       gitx rebase (--continue | --skip | --abort | --edit-todo)")
+			os.Exit(1)
+		}
+		repo := openRepository()
+
+		var result *file_operations.RebaseResult
+		var err error
+		switch {
+		case *rebaseContinue:
+			result, err = file_operations.ContinueRebase(repo, rebaseOptions)
+		case *rebaseSkip:
+			result, err = file_operations.SkipRebase(repo, rebaseOptions)
+		case *rebaseAbort:
+			err = file_operations.AbortRebase(repo)
+		case *rebaseEditTodo:
+			err = file_operations.EditRebaseTodo(repo, rebaseOptions)
+		default:
+			result, err = file_operations.Rebase(repo, rebaseCommand.Arg(0), rebaseOptions)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if result != nil {
+			os.Exit(printRebaseResult(result))
+		}
+
+	case "merge-base":
+		mergeBaseCommand := flag.NewFlagSet("merge-base", flag.ExitOnError)
+		isAncestor := mergeBaseCommand.Bool("is-ancestor", false, "Exit with status 0 if the first commit is an ancestor of the second")
+		mergeBaseCommand.Parse(args)
+		if mergeBaseCommand.NArg() != 2 {
+			fmt.Println("Usage: gitx merge-base [--is-ancestor] <commit> <commit>")
+			os.Exit(1)
+		}
+		repo := openRepository()
+		var ids [2]string
+		for i, rev := range mergeBaseCommand.Args() {
+			id, err := vcs_operations.ResolveRevision(repo, rev)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			ids[i] = id
+		}
+		if *isAncestor {
+			ancestor, err := vcs_operations.IsAncestor(repo, ids[0], ids[1])
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			if !ancestor {
+				os.Exit(1)
+			}
+			break
+		}
+		base, err := vcs_operations.MergeBase(repo, ids[0], ids[1])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if base == "" {
+			os.Exit(1)
+		}
+		fmt.Println(base)
+
+	case "ls-tree":
+		lsTreeCommand := flag.NewFlagSet("ls-tree", flag.ExitOnError)
+		var lsTreeOptions file_operations.LsTreeOptions
+		lsTreeCommand.BoolVar(&lsTreeOptions.Recursive, "r", false, "Recurse into subdirectories")
+		nameOnly := lsTreeCommand.Bool("name-only", false, "Only show the names of the entries")
+		nulTerminated := lsTreeCommand.Bool("z", false, "Terminate entries with NUL instead of a newline")
+		lsTreeCommand.Parse(args)
+		if lsTreeCommand.NArg() == 0 {
+			fmt.Println("Usage: gitx ls-tree [-r] [--name-only] [-z] <tree-ish> [<path>...]")
+			os.Exit(1)
+		}
+		entries, err := file_operations.LsTree(openRepository(), lsTreeCommand.Arg(0), lsTreeCommand.Args()[1:], lsTreeOptions)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		terminator := lineTerminator(*nulTerminated)
+		for _, entry := range entries {
+			if *nameOnly {
+				fmt.Print(entry.Name + terminator)
+			} else {
+				fmt.Printf("%s %s %s\t%s%s", entry.Mode, entry.Type, entry.ID, entry.Name, terminator)
+			}
+		}
+
+	case "ls-files":
+		lsFilesCommand := flag.NewFlagSet("ls-files", flag.ExitOnError)
+		var lsFilesOptions file_operations.LsFilesOptions
+		var stage bool
+		lsFilesCommand.BoolVar(&stage, "s", false, "Show the mode, object ID and stage of files in the INDEX")
+		lsFilesCommand.BoolVar(&stage, "stage", false, "Show the mode, object ID and stage of files in the INDEX")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Modified, "m", false, "Show files with changes in the working tree")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Modified, "modified", false, "Show files with changes in the working tree")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Others, "o", false, "Show untracked files")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Others, "others", false, "Show untracked files")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Ignored, "i", false, "Show ignored files")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Ignored, "ignored", false, "Show ignored files")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Unmerged, "u", false, "Show files with unresolved conflicts, with their stage")
+		lsFilesCommand.BoolVar(&lsFilesOptions.Unmerged, "unmerged", false, "Show files with unresolved conflicts, with their stage")
+		nulTerminated := lsFilesCommand.Bool("z", false, "Terminate entries with NUL instead of a newline")
+		lsFilesCommand.Parse(args)
+		entries, err := file_operations.LsFiles(openRepository(), lsFilesCommand.Args(), lsFilesOptions)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		terminator := lineTerminator(*nulTerminated)
+		for _, entry := range entries {
+			if (stage || lsFilesOptions.Unmerged) && entry.Hash != "" {
+				fmt.Printf("%s %s %d\t%s%s", entry.Mode, entry.Hash, entry.Stage, entry.Path, terminator)
+			} else {
+				fmt.Print(entry.Path + terminator)
+			}
+		}
+
+	case "hash-object":
+		hashObjectCommand := flag.NewFlagSet("hash-object", flag.ExitOnError)
+		write := hashObjectCommand.Bool("w", false, "Write the object into the object store")
+		fromStdin := hashObjectCommand.Bool("stdin", false, "Read the object from standard input")
+		hashObjectCommand.Parse(args)
+		if !*fromStdin && hashObjectCommand.NArg() == 0 {
+			fmt.Println("Usage: gitx hash-object [-w] [--stdin] [<file>...]")
+			os.Exit(1)
+		}
+
+		// Only writing needs a repository. Outside of one, IDs are computed with SHA-1
+		var store gitx.FS
+		alg := hash.SHA1
+		if *write {
+			repo := openRepository()
+			store, alg = repo.Store, repo.Hash()
+		} else if repo, err := gitx.Discover("."); err == nil {
+			alg = repo.Hash()
+		}
+		if *fromStdin {
+			id, err := hashStdin(alg, store)
+			if err != nil {
+				fmt.Println("Error hashing standard input:", err)
+				os.Exit(1)
+			}
+			fmt.Println(id)
+		}
+		for _, filePath := range hashObjectCommand.Args() {
+			id, err := hashObjectFile(alg, store, filePath)
+			if err != nil {
+				fmt.Printf("Error hashing '%s': %v\n", filePath, err)
+				os.Exit(1)
+			}
+			fmt.Println(id)
+		}
+
+	case "commit-graph":
+		if len(args) != 1 || args[0] != "write" {
+			fmt.Println("Usage: gitx commit-graph write")
+			os.Exit(1)
+		}
+		count, err := vcs_operations.WriteCommitGraph(openRepository())
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote commit-graph with %d commits\n", count)
+
+	case "merkle":
+		if len(args) == 0 {
+			fmt.Println("Usage: gitx merkle root [<rev>] | prove <commit> [<rev>] | record [--tag <name> [-s]] [<branch>] | verify [--root <hash>] <proof-file>")
+			os.Exit(1)
+		}
+		switch args[0] {
+		case "root":
+			rev := "HEAD"
+			if len(args) > 1 {
+				rev = args[1]
+			}
+			root, count, err := vcs_operations.MerkleRoot(openRepository(), rev)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s %d\n", root, count)
+		case "prove":
+			if len(args) < 2 || len(args) > 3 {
+				fmt.Println("Usage: gitx merkle prove <commit> [<rev>]")
+				os.Exit(1)
+			}
+			rev := "HEAD"
+			if len(args) == 3 {
+				rev = args[2]
+			}
+			proof, err := vcs_operations.MerkleProof(openRepository(), rev, args[1])
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			data, err := json.MarshalIndent(proof, "", "  ")
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		case "record":
+			recordCommand := flag.NewFlagSet("merkle record", flag.ExitOnError)
+			recordTag := recordCommand.String("tag", "", "Also record the root in an annotated tag of this name")
+			recordSign := recordCommand.Bool("s", false, "Sign the tag")
+			recordCommand.Parse(args[1:])
+			if recordCommand.NArg() > 1 || (*recordSign && *recordTag == "") {
+				fmt.Println("Usage: gitx merkle record [--tag <name> [-s]] [<branch>]")
+				os.Exit(1)
+			}
+			repo := openRepository()
+			note, err := vcs_operations.RecordMerkleRoot(repo, recordCommand.Arg(0))
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Recorded root %s of %d commits at %s\n", note.Root, note.Size, note.Commit)
+			if *recordTag != "" {
+				tagOptions := file_operations.TagOptions{Annotate: true, Sign: *recordSign, Message: vcs_operations.MerkleTagMessage(note)}
+				if _, err := file_operations.TagHandler(repo, *recordTag, note.Commit, tagOptions); err != nil {
+					fmt.Println("Error:", err)
+					os.Exit(1)
+				}
+			}
+		case "verify":
+			// Verifying only needs the proof, not a repository
+			verifyCommand := flag.NewFlagSet("merkle verify", flag.ExitOnError)
+			expectedRoot := verifyCommand.String("root", "", "Published root the proof must lead to")
+			verifyCommand.Parse(args[1:])
+			if verifyCommand.NArg() != 1 {
+				fmt.Println("Usage: gitx merkle verify [--root <hash>] <proof-file>")
+				os.Exit(1)
+			}
+			var data []byte
+			var err error
+			if verifyCommand.Arg(0) == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(verifyCommand.Arg(0))
+			}
+			if err != nil {
+				fmt.Println("Error reading proof:", err)
+				os.Exit(1)
+			}
+			var proof merkle.Proof
+			if err := json.Unmarshal(data, &proof); err != nil {
+				fmt.Println("Error parsing proof:", err)
+				os.Exit(1)
+			}
+			if err := proof.Verify(); err != nil {
+				fmt.Println("Invalid proof:", err)
+				os.Exit(1)
+			}
+			if *expectedRoot != "" && proof.Root != *expectedRoot {
+				fmt.Printf("Invalid proof: it leads to root %s, not %s\n", proof.Root, *expectedRoot)
+				os.Exit(1)
+			}
+			fmt.Printf("Commit %s is leaf %d of %d under root %s\n", proof.Commit, proof.Index, proof.Size, proof.Root)
+			if *expectedRoot == "" {
+				fmt.Println("Compare the root with the published one, or pass it with --root.")
+			}
+		default:
+			fmt.Printf("Unknown merkle command: %s\n", args[0])
+			os.Exit(1)
+		}
+
+	case "verify-history":
+		verifyHistoryCommand := flag.NewFlagSet("verify-history", flag.ExitOnError)
+		publishedRoot := verifyHistoryCommand.String("root", "", "Published Merkle root to check the history against")
+		verifyHistoryCommand.Parse(args)
+		if verifyHistoryCommand.NArg() > 1 {
+			fmt.Println("Usage: gitx verify-history [--root <hash>] [<branch>]")
+			os.Exit(1)
+		}
+		repo := openRepository()
+		branch := verifyHistoryCommand.Arg(0)
+		if branch == "" {
+			var err error
+			if branch, err = vcs_operations.CurrentBranch(repo); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+
+		// Without a root, every root recorded for the branch is checked
+		roots := []string{*publishedRoot}
+		if *publishedRoot == "" {
+			notes, err := vcs_operations.MerkleNotes(repo)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			roots = nil
+			for _, note := range notes {
+				if note.Ref == "refs/heads/"+branch {
+					roots = append(roots, note.Root)
+				}
+			}
+			if len(roots) == 0 {
+				fmt.Printf("No roots recorded for branch %s (use \"gitx merkle record\")\n", branch)
+				break
+			}
+		}
+
+		rewritten := false
+		checked := make(map[string]bool)
+		for _, root := range roots {
+			// A root may be recorded both in a note and in a tag
+			if checked[root] {
+				continue
+			}
+			checked[root] = true
+			check, err := vcs_operations.VerifyHistory(repo, branch, root)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			switch {
+			case check.Intact:
+				fmt.Printf("%s: intact, %d commits up to %s\n", root, check.Size, check.Commit)
+			case check.Published == "":
+				rewritten = true
+				fmt.Printf("%s: history was rewritten; no note records the commits under this root\n", root)
+			case check.Current == "":
+				rewritten = true
+				fmt.Printf("%s: history was rewritten; commit %s at position %d is missing\n", root, check.Published, check.Diverged)
+			default:
+				rewritten = true
+				fmt.Printf("%s: history was rewritten; first divergent commit at position %d: %s, published as %s\n",
+					root, check.Diverged, check.Current, check.Published)
+			}
+		}
+		if rewritten {
+			os.Exit(1)
+		}
+
+	case "tag":
+		tagCommand := flag.NewFlagSet("tag", flag.ExitOnError)
+		var tagOptions file_operations.TagOptions
+		tagCommand.BoolVar(&tagOptions.Annotate, "a", false, "Create an annotated tag")
+		tagCommand.BoolVar(&tagOptions.Sign, "s", false, "Create a signed annotated tag")
+		tagCommand.StringVar(&tagOptions.Message, "m", "", "Message of the annotated tag")
+		tagCommand.BoolVar(&tagOptions.Force, "f", false, "Replace an existing tag")
+		tagDelete := tagCommand.Bool("d", false, "Delete the tag")
+		tagVerify := tagCommand.Bool("v", false, "Verify the signature of the tag")
+		tagCommand.Parse(args)
+		repo := openRepository()
+
+		switch {
+		case *tagVerify:
+			if tagCommand.NArg() == 0 {
+				fmt.Println("Usage: gitx tag -v <tagname>...")
+				os.Exit(1)
+			}
+			os.Exit(verifyTags(repo, tagCommand.Args()))
+		case *tagDelete:
+			if tagCommand.NArg() == 0 {
+				fmt.Println("Usage: gitx tag -d <tagname>...")
+				os.Exit(1)
+			}
+			for _, name := range tagCommand.Args() {
+				id, err := vcs_operations.ReadTagRef(repo, name)
+				if err == nil {
+					err = vcs_operations.DeleteTagRef(repo, name)
+				}
+				if err != nil {
+					fmt.Println("Error:", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Deleted tag '%s' (was %s)\n", name, id[:7])
+			}
+		case tagCommand.NArg() == 0:
+			tags, err := vcs_operations.GetTags(repo)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			for _, name := range tags {
+				fmt.Println(name)
+			}
+		default:
+			if tagCommand.NArg() > 2 {
+				fmt.Println("Usage: gitx tag [-a | -s] [-m <msg>] [-f] <tagname> [<commit>]")
+				os.Exit(1)
+			}
+			if _, err := file_operations.TagHandler(repo, tagCommand.Arg(0), tagCommand.Arg(1), tagOptions); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+
+	case "verify-tag":
+		if len(args) == 0 {
+			fmt.Println("Usage: gitx verify-tag <tag>...")
+			os.Exit(1)
+		}
+		os.Exit(verifyTags(openRepository(), args))
+
+	case "verify-commit":
+		if len(args) == 0 {
+			fmt.Println("Usage: gitx verify-commit <commit>...")
+			os.Exit(1)
+		}
+		repo := openRepository()
+		status := 0
+		for _, rev := range args {
+			commit, check, err := file_operations.VerifyCommit(repo, rev)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			if check.Status == file_operations.SignatureNone {
+				fmt.Printf("Commit %s is not signed\n", commit.ID)
+			} else {
+				fmt.Println(check)
+			}
+			if check.Status != file_operations.SignatureGood {
+				status = 1
+			}
+		}
+		os.Exit(status)
 
 	case "squash":
 		// Define flags for squash command
@@ -188,8 +1164,8 @@ func main() {
 		targetCommit := squashCommand.String("target-commit", "", "Target commit")
 
 		// Parse flags for squash command
-		squashCommand.Parse(os.Args[2:])
-		if len(os.Args) != 6 {
+		squashCommand.Parse(args)
+		if len(args) != 4 {
 			fmt.Println("Usage: gitx squash -base-commit <base-commit> -target-commit <target-commit>")
 			os.Exit(1)
 		}
@@ -200,35 +1176,236 @@ func main() {
 		}
 
 	case "stash":
-		if err := vcs_operations.Stash(); err != nil {
+		stashDir, err := vcs_operations.Stash(openRepository())
+		if err != nil {
 			fmt.Printf("Error stashing changes: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Printf("Stashed changes in %s\n", stashDir)
 		fmt.Println("Changes stashed successfully")
 
 	case "cat-file":
-		// Define flags for cat-file command
 		catFileCommand := flag.NewFlagSet("cat-file", flag.ExitOnError)
-		objectType := catFileCommand.String("type", "", "Object type")
-
-		// Parse flags for cat-file command
-		catFileCommand.Parse(os.Args[2:])
-		if len(os.Args) != 4 {
-			fmt.Println("Usage: gitx cat-file -type <object-type>")
+		showType := catFileCommand.Bool("t", false, "Show the type of the object")
+		showSize := catFileCommand.Bool("s", false, "Show the size of the object in bytes")
+		pretty := catFileCommand.Bool("p", false, "Show the content of the object")
+		catFileCommand.Parse(args)
+		var format byte
+		switch {
+		case *showType:
+			format = 't'
+		case *showSize:
+			format = 's'
+		case *pretty:
+			format = 'p'
+		}
+		if format == 0 || catFileCommand.NArg() != 1 {
+			fmt.Println("Usage: gitx cat-file (-t | -s | -p) <object>")
 			os.Exit(1)
 		}
-		// Call the CatFile function from the vcs_operations package
-		if err := vcs_operations.CatFile(*objectType); err != nil {
-			fmt.Printf("Error executing cat-file: %v\n", err)
+		if err := vcs_operations.CatFile(openRepository(), os.Stdout, catFileCommand.Arg(0), format); err != nil {
+			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
 
+	case "show":
+		showCommand := flag.NewFlagSet("show", flag.ExitOnError)
+		showCommand.Parse(args)
+		names := showCommand.Args()
+		if len(names) == 0 {
+			names = []string{"HEAD"}
+		}
+		repo := openRepository()
+		for i, name := range names {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := file_operations.Show(repo, os.Stdout, name); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+
 	case "reflog":
 		// Call ReflogHandler from the vcs_operations package
-		vcs_operations.ReflogHandler()
+		if err := vcs_operations.ReflogHandler(openRepository(), os.Stdout); err != nil {
+			fmt.Println("Error reading reflog:", err)
+			os.Exit(1)
+		}
+
+	case "fsck":
+		// Verify the integrity of the repository and print one line per problem
+		report, err := vcs_operations.Fsck(openRepository())
+		if err != nil {
+			fmt.Printf("Error checking repository: %v\n", err)
+			os.Exit(1)
+		}
+		for _, problem := range report.Problems {
+			fmt.Println(problem)
+		}
+		if report.HasErrors() {
+			os.Exit(1)
+		}
 
 	default:
-		fmt.Printf("gitx: %s is not a valid command\n", os.Args[1])
+		fmt.Printf("gitx: %s is not a valid command\n", command)
 		os.Exit(1)
 	}
 }
+
+// openRepository opens the repository selected by the global options, or else the one
+// found by walking up from the current working directory.
+func openRepository() *models.Repository {
+	var repo *gitx.Repository
+	var err error
+	if *gitxDirOption != "" {
+		repo, err = gitx.OpenDir(*gitxDirOption, *workTreeOption)
+	} else {
+		repo, err = gitx.Discover(".")
+		if err == nil && *workTreeOption != "" {
+			repo, err = gitx.OpenDir(repo.GitxDir, *workTreeOption)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	return repo.Repository
+}
+
+// hashStdin hashes standard input with the given algorithm, storing it if store is not nil. The
+// content is spooled to learn its length: to the store, or to a temporary directory when there
+// is none.
+func hashStdin(alg *hash.Algorithm, store gitx.FS) (string, error) {
+	if store != nil {
+		return hash.HashStream(alg, store, os.Stdin, true)
+	}
+	dir, err := os.MkdirTemp("", "gitx-hash-object-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	return hash.HashStream(alg, gitx.NewOSFS(dir), os.Stdin, false)
+}
+
+// lineTerminator returns what ends each entry of a listing: NUL with -z, a newline otherwise.
+func lineTerminator(nul bool) string {
+	if nul {
+		return "\x00"
+	}
+	return "\n"
+}
+
+// printPickResult reports what a cherry-pick or revert did and returns the exit status: 1 if
+// it stopped on a conflict.
+func printPickResult(command string, result *file_operations.PickResult) int {
+	for _, commit := range result.Commits {
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		fmt.Printf("[%s] %s\n", commit.ID[:7], subject)
+	}
+	for _, id := range result.Skipped {
+		fmt.Printf("Skipped %s\n", id[:7])
+	}
+	if result.Stopped == nil {
+		return 0
+	}
+
+	subject, _, _ := strings.Cut(result.Stopped.Message, "\n")
+	for _, filePath := range result.Conflicts {
+		fmt.Printf("CONFLICT: Merge conflict in %s\n", filePath)
+	}
+	fmt.Printf("Could not apply %s... %s\n", result.Stopped.ID[:7], subject)
+	fmt.Printf("Resolve the conflicts, mark them with \"gitx add <path>\" and run \"gitx %s --continue\".\n", command)
+	fmt.Printf("Use \"gitx %s --skip\" to drop this commit, or \"gitx %s --abort\" to cancel.\n", command, command)
+	return 1
+}
+
+// printRebaseResult reports where a rebase got to and returns the exit status: 1 if it
+// stopped on a conflict or a failed exec.
+func printRebaseResult(result *file_operations.RebaseResult) int {
+	switch {
+	case result.UpToDate:
+		fmt.Println("Current branch is up to date.")
+		return 0
+	case result.Stopped == "":
+		fmt.Printf("Successfully rebased, now at %s.\n", result.Head[:7])
+		return 0
+	case len(result.Conflicts) > 0:
+		for _, filePath := range result.Conflicts {
+			fmt.Printf("CONFLICT: Merge conflict in %s\n", filePath)
+		}
+		fmt.Printf("Could not apply: %s\n", result.Stopped)
+		fmt.Println("Resolve the conflicts, mark them with \"gitx add <path>\" and run \"gitx rebase --continue\".")
+		fmt.Println("Use \"gitx rebase --skip\" to drop this commit, or \"gitx rebase --abort\" to cancel.")
+		return 1
+	case strings.HasPrefix(result.Stopped, "exec "):
+		fmt.Printf("Execution failed: %s\n", strings.TrimPrefix(result.Stopped, "exec "))
+		fmt.Println("Fix the problem and run \"gitx rebase --continue\".")
+		return 1
+	}
+	fmt.Printf("Stopped at %s\n", result.Stopped)
+	fmt.Println("You can amend the commit now, with \"gitx commit --amend\".")
+	fmt.Println("Once you are satisfied with your changes, run \"gitx rebase --continue\".")
+	return 0
+}
+
+// verifyTags prints the signature checks of the tags and returns the exit status: 0 if all of
+// them have a good signature.
+func verifyTags(repo *models.Repository, names []string) int {
+	status := 0
+	for _, name := range names {
+		tag, check, err := file_operations.VerifyTag(repo, name)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return 1
+		}
+		if check.Status == file_operations.SignatureNone {
+			fmt.Printf("Tag %s is not signed\n", tag.Name)
+		} else {
+			fmt.Println(check)
+		}
+		if check.Status != file_operations.SignatureGood {
+			status = 1
+		}
+	}
+	return status
+}
+
+// hashObjectFile streams the file through hash.HashObject, storing it if store is not nil.
+func hashObjectFile(alg *hash.Algorithm, store gitx.FS, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", filePath)
+	}
+	return hash.HashObject(alg, store, file, info.Size())
+}
+
+// formatVersion is a flag that may be given alone or with a format version, like
+// --porcelain or --porcelain=v2. Given alone, it selects v1.
+type formatVersion string
+
+func (v *formatVersion) String() string { return string(*v) }
+
+func (v *formatVersion) Set(value string) error {
+	switch value {
+	case "true", "v1", "1":
+		*v = "v1"
+	case "v2", "2":
+		*v = "v2"
+	default:
+		return fmt.Errorf("unsupported format version %q", value)
+	}
+	return nil
+}
+
+// IsBoolFlag lets the flag be given without a value.
+func (v *formatVersion) IsBoolFlag() bool { return true }
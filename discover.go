@@ -0,0 +1,101 @@
+package gitx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Discover finds the repository containing path by walking up its parent directories
+// until a .gitx directory is found.
+//
+// The search does not move up into any directory listed in GITX_CEILING_DIRECTORIES,
+// and stops at filesystem boundaries unless GITX_DISCOVERY_ACROSS_FILESYSTEM is "true".
+func Discover(path string) (*Repository, error) {
+	var ceilings []string
+	for _, ceiling := range filepath.SplitList(os.Getenv("GITX_CEILING_DIRECTORIES")) {
+		if ceiling == "" {
+			continue
+		}
+		if absCeiling, err := filepath.Abs(ceiling); err == nil {
+			ceilings = append(ceilings, absCeiling)
+		}
+	}
+	acrossFilesystem := strings.EqualFold(os.Getenv("GITX_DISCOVERY_ACROSS_FILESYSTEM"), "true")
+
+	return discover(path, ceilings, acrossFilesystem)
+}
+
+// discover walks up from path looking for a .gitx directory.
+func discover(path string, ceilings []string, acrossFilesystem bool) (*Repository, error) {
+	start, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	startInfo, err := os.Stat(start)
+	if err != nil {
+		return nil, err
+	}
+	startDevice, hasDevice := deviceID(startInfo)
+
+	directory := start
+	for {
+		gitxDir := filepath.Join(directory, ".gitx")
+		if info, err := os.Stat(gitxDir); err == nil && info.IsDir() {
+			return openRepository(newRepository(directory, gitxDir))
+		}
+
+		parent := filepath.Dir(directory)
+		if parent == directory || isCeiling(parent, ceilings) {
+			break
+		}
+
+		if !acrossFilesystem && hasDevice {
+			info, err := os.Stat(parent)
+			if err != nil {
+				break
+			}
+			if device, ok := deviceID(info); ok && device != startDevice {
+				break
+			}
+		}
+
+		directory = parent
+	}
+
+	return nil, fmt.Errorf("%w (or any of the parent directories): %s", ErrNotARepository, start)
+}
+
+// isCeiling reports whether directory is one of the ceiling directories.
+func isCeiling(directory string, ceilings []string) bool {
+	for _, ceiling := range ceilings {
+		if filepath.Clean(ceiling) == directory {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenDir opens a repository whose .gitx directory and working tree are given explicitly.
+// An empty workTree means the current working directory, as with Git's --git-dir.
+func OpenDir(gitxDir, workTree string) (*Repository, error) {
+	absGitxDir, err := filepath.Abs(gitxDir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(absGitxDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotARepository, absGitxDir)
+	}
+
+	if workTree == "" {
+		workTree = "."
+	}
+	absWorkTree, err := filepath.Abs(workTree)
+	if err != nil {
+		return nil, err
+	}
+
+	return openRepository(newRepository(absWorkTree, absGitxDir))
+}
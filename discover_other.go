@@ -0,0 +1,10 @@
+//go:build !unix
+
+package gitx
+
+import "os"
+
+// deviceID is not available on this platform, so discovery never stops at filesystem boundaries.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
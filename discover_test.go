@@ -0,0 +1,65 @@
+package gitx_test
+
+import (
+	"GitX"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	if _, err := gitx.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "sub", "deeper")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GITX_CEILING_DIRECTORIES", "")
+	for _, start := range []string{root, nested} {
+		repo, err := gitx.Discover(start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.Directory != root {
+			t.Errorf("Discover(%q) finds %q, want %q", start, repo.Directory, root)
+		}
+	}
+
+	// The search never moves up into a ceiling directory
+	t.Setenv("GITX_CEILING_DIRECTORIES", root)
+	if _, err := gitx.Discover(nested); !errors.Is(err, gitx.ErrNotARepository) {
+		t.Errorf("Discover below a ceiling returns %v, want ErrNotARepository", err)
+	}
+	if _, err := gitx.Discover(root); err != nil {
+		t.Errorf("Discover at the repository root below a ceiling: %v", err)
+	}
+
+	t.Setenv("GITX_CEILING_DIRECTORIES", "")
+	if _, err := gitx.Discover(t.TempDir()); !errors.Is(err, gitx.ErrNotARepository) {
+		t.Errorf("Discover outside a repository returns %v, want ErrNotARepository", err)
+	}
+}
+
+func TestOpenDir(t *testing.T) {
+	root := t.TempDir()
+	if _, err := gitx.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	workTree := t.TempDir()
+
+	repo, err := gitx.OpenDir(filepath.Join(root, ".gitx"), workTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Directory != workTree || repo.GitxDir != filepath.Join(root, ".gitx") {
+		t.Errorf("OpenDir = (%q, %q), want (%q, %q)", repo.Directory, repo.GitxDir, workTree, filepath.Join(root, ".gitx"))
+	}
+
+	if _, err := gitx.OpenDir(filepath.Join(workTree, ".gitx"), workTree); !errors.Is(err, gitx.ErrNotARepository) {
+		t.Errorf("OpenDir of a missing .gitx directory returns %v, want ErrNotARepository", err)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package gitx
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceID returns the ID of the filesystem holding the file, used to stop discovery at mount points.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
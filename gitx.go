@@ -0,0 +1,472 @@
+// Package gitx exposes GitX repositories as a library.
+//
+// Every operation works on an explicit repository root instead of the process
+// working directory, so a single program can manage many repositories at once.
+package gitx
+
+import (
+	"GitX/internal/fsys"
+	"GitX/internal/hash"
+	"GitX/internal/merkle"
+	"GitX/models"
+	"GitX/utils/file_operations"
+	"GitX/utils/vcs_operations"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Errors returned by repository operations, for use with errors.Is.
+var (
+	ErrNotARepository  = models.ErrNotARepository
+	ErrRefNotFound     = models.ErrRefNotFound
+	ErrBranchExists    = models.ErrBranchExists
+	ErrTagExists       = models.ErrTagExists
+	ErrConflict        = models.ErrConflict
+	ErrNothingToCommit = models.ErrNothingToCommit
+	ErrObjectNotFound  = models.ErrObjectNotFound
+	ErrIgnored         = models.ErrIgnored
+	ErrIdentityUnknown = models.ErrIdentityUnknown
+	ErrHookFailed      = models.ErrHookFailed
+)
+
+// FS is the file system a repository reads and writes through. Both the working tree
+// and the .gitx store are accessed with slash-separated paths relative to their root.
+type FS = fsys.FS
+
+// MerkleProof shows that a commit is part of a history with a given Merkle root.
+type MerkleProof = merkle.Proof
+
+// HistoryCheck is the result of checking a history against a published Merkle root.
+type HistoryCheck = vcs_operations.HistoryCheck
+
+// Object is a blob, tree, commit or annotated tag read from a repository.
+type Object = vcs_operations.Object
+
+// NewOSFS returns an FS for the directory root on the operating system's file system.
+func NewOSFS(root string) FS {
+	return fsys.NewOSFS(root)
+}
+
+// NewMemFS returns an empty in-memory FS.
+func NewMemFS() FS {
+	return fsys.NewMemFS()
+}
+
+// Repository is a handle to a GitX repository on disk.
+// Operations on the same handle are serialized; separate handles are independent.
+type Repository struct {
+	*models.Repository
+
+	mu sync.Mutex
+}
+
+// Open opens the existing repository whose working tree is rooted at path.
+func Open(path string) (*Repository, error) {
+	directory, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	gitxDir := filepath.Join(directory, ".gitx")
+	if info, err := os.Stat(gitxDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotARepository, directory)
+	}
+
+	return openRepository(newRepository(directory, gitxDir))
+}
+
+// InitOptions configures a new repository.
+type InitOptions struct {
+	// ObjectFormat is the hash algorithm identifying objects, "sha1" or "sha256".
+	// It cannot be changed once the repository exists. Empty means "sha1".
+	ObjectFormat string
+}
+
+// Init creates a new repository rooted at path and returns a handle to it.
+func Init(path string) (*Repository, error) {
+	return InitWithOptions(path, InitOptions{})
+}
+
+// InitWithOptions creates a new repository rooted at path with the given options.
+func InitWithOptions(path string, opts InitOptions) (*Repository, error) {
+	directory, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return initRepository(newRepository(directory, filepath.Join(directory, ".gitx")), opts)
+}
+
+// OpenFS opens the existing repository stored in store, with its working tree in workTree.
+func OpenFS(workTree, store FS) (*Repository, error) {
+	if !fsys.Exists(store, "HEAD") {
+		return nil, fmt.Errorf("%w: HEAD not found in store", ErrNotARepository)
+	}
+	return openRepository(newRepositoryFS(workTree, store))
+}
+
+// InitFS creates a new repository in store, with its working tree in workTree.
+// Passing in-memory file systems gives a repository that never touches the disk.
+func InitFS(workTree, store FS) (*Repository, error) {
+	return InitFSWithOptions(workTree, store, InitOptions{})
+}
+
+// InitFSWithOptions creates a new repository in store with the given options.
+func InitFSWithOptions(workTree, store FS, opts InitOptions) (*Repository, error) {
+	return initRepository(newRepositoryFS(workTree, store), opts)
+}
+
+// openRepository reads the settings of an existing repository that its handle depends on.
+func openRepository(repo *Repository) (*Repository, error) {
+	alg, err := file_operations.LoadObjectFormat(repo.Store)
+	if err != nil {
+		return nil, err
+	}
+	repo.ObjectFormat = alg
+	return repo, nil
+}
+
+// initRepository creates the repository of the handle.
+func initRepository(repo *Repository, opts InitOptions) (*Repository, error) {
+	alg, err := hash.Lookup(opts.ObjectFormat)
+	if err != nil {
+		return nil, err
+	}
+	repo.ObjectFormat = alg
+	if err := file_operations.InitHandler(repo.Repository); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func newRepository(directory, gitxDir string) *Repository {
+	return &Repository{
+		Repository: &models.Repository{
+			Directory:    directory,
+			GitxDir:      gitxDir,
+			WorkTree:     fsys.NewOSFS(directory),
+			Store:        fsys.NewOSFS(gitxDir),
+			ReadHostFile: os.ReadFile,
+		},
+	}
+}
+
+// newRepositoryFS builds a repository that is not rooted on disk. Its directories are
+// nominal and only used to resolve and display paths.
+func newRepositoryFS(workTree, store FS) *Repository {
+	directory := string(filepath.Separator)
+	return &Repository{
+		Repository: &models.Repository{
+			Directory: directory,
+			GitxDir:   filepath.Join(directory, ".gitx"),
+			WorkTree:  workTree,
+			Store:     store,
+		},
+	}
+}
+
+// Add stages the given files. Relative paths are resolved against the repository root.
+func (r *Repository) Add(paths ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.Directory, path)
+		}
+		if err := file_operations.AddHandler(r.Repository, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit records the staged changes on the current branch and returns the new commit.
+func (r *Repository) Commit(message string) (*models.Commit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.CommitHandler(r.Repository, message, CommitOptions{})
+}
+
+// CommitOptions configures CommitWithOptions.
+type CommitOptions = file_operations.CommitOptions
+
+// CommitWithOptions records the staged changes like Commit, with the given options.
+func (r *Repository) CommitWithOptions(message string, opts CommitOptions) (*models.Commit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.CommitHandler(r.Repository, message, opts)
+}
+
+// TagOptions configures Tag.
+type TagOptions = file_operations.TagOptions
+
+// Tag creates the tag called name for the commit named by rev, HEAD if rev is empty, and
+// returns the ID the tag points to.
+func (r *Repository) Tag(name, rev string, opts TagOptions) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.TagHandler(r.Repository, name, rev, opts)
+}
+
+// SignatureCheck is the result of verifying a signature against the allowed signers.
+type SignatureCheck = file_operations.SignatureCheck
+
+// VerifyCommit verifies the signature of the commit named by rev.
+func (r *Repository) VerifyCommit(rev string) (*SignatureCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, check, err := file_operations.VerifyCommit(r.Repository, rev)
+	return check, err
+}
+
+// VerifyTag verifies the signature of the annotated tag called name.
+func (r *Repository) VerifyTag(name string) (*SignatureCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, check, err := file_operations.VerifyTag(r.Repository, name)
+	return check, err
+}
+
+// Branches returns the names of all the branches in the repository.
+func (r *Repository) Branches() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.GetBranches(r.Repository)
+}
+
+// Checkout switches HEAD to the given branch.
+func (r *Repository) Checkout(branchName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.SwitchBranch(r.Repository, branchName)
+}
+
+// Log returns the history reachable from HEAD, newest commit first.
+func (r *Repository) Log() ([]*models.Commit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.CommitLog(r.Repository)
+}
+
+// Merge merges the given branch into the current branch.
+func (r *Repository) Merge(branchName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := file_operations.MergeHandler(r.Repository, branchName, MergeOptions{})
+	return err
+}
+
+// MergeOptions configures MergeWithOptions.
+type MergeOptions = file_operations.MergeOptions
+
+// MergeWithOptions merges the given branch into the current branch like Merge, with the given options.
+func (r *Repository) MergeWithOptions(branchName string, opts MergeOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := file_operations.MergeHandler(r.Repository, branchName, opts)
+	return err
+}
+
+// PickOptions configures CherryPick and Revert.
+type PickOptions = file_operations.PickOptions
+
+// PickResult describes what a cherry-pick or revert did, including where it stopped on a conflict.
+type PickResult = file_operations.PickResult
+
+// CherryPick applies the changes made by the given commits, in order, to the current branch.
+func (r *Repository) CherryPick(revs []string, opts PickOptions) (*PickResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.CherryPick(r.Repository, revs, opts)
+}
+
+// Revert commits the reverse of the changes made by the given commits, in order.
+func (r *Repository) Revert(revs []string, opts PickOptions) (*PickResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.Revert(r.Repository, revs, opts)
+}
+
+// ContinueSequence resumes a cherry-pick or revert, named by action, that stopped on a
+// conflict once the conflicts are resolved and staged.
+func (r *Repository) ContinueSequence(action string) (*PickResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.ContinueSequence(r.Repository, action)
+}
+
+// SkipSequence drops the commit a cherry-pick or revert stopped at and applies the rest.
+func (r *Repository) SkipSequence(action string) (*PickResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.SkipSequence(r.Repository, action)
+}
+
+// AbortSequence cancels a cherry-pick or revert, returning to the commit it started from.
+func (r *Repository) AbortSequence(action string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.AbortSequence(r.Repository, action)
+}
+
+// RebaseOptions configures Rebase, including the editor used for interactive rebases.
+type RebaseOptions = file_operations.RebaseOptions
+
+// RebaseResult describes where a rebase got to, including the instruction it stopped at.
+type RebaseResult = file_operations.RebaseResult
+
+// Rebase replays the commits of the current branch that upstream does not have on top of it.
+func (r *Repository) Rebase(upstream string, opts RebaseOptions) (*RebaseResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.Rebase(r.Repository, upstream, opts)
+}
+
+// ContinueRebase resumes a rebase once its conflicts are resolved and staged, or once the
+// commit it stopped at for editing is amended.
+func (r *Repository) ContinueRebase(opts RebaseOptions) (*RebaseResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.ContinueRebase(r.Repository, opts)
+}
+
+// SkipRebase drops the commit a rebase stopped at and executes the rest of the todo list.
+func (r *Repository) SkipRebase(opts RebaseOptions) (*RebaseResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.SkipRebase(r.Repository, opts)
+}
+
+// AbortRebase cancels a rebase, returning the branch to the commit it pointed to before.
+func (r *Repository) AbortRebase() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.AbortRebase(r.Repository)
+}
+
+// Status compares HEAD, the INDEX and the working tree without writing to the repository.
+func (r *Repository) Status() (*file_operations.Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.GetStatus(r.Repository)
+}
+
+// HashObject returns the blob ID of the content read from r, which is size bytes long, or of
+// unknown length if size is negative. The content is streamed rather than loaded in memory,
+// through a temporary file of the store when its length is unknown, and the blob is only
+// stored in the repository when write is set.
+func (r *Repository) HashObject(content io.Reader, size int64, write bool) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if size < 0 {
+		return hash.HashStream(r.Hash(), r.Store, content, write)
+	}
+	if !write {
+		return hash.HashObject(r.Hash(), nil, content, size)
+	}
+	return hash.HashObject(r.Hash(), r.Store, content, size)
+}
+
+// ReadObject returns the object named by name: a revision, "<rev>:<path>", an annotated tag,
+// or the full ID of a blob or tree.
+func (r *Repository) ReadObject(name string) (*Object, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.ReadObject(r.Repository, name)
+}
+
+// Show writes the object named by name to w like "gitx show": a commit with its patch, a tag
+// with the commit it tags, a tree listing or a file's content.
+func (r *Repository) Show(w io.Writer, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.Show(r.Repository, w, name)
+}
+
+// LsTreeOptions configures LsTree.
+type LsTreeOptions = file_operations.LsTreeOptions
+
+// LsTree returns the entries of the tree named by treeish, optionally restricted to paths.
+func (r *Repository) LsTree(treeish string, paths []string, opts LsTreeOptions) ([]models.TreeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.LsTree(r.Repository, treeish, paths, opts)
+}
+
+// LsFilesOptions selects the files LsFiles lists.
+type LsFilesOptions = file_operations.LsFilesOptions
+
+// LsFilesEntry is a file listed by LsFiles, with its INDEX entry if it has one.
+type LsFilesEntry = file_operations.LsFilesEntry
+
+// LsFiles lists the files of the INDEX and the working tree selected by opts and the pathspecs.
+func (r *Repository) LsFiles(paths []string, opts LsFilesOptions) ([]LsFilesEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return file_operations.LsFiles(r.Repository, paths, opts)
+}
+
+// MerkleRoot returns the root of the Merkle tree over the history of rev, and the number of
+// commits it covers.
+func (r *Repository) MerkleRoot(rev string) (string, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.MerkleRoot(r.Repository, rev)
+}
+
+// RecordMerkleRoot records the Merkle root of the history of branch, or of the current branch
+// if it is empty, in a note attached to its newest commit.
+func (r *Repository) RecordMerkleRoot(branch string) (*models.MerkleNote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.RecordMerkleRoot(r.Repository, branch)
+}
+
+// VerifyHistory checks the history of rev against a published Merkle root and reports the
+// first divergent commit when it was rewritten.
+func (r *Repository) VerifyHistory(rev, root string) (*HistoryCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.VerifyHistory(r.Repository, rev, root)
+}
+
+// MerkleProof returns the proof that commit is part of the history of rev. The proof can be
+// checked with its Verify method without access to the repository.
+func (r *Repository) MerkleProof(rev, commit string) (*MerkleProof, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return vcs_operations.MerkleProof(r.Repository, rev, commit)
+}
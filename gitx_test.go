@@ -0,0 +1,225 @@
+package gitx_test
+
+import (
+	"GitX"
+	"GitX/internal/hash"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setIdentity sets the identity commits are made with through the environment.
+func setIdentity(t *testing.T) {
+	for _, role := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GITX_"+role+"_NAME", "Test User")
+		t.Setenv("GITX_"+role+"_EMAIL", "test@example.com")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	directory := t.TempDir()
+	if _, err := gitx.Open(directory); !errors.Is(err, gitx.ErrNotARepository) {
+		t.Fatalf("Open before Init returns %v, want ErrNotARepository", err)
+	}
+	if _, err := gitx.Init(directory); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := gitx.Open(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.GitxDir != filepath.Join(directory, ".gitx") {
+		t.Errorf("GitxDir = %q, want %q", repo.GitxDir, filepath.Join(directory, ".gitx"))
+	}
+}
+
+func TestRepositoriesAreIndependent(t *testing.T) {
+	setIdentity(t)
+	repos := make(map[string]*gitx.Repository)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		repo, err := gitx.Init(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo.Directory, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Add(name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Commit("add " + name); err != nil {
+			t.Fatal(err)
+		}
+		repos[name] = repo
+	}
+
+	for name, repo := range repos {
+		history, err := repo.Log()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messages []string
+		for _, commit := range history {
+			messages = append(messages, commit.Message)
+		}
+		if want := []string{"add " + name, "Initial commit"}; !slices.Equal(messages, want) {
+			t.Errorf("log of the repository of %s = %q, want %q", name, messages, want)
+		}
+
+		branches, err := repo.Branches()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(branches, []string{"main"}) {
+			t.Errorf("branches = %q, want [main]", branches)
+		}
+		if err := repo.Checkout("missing"); !errors.Is(err, gitx.ErrRefNotFound) {
+			t.Errorf("Checkout of a missing branch returns %v, want ErrRefNotFound", err)
+		}
+		if err := repo.Merge("missing"); !errors.Is(err, gitx.ErrRefNotFound) {
+			t.Errorf("Merge of a missing branch returns %v, want ErrRefNotFound", err)
+		}
+		if _, err := repo.Commit("again"); !errors.Is(err, gitx.ErrNothingToCommit) {
+			t.Errorf("Commit without changes returns %v, want ErrNothingToCommit", err)
+		}
+		if err := repo.Checkout("main"); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestInitFS(t *testing.T) {
+	setIdentity(t)
+	workTree, store := gitx.NewMemFS(), gitx.NewMemFS()
+	if _, err := gitx.OpenFS(workTree, store); !errors.Is(err, gitx.ErrNotARepository) {
+		t.Fatalf("OpenFS before InitFS returns %v, want ErrNotARepository", err)
+	}
+	if _, err := gitx.InitFS(workTree, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := workTree.WriteFile("a.txt", []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := gitx.OpenFS(workTree, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.Commit("add a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := store.ReadFile("refs/heads/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(head) != commit.ID {
+		t.Errorf("refs/heads/main = %q, want %q", head, commit.ID)
+	}
+}
+
+func TestHashObject(t *testing.T) {
+	// ID of the blob "hello\n", as computed by "git hash-object"
+	const id = "ce013625030ba8dba906f756967f9e9ca394464a"
+	objectFile := "objects/" + id[:2] + "/" + id[2:]
+
+	tests := []struct {
+		name  string
+		size  int64
+		write bool
+	}{
+		{"known size", 6, false},
+		{"unknown size", -1, false},
+		{"known size written", 6, true},
+		{"unknown size written", -1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := gitx.NewMemFS()
+			repo, err := gitx.InitFS(gitx.NewMemFS(), store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := repo.HashObject(strings.NewReader("hello\n"), test.size, test.write)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != id {
+				t.Errorf("HashObject = %s, want %s", got, id)
+			}
+			if _, err := store.Stat(objectFile); (err == nil) != test.write {
+				t.Errorf("object stored = %v, want %v", err == nil, test.write)
+			}
+			entries, err := store.ReadDir("objects")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "tmp_obj_") {
+					t.Errorf("temporary file %s is left in objects/", entry.Name())
+				}
+			}
+		})
+	}
+
+	repo, err := gitx.InitFS(gitx.NewMemFS(), gitx.NewMemFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.HashObject(strings.NewReader("hello\n"), 10, false); err == nil {
+		t.Error("HashObject of content shorter than its size succeeds")
+	}
+}
+
+func TestSHA256(t *testing.T) {
+	setIdentity(t)
+	workTree, store := gitx.NewMemFS(), gitx.NewMemFS()
+	if _, err := gitx.InitFSWithOptions(workTree, store, gitx.InitOptions{ObjectFormat: "md5"}); err == nil {
+		t.Fatal("InitFSWithOptions with an unknown object format succeeds")
+	}
+	if _, err := gitx.InitFSWithOptions(workTree, store, gitx.InitOptions{ObjectFormat: "sha256"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The object format is read back from the repository
+	repo, err := gitx.OpenFS(workTree, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Hash() != hash.SHA256 {
+		t.Fatalf("reopened repository uses %s, want sha256", repo.Hash().Name)
+	}
+	if err := workTree.WriteFile("a.txt", []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.Commit("add a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hash.SHA256.CheckID(commit.ID); err != nil {
+		t.Error(err)
+	}
+	// ID of the blob "hello\n", as computed by "git hash-object" in a SHA-256 repository
+	const blobID = "2cf8d83d9ee29543b34a87727421fdecb7e3f3a183d337639025de576db9ebb4"
+	if len(commit.Tree.Entries) != 1 || commit.Tree.Entries[0].ID != blobID {
+		t.Errorf("tree entries = %+v, want a.txt with blob %s", commit.Tree.Entries, blobID)
+	}
+
+	report, err := vcs_operations.Fsck(repo.Repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 0 {
+		t.Errorf("fsck of a SHA-256 repository reports %+v", report.Problems)
+	}
+}
@@ -0,0 +1,325 @@
+// Package commitgraph implements the commit-graph file, a compact summary of the commit history
+// that answers ancestry queries without reading every commit.
+//
+// The file lists one commit per line as "<id> <generation> <date> <parents>", where the date is
+// in Unix seconds and the parents are the comma-separated positions of the parent lines, or "-"
+// for a root commit. Parents always come before their children, so new commits are appended
+// without renumbering. Each batch of appended lines is followed by a "checksum <hash>" line,
+// using the repository's hash algorithm, that covers the previous checksum and the lines of the
+// batch, so the last checksum covers the whole file.
+package commitgraph
+
+import (
+	"GitX/internal/hash"
+	"bufio"
+	"bytes"
+	"container/heap"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checksumPrefix marks the line holding the checksum of a batch of commit lines.
+const checksumPrefix = "checksum "
+
+// Commit is the summary of a commit kept in the graph.
+type Commit struct {
+	ID         string
+	Generation int   // 1 for a root commit, otherwise one more than the highest parent generation
+	Date       int64 // Commit date in Unix seconds
+	Parents    []int // Positions of the parents in the graph
+}
+
+// Graph holds the commits of the commit-graph file in file order.
+type Graph struct {
+	Commits   []Commit
+	alg       *hash.Algorithm
+	positions map[string]int
+	checksum  string // Last checksum of the file, which the next batch of lines is chained to
+}
+
+// New returns an empty graph of commits identified with the given hash algorithm.
+func New(alg *hash.Algorithm) *Graph {
+	return &Graph{alg: alg, positions: make(map[string]int)}
+}
+
+// Parse reads a graph from the content of a commit-graph file and validates its IDs and its
+// checksums.
+func Parse(alg *hash.Algorithm, content []byte) (*Graph, error) {
+	g := New(alg)
+	var batch []byte
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, checksumPrefix) {
+			checksum := strings.TrimSpace(strings.TrimPrefix(line, checksumPrefix))
+			if len(batch) == 0 {
+				return nil, fmt.Errorf("invalid commit-graph: empty batch before checksum")
+			}
+			if g.chainChecksum(g.checksum, batch) != checksum {
+				return nil, fmt.Errorf("commit-graph checksum mismatch")
+			}
+			g.checksum, batch = checksum, nil
+			continue
+		}
+		batch = append(batch, line...)
+		batch = append(batch, '\n')
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid commit-graph: line %d", len(g.Commits)+1)
+		}
+		if err := alg.CheckID(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid commit-graph: %v", err)
+		}
+		commit := Commit{ID: fields[0]}
+		var err error
+		if commit.Generation, err = strconv.Atoi(fields[1]); err != nil || commit.Generation < 1 {
+			return nil, fmt.Errorf("invalid commit-graph: bad generation on line %d", len(g.Commits)+1)
+		}
+		if commit.Date, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid commit-graph: bad date on line %d", len(g.Commits)+1)
+		}
+		if fields[3] != "-" {
+			for _, field := range strings.Split(fields[3], ",") {
+				parent, err := strconv.Atoi(field)
+				if err != nil || parent < 0 || parent >= len(g.Commits) {
+					return nil, fmt.Errorf("invalid commit-graph: bad parent on line %d", len(g.Commits)+1)
+				}
+				commit.Parents = append(commit.Parents, parent)
+			}
+		}
+		if _, ok := g.positions[commit.ID]; ok {
+			return nil, fmt.Errorf("invalid commit-graph: duplicate commit %s", commit.ID)
+		}
+		g.positions[commit.ID] = len(g.Commits)
+		g.Commits = append(g.Commits, commit)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading commit-graph: %v", err)
+	}
+	if len(batch) > 0 {
+		return nil, fmt.Errorf("commit-graph checksum is missing")
+	}
+	return g, nil
+}
+
+// chainChecksum returns the checksum of a batch of lines appended after the given checksum.
+func (g *Graph) chainChecksum(previous string, batch []byte) string {
+	return g.alg.Sum(append([]byte(previous), batch...))
+}
+
+// Bytes returns the content of a commit-graph file holding the whole graph, as a single batch.
+func (g *Graph) Bytes() []byte {
+	g.checksum = ""
+	return g.BytesSince(0)
+}
+
+// BytesSince returns the lines of the commits from the given position on, followed by their
+// checksum, to be appended to the commit-graph file the graph was read from. Nothing is returned
+// when there are no such commits.
+func (g *Graph) BytesSince(position int) []byte {
+	if position >= len(g.Commits) {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, commit := range g.Commits[position:] {
+		parents := "-"
+		if len(commit.Parents) > 0 {
+			positions := make([]string, len(commit.Parents))
+			for i, parent := range commit.Parents {
+				positions[i] = strconv.Itoa(parent)
+			}
+			parents = strings.Join(positions, ",")
+		}
+		fmt.Fprintf(&buf, "%s %d %d %s\n", commit.ID, commit.Generation, commit.Date, parents)
+	}
+	g.checksum = g.chainChecksum(g.checksum, buf.Bytes())
+	fmt.Fprintf(&buf, "%s%s\n", checksumPrefix, g.checksum)
+	return buf.Bytes()
+}
+
+// Lookup returns the position of the commit with the given ID.
+func (g *Graph) Lookup(id string) (int, bool) {
+	position, ok := g.positions[id]
+	return position, ok
+}
+
+// Add appends a commit whose parents are already in the graph and returns its position.
+// Adding a commit that is already in the graph returns its existing position.
+func (g *Graph) Add(id string, date time.Time, parents []string) (int, error) {
+	if position, ok := g.positions[id]; ok {
+		return position, nil
+	}
+	commit := Commit{ID: id, Generation: 1, Date: date.Unix()}
+	for _, parentID := range parents {
+		parent, ok := g.positions[parentID]
+		if !ok {
+			return 0, fmt.Errorf("parent %s of commit %s is not in the commit-graph", parentID, id)
+		}
+		commit.Parents = append(commit.Parents, parent)
+		if g.Commits[parent].Generation >= commit.Generation {
+			commit.Generation = g.Commits[parent].Generation + 1
+		}
+	}
+	g.positions[id] = len(g.Commits)
+	g.Commits = append(g.Commits, commit)
+	return len(g.Commits) - 1, nil
+}
+
+// IsAncestor reports whether the commit at position a is reachable from the commit at
+// position b, counting b itself. Commits with a generation below a's cannot lead to a and
+// are not visited.
+func (g *Graph) IsAncestor(a, b int) bool {
+	generation := g.Commits[a].Generation
+	visited := make(map[int]bool)
+	pending := []int{b}
+	for len(pending) > 0 {
+		position := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if position == a {
+			return true
+		}
+		if visited[position] || g.Commits[position].Generation <= generation {
+			continue
+		}
+		visited[position] = true
+		pending = append(pending, g.Commits[position].Parents...)
+	}
+	return false
+}
+
+// Flags painted on commits while looking for merge bases.
+const (
+	fromA = 1 << iota
+	fromB
+	stale
+)
+
+// MergeBases returns the positions of the best common ancestors of the commits at positions a
+// and b: the common ancestors that are not ancestors of another common ancestor. Commits are
+// visited in decreasing generation order, so the walk stops as soon as only commits below the
+// merge bases are left.
+func (g *Graph) MergeBases(a, b int) []int {
+	if a == b {
+		return []int{a}
+	}
+
+	flags := map[int]int{a: fromA, b: fromB}
+	queue := &generationQueue{graph: g}
+	heap.Push(queue, a)
+	heap.Push(queue, b)
+
+	var candidates []int
+	for queue.Len() > 0 && !queue.allStale(flags) {
+		position := heap.Pop(queue).(int)
+		// Parents of a common ancestor are common ancestors too, but never the best ones
+		painted := flags[position]
+		if painted == fromA|fromB {
+			candidates = append(candidates, position)
+			painted |= stale
+		}
+		for _, parent := range g.Commits[position].Parents {
+			if flags[parent]&painted == painted {
+				continue
+			}
+			if flags[parent] == 0 {
+				heap.Push(queue, parent)
+			}
+			flags[parent] |= painted
+		}
+	}
+
+	// Drop the candidates reachable from another candidate
+	var bases []int
+	for i, candidate := range candidates {
+		redundant := false
+		for j, other := range candidates {
+			if i != j && g.IsAncestor(candidate, other) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			bases = append(bases, candidate)
+		}
+	}
+	return bases
+}
+
+// AheadBehind counts the commits reachable from the commit at position a but not from the one
+// at position b, and the other way around. The walk stops once only common history is left.
+func (g *Graph) AheadBehind(a, b int) (ahead, behind int) {
+	if a == b {
+		return 0, 0
+	}
+
+	flags := map[int]int{a: fromA, b: fromB}
+	queue := &generationQueue{graph: g}
+	heap.Push(queue, a)
+	heap.Push(queue, b)
+
+	for queue.Len() > 0 && !queue.allStale(flags) {
+		position := heap.Pop(queue).(int)
+		painted := flags[position]
+		switch painted {
+		case fromA:
+			ahead++
+		case fromB:
+			behind++
+		default:
+			// Reachable from both, so neither side counts it or its ancestors
+			painted |= stale
+		}
+		for _, parent := range g.Commits[position].Parents {
+			if flags[parent]&painted == painted {
+				continue
+			}
+			if flags[parent] == 0 {
+				heap.Push(queue, parent)
+			}
+			flags[parent] |= painted
+		}
+	}
+	return ahead, behind
+}
+
+// generationQueue orders graph positions by decreasing generation, then by decreasing date.
+type generationQueue struct {
+	graph     *Graph
+	positions []int
+}
+
+func (q *generationQueue) Len() int { return len(q.positions) }
+
+func (q *generationQueue) Less(i, j int) bool {
+	a, b := q.graph.Commits[q.positions[i]], q.graph.Commits[q.positions[j]]
+	if a.Generation != b.Generation {
+		return a.Generation > b.Generation
+	}
+	return a.Date > b.Date
+}
+
+func (q *generationQueue) Swap(i, j int) {
+	q.positions[i], q.positions[j] = q.positions[j], q.positions[i]
+}
+
+func (q *generationQueue) Push(x any) { q.positions = append(q.positions, x.(int)) }
+
+func (q *generationQueue) Pop() any {
+	last := q.positions[len(q.positions)-1]
+	q.positions = q.positions[:len(q.positions)-1]
+	return last
+}
+
+// allStale reports whether every queued commit is already below a merge base.
+func (q *generationQueue) allStale(flags map[int]int) bool {
+	for _, position := range q.positions {
+		if flags[position]&stale == 0 {
+			return false
+		}
+	}
+	return true
+}
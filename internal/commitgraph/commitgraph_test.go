@@ -0,0 +1,303 @@
+package commitgraph
+
+import (
+	"GitX/internal/hash"
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHistory is a history with two merges, in the order its commits were made. Main goes
+// a, b, c, f, g and a topic branch forks from b with d; e merges c into the topic branch, f
+// merges e back into main, and h and i are two more branches from e. x is an unrelated root.
+var testHistory = []struct {
+	name    string
+	parents []string
+}{
+	{"a", nil},
+	{"b", []string{"a"}},
+	{"c", []string{"b"}},
+	{"d", []string{"b"}},
+	{"e", []string{"d", "c"}},
+	{"f", []string{"c", "e"}},
+	{"g", []string{"f"}},
+	{"h", []string{"e"}},
+	{"i", []string{"e"}},
+	{"x", nil},
+}
+
+// commitID returns the ID standing for the commit called name.
+func commitID(name string) string {
+	return hash.SHA1.Sum([]byte(name))
+}
+
+// newTestGraph builds the graph of testHistory, a commit every minute.
+func newTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	g := New(hash.SHA1)
+	start := time.Unix(1700000000, 0)
+	for i, commit := range testHistory {
+		var parents []string
+		for _, parent := range commit.parents {
+			parents = append(parents, commitID(parent))
+		}
+		if _, err := g.Add(commitID(commit.name), start.Add(time.Duration(i)*time.Minute), parents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+// position returns the position of the commit called name.
+func position(t *testing.T, g *Graph, name string) int {
+	t.Helper()
+	p, ok := g.Lookup(commitID(name))
+	if !ok {
+		t.Fatalf("commit %s is not in the graph", name)
+	}
+	return p
+}
+
+// names returns the names of the commits at the given positions, sorted.
+func names(g *Graph, positions []int) string {
+	byID := make(map[string]string)
+	for _, commit := range testHistory {
+		byID[commitID(commit.name)] = commit.name
+	}
+	var result []string
+	for _, p := range positions {
+		result = append(result, byID[g.Commits[p].ID])
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
+
+func TestGenerations(t *testing.T) {
+	g := newTestGraph(t)
+	want := map[string]int{"a": 1, "b": 2, "c": 3, "d": 3, "e": 4, "f": 5, "g": 6, "h": 5, "i": 5, "x": 1}
+	for name, generation := range want {
+		if got := g.Commits[position(t, g, name)].Generation; got != generation {
+			t.Errorf("generation of %s is %d, want %d", name, got, generation)
+		}
+	}
+}
+
+func TestAddNeedsParents(t *testing.T) {
+	g := New(hash.SHA1)
+	if _, err := g.Add(commitID("b"), time.Now(), []string{commitID("a")}); err == nil {
+		t.Error("adding a commit before its parent succeeded")
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a", "g", true},
+		{"g", "g", true},
+		{"d", "g", true},
+		{"d", "c", false},
+		{"c", "h", true},
+		{"h", "g", false},
+		{"g", "a", false},
+		{"x", "g", false},
+		{"i", "h", false},
+	}
+	for _, test := range tests {
+		t.Run(test.a+" in "+test.b, func(t *testing.T) {
+			if got := g.IsAncestor(position(t, g, test.a), position(t, g, test.b)); got != test.want {
+				t.Errorf("IsAncestor(%s, %s) = %v, want %v", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeBases(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		a, b  string
+		bases string
+	}{
+		{"g", "g", "g"},
+		{"c", "d", "b"},
+		{"g", "h", "e"},
+		{"h", "i", "e"},
+		{"f", "a", "a"},
+		{"c", "e", "c"},
+		{"g", "x", ""},
+		{"f", "h", "e"},
+	}
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			bases := g.MergeBases(position(t, g, test.a), position(t, g, test.b))
+			if got := names(g, bases); got != test.bases {
+				t.Errorf("MergeBases(%s, %s) = %q, want %q", test.a, test.b, got, test.bases)
+			}
+		})
+	}
+}
+
+func TestMergeBasesCrissCross(t *testing.T) {
+	// p and q each merge the other's parent, so both r and s are best common ancestors
+	g := New(hash.SHA1)
+	now := time.Unix(1700000000, 0)
+	for _, commit := range []struct {
+		name    string
+		parents []string
+	}{
+		{"root", nil},
+		{"r", []string{"root"}},
+		{"s", []string{"root"}},
+		{"p", []string{"r", "s"}},
+		{"q", []string{"s", "r"}},
+	} {
+		var parents []string
+		for _, parent := range commit.parents {
+			parents = append(parents, commitID(parent))
+		}
+		if _, err := g.Add(commitID(commit.name), now, parents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, _ := g.Lookup(commitID("p"))
+	q, _ := g.Lookup(commitID("q"))
+	r, _ := g.Lookup(commitID("r"))
+	s, _ := g.Lookup(commitID("s"))
+	bases := g.MergeBases(p, q)
+	sort.Ints(bases)
+	if len(bases) != 2 || bases[0] != r || bases[1] != s {
+		t.Errorf("MergeBases(p, q) = %v, want [%d %d]", bases, r, s)
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	g := newTestGraph(t)
+	tests := []struct {
+		a, b          string
+		ahead, behind int
+	}{
+		{"g", "g", 0, 0},
+		{"g", "h", 2, 1},
+		{"h", "g", 1, 2},
+		{"c", "d", 1, 1},
+		{"g", "a", 6, 0},
+		{"a", "g", 0, 6},
+		{"h", "i", 1, 1},
+		{"g", "x", 7, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			ahead, behind := g.AheadBehind(position(t, g, test.a), position(t, g, test.b))
+			if ahead != test.ahead || behind != test.behind {
+				t.Errorf("AheadBehind(%s, %s) = %d, %d, want %d, %d", test.a, test.b, ahead, behind, test.ahead, test.behind)
+			}
+		})
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	g := newTestGraph(t)
+	parsed, err := Parse(hash.SHA1, g.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Bytes(), g.Bytes()) {
+		t.Error("parsed graph differs from the original")
+	}
+	if len(parsed.Commits) != len(testHistory) {
+		t.Errorf("parsed %d commits, want %d", len(parsed.Commits), len(testHistory))
+	}
+}
+
+func TestFileAppend(t *testing.T) {
+	// Each commit is appended to the file as a batch of its own
+	g := New(hash.SHA1)
+	var content []byte
+	for i, commit := range testHistory {
+		var parents []string
+		for _, parent := range commit.parents {
+			parents = append(parents, commitID(parent))
+		}
+		size := len(g.Commits)
+		if _, err := g.Add(commitID(commit.name), time.Unix(int64(i), 0), parents); err != nil {
+			t.Fatal(err)
+		}
+		content = append(content, g.BytesSince(size)...)
+
+		parsed, err := Parse(hash.SHA1, content)
+		if err != nil {
+			t.Fatalf("after appending %s: %v", commit.name, err)
+		}
+		if len(parsed.Commits) != i+1 {
+			t.Fatalf("after appending %s: parsed %d commits, want %d", commit.name, len(parsed.Commits), i+1)
+		}
+		// A graph read back from the file appends to it the same way
+		g = parsed
+	}
+	if got := g.BytesSince(len(g.Commits)); got != nil {
+		t.Errorf("BytesSince the end = %q, want nothing", got)
+	}
+}
+
+func TestParseRejectsCorruption(t *testing.T) {
+	g := newTestGraph(t)
+	valid := string(g.Bytes())
+	firstLine, _, _ := strings.Cut(valid, "\n")
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"changed date", strings.Replace(valid, " 1700000000 ", " 1700000001 ", 1)},
+		{"missing checksum", strings.TrimSuffix(valid[:strings.LastIndex(valid, checksumPrefix)], "\n") + "\n"},
+		{"unfinished batch", valid + firstLine[:len(firstLine)-1] + "\n"},
+		{"duplicate commit", firstLine + "\n" + firstLine + "\n" + checksumPrefix + hash.SHA1.Sum([]byte(firstLine+"\n"+firstLine+"\n")) + "\n"},
+		{"malformed line", "bad " + valid},
+		{"unknown parent", strings.Replace(valid, " 0\n", " 99\n", 1)},
+		{"checksum without lines", checksumPrefix + hash.SHA1.Sum(nil) + "\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Parse(hash.SHA1, []byte(test.content)); err == nil {
+				t.Error("Parse succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	g, err := Parse(hash.SHA1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Commits) != 0 || len(g.Bytes()) != 0 {
+		t.Errorf("empty file gives %d commits", len(g.Commits))
+	}
+}
+
+func TestParseSHA256(t *testing.T) {
+	g := New(hash.SHA256)
+	root := hash.SHA256.Sum([]byte("root"))
+	child := hash.SHA256.Sum([]byte("child"))
+	if _, err := g.Add(root, time.Unix(1700000000, 0), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Add(child, time.Unix(1700000060, 0), []string{root}); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(hash.SHA256, g.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position, ok := parsed.Lookup(child); !ok || parsed.Commits[position].Generation != 2 {
+		t.Errorf("parsed graph lost %s", child)
+	}
+
+	// A file is only valid for the algorithm it was written with
+	if _, err := Parse(hash.SHA1, g.Bytes()); err == nil {
+		t.Error("Parse of a SHA-256 graph as SHA-1 succeeds")
+	}
+}
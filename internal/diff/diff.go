@@ -0,0 +1,353 @@
+// Package diff computes line based differences between two versions of a file and groups them
+// into hunks that can be displayed, split and applied selectively.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Line is a single line of an edit script. Kind is ' ' for context, '-' for a removed line and
+// '+' for an added line. Text keeps its trailing newline, if any.
+type Line struct {
+	Kind byte
+	Text string
+}
+
+// Hunk is a group of nearby changes with their surrounding context. OldStart and NewStart are
+// the 1-based line numbers where the hunk begins in the old and the new version.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// SplitLines splits content into lines, each keeping its trailing newline.
+func SplitLines(content []byte) []string {
+	var lines []string
+	text := string(content)
+	for text != "" {
+		line, rest, found := strings.Cut(text, "\n")
+		if found {
+			line += "\n"
+		}
+		lines = append(lines, line)
+		text = rest
+	}
+	return lines
+}
+
+// Diff returns the shortest edit script turning a into b, using the linear space variant of
+// Myers' algorithm.
+func Diff(a, b []string) []Line {
+	return appendDiff(nil, a, b)
+}
+
+// appendDiff appends the shortest edit script turning a into b to lines. The common prefix and
+// suffix never change, so they are kept out of the search. The rest is split at a point of an
+// optimal path found by bisect, and both halves are diffed in turn, so memory stays linear in
+// the length of the input.
+func appendDiff(lines []Line, a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Kind: ' ', Text: text})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, text := range midB {
+			lines = append(lines, Line{Kind: '+', Text: text})
+		}
+	case len(midB) == 0:
+		for _, text := range midA {
+			lines = append(lines, Line{Kind: '-', Text: text})
+		}
+	default:
+		x, y := bisect(midA, midB)
+		lines = appendDiff(lines, midA[:x], midB[:y])
+		lines = appendDiff(lines, midA[x:], midB[y:])
+	}
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Kind: ' ', Text: text})
+	}
+	return lines
+}
+
+// bisect returns a point (x, y) of the edit graph that lies on a shortest path from the start
+// to the end. It explores the graph one edit distance at a time from both ends at once, keeping
+// only the furthest reaching point of each diagonal, until the two searches overlap. a and b
+// must not be empty.
+func bisect(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	// The searches can only meet on a diagonal reached by the one that moves last, which
+	// depends on the parity of the difference in length
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that ran off the edge of the graph are skipped
+	var forwardStart, forwardEnd, backwardStart, backwardEnd int
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd:
+				// Backward diagonals count from the end, so they are mirrored around delta
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 && forward[j] >= n-x {
+					return forward[j], forward[j] - (j - offset)
+				}
+			}
+		}
+	}
+
+	// Not reached for valid input: delete everything, then insert everything
+	return n, 0
+}
+
+// Hunks compares a and b and groups the changes into hunks with up to context lines of
+// unchanged text around them. Changes separated by at most twice that are merged.
+func Hunks(a, b []string, context int) []Hunk {
+	lines := Diff(a, b)
+
+	// Line numbers in each version before every line of the edit script
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if line.Kind != '+' {
+			oldPos[i+1]++
+		}
+		if line.Kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	nextChange := func(from int) int {
+		for i := from; i < len(lines); i++ {
+			if lines[i].Kind != ' ' {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var hunks []Hunk
+	for change := nextChange(0); change >= 0; {
+		start := change - context
+		if start < 0 {
+			start = 0
+		}
+		end := change
+		for {
+			for end < len(lines) && lines[end].Kind != ' ' {
+				end++
+			}
+			next := nextChange(end)
+			if next < 0 || next-end > 2*context {
+				change = next
+				break
+			}
+			end = next
+		}
+		end += context
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunks = append(hunks, NewHunk(oldPos[start]+1, newPos[start]+1, lines[start:end]))
+	}
+	return hunks
+}
+
+// NewHunk builds a hunk starting at the given line numbers and counts its lines.
+func NewHunk(oldStart, newStart int, lines []Line) Hunk {
+	hunk := Hunk{OldStart: oldStart, NewStart: newStart, Lines: append([]Line(nil), lines...)}
+	for _, line := range lines {
+		if line.Kind != '+' {
+			hunk.OldLines++
+		}
+		if line.Kind != '-' {
+			hunk.NewLines++
+		}
+	}
+	return hunk
+}
+
+// Header returns the "@@ -old +new @@" line describing the hunk's ranges.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+// hunkRange formats a range like diff does, where an empty range names the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Write prints the hunk in unified diff format.
+func (h Hunk) Write(w io.Writer) {
+	fmt.Fprintln(w, h.Header())
+	for _, line := range h.Lines {
+		fmt.Fprintf(w, "%c%s", line.Kind, line.Text)
+		if !strings.HasSuffix(line.Text, "\n") {
+			fmt.Fprint(w, "\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// Split breaks the hunk into smaller hunks, one for each group of changes separated by context.
+// The context between two groups is shared by both halves. A hunk that cannot be split is
+// returned unchanged.
+func Split(h Hunk) []Hunk {
+	// Find the [start, end) ranges of consecutive changed lines
+	var groups [][2]int
+	for i := 0; i < len(h.Lines); i++ {
+		if h.Lines[i].Kind == ' ' {
+			continue
+		}
+		start := i
+		for i < len(h.Lines) && h.Lines[i].Kind != ' ' {
+			i++
+		}
+		groups = append(groups, [2]int{start, i})
+	}
+	if len(groups) < 2 {
+		return []Hunk{h}
+	}
+
+	var hunks []Hunk
+	for g := range groups {
+		start, end := 0, len(h.Lines)
+		if g > 0 {
+			start = groups[g-1][1]
+		}
+		if g < len(groups)-1 {
+			end = groups[g+1][0]
+		}
+
+		oldStart, newStart := h.OldStart, h.NewStart
+		for _, line := range h.Lines[:start] {
+			if line.Kind != '+' {
+				oldStart++
+			}
+			if line.Kind != '-' {
+				newStart++
+			}
+		}
+		hunks = append(hunks, NewHunk(oldStart, newStart, h.Lines[start:end]))
+	}
+	return hunks
+}
+
+// Apply applies the hunks, sorted by OldStart, to the lines of the old version and returns the
+// lines of the result. Hunks may overlap on context lines, as the halves of a split hunk do.
+// An error is returned if a hunk's context or removed lines do not match old.
+func Apply(old []string, hunks []Hunk) ([]string, error) {
+	var result []string
+	pos := 0
+	for _, hunk := range hunks {
+		start := hunk.OldStart - 1
+		if hunk.OldLines == 0 && start > len(old) {
+			start = len(old)
+		}
+		if start < 0 || start > len(old) {
+			return nil, fmt.Errorf("hunk %s is out of range", hunk.Header())
+		}
+
+		lines := hunk.Lines
+		if start < pos {
+			// Skip the context already copied by the previous hunk
+			for skip := pos - start; skip > 0; skip-- {
+				if len(lines) == 0 || lines[0].Kind != ' ' {
+					return nil, fmt.Errorf("hunk %s overlaps the previous hunk", hunk.Header())
+				}
+				lines = lines[1:]
+			}
+			start = pos
+		}
+		result = append(result, old[pos:start]...)
+		pos = start
+
+		for _, line := range lines {
+			switch line.Kind {
+			case ' ', '-':
+				if pos >= len(old) || old[pos] != line.Text {
+					return nil, fmt.Errorf("hunk %s does not apply", hunk.Header())
+				}
+				if line.Kind == ' ' {
+					result = append(result, line.Text)
+				}
+				pos++
+			case '+':
+				result = append(result, line.Text)
+			}
+		}
+	}
+	return append(result, old[pos:]...), nil
+}
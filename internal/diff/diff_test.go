@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// lines splits text into lines the way files are split.
+func lines(text string) []string {
+	return SplitLines([]byte(text))
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		edits int // Length of the shortest edit script
+	}{
+		{"identical", "a\nb\nc\n", "a\nb\nc\n", 0},
+		{"both empty", "", "", 0},
+		{"from empty", "", "a\nb\n", 2},
+		{"to empty", "a\nb\n", "", 2},
+		{"insert in middle", "a\nc\n", "a\nb\nc\n", 1},
+		{"delete in middle", "a\nb\nc\n", "a\nc\n", 1},
+		{"replace line", "a\nb\nc\n", "a\nx\nc\n", 2},
+		{"swap", "a\nb\n", "b\na\n", 2},
+		{"missing final newline", "a\nb", "a\nb\n", 2},
+		{"interleaved", "a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", 5},
+		{"rewrite", "a\nb\nc\nd\n", "w\nx\ny\nz\n", 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := lines(test.a), lines(test.b)
+			script := Diff(a, b)
+
+			var oldText, newText strings.Builder
+			edits := 0
+			for _, line := range script {
+				if line.Kind != '+' {
+					oldText.WriteString(line.Text)
+				}
+				if line.Kind != '-' {
+					newText.WriteString(line.Text)
+				}
+				if line.Kind != ' ' {
+					edits++
+				}
+			}
+			if oldText.String() != test.a || newText.String() != test.b {
+				t.Errorf("script turns %q into %q, want %q into %q", oldText.String(), newText.String(), test.a, test.b)
+			}
+			if edits != test.edits {
+				t.Errorf("script has %d edits, want %d", edits, test.edits)
+			}
+		})
+	}
+}
+
+func TestDiffLargeRewrite(t *testing.T) {
+	// Both versions share no line, which is the worst case for the search
+	var a, b []string
+	for i := 0; i < 4000; i++ {
+		a = append(a, "old "+strings.Repeat("x", i%7)+"\n")
+		b = append(b, "new "+strings.Repeat("y", i%5)+"\n")
+	}
+	if got := len(Diff(a, b)); got != 8000 {
+		t.Errorf("script has %d lines, want 8000", got)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		headers []string
+	}{
+		{"no change", "a\nb\n", "a\nb\n", 3, nil},
+		{"single change", "a\nb\nc\nd\ne\n", "a\nb\nX\nd\ne\n", 1, []string{"@@ -2,3 +2,3 @@"}},
+		{"added at end", "a\n", "a\nb\n", 3, []string{"@@ -1 +1,2 @@"}},
+		{"everything removed", "a\nb\n", "", 3, []string{"@@ -1,2 +0,0 @@"}},
+		{"separate changes", "1\n2\n3\n4\n5\n6\n7\n8\n", "X\n2\n3\n4\n5\n6\n7\nY\n", 1, []string{"@@ -1,2 +1,2 @@", "@@ -7,2 +7,2 @@"}},
+		{"close changes are merged", "1\n2\n3\n4\n5\n", "X\n2\n3\n4\nY\n", 2, []string{"@@ -1,5 +1,5 @@"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var headers []string
+			for _, hunk := range Hunks(lines(test.a), lines(test.b), test.context) {
+				headers = append(headers, hunk.Header())
+			}
+			if strings.Join(headers, " ") != strings.Join(test.headers, " ") {
+				t.Errorf("hunks %q, want %q", headers, test.headers)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		split bool // Apply the hunks split into their smallest parts
+	}{
+		{"single change", "a\nb\nc\n", "a\nB\nc\n", false},
+		{"insertions and deletions", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "0\n1\n3\n4\n5\n6\n7\n8\n9\n10\n", false},
+		{"split hunk", "1\n2\n3\n4\n5\n", "X\n2\n3\n4\nY\n", true},
+		{"new file", "", "a\nb\n", false},
+		{"no final newline", "a\nb", "a\nc", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hunks := Hunks(lines(test.a), lines(test.b), 3)
+			if test.split {
+				var parts []Hunk
+				for _, hunk := range hunks {
+					parts = append(parts, Split(hunk)...)
+				}
+				if len(parts) <= len(hunks) {
+					t.Fatalf("split %d hunks into %d", len(hunks), len(parts))
+				}
+				hunks = parts
+			}
+			result, err := Apply(lines(test.a), hunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(result, ""); got != test.b {
+				t.Errorf("Apply gives %q, want %q", got, test.b)
+			}
+		})
+	}
+}
+
+func TestApplyMismatch(t *testing.T) {
+	hunks := Hunks(lines("a\nb\nc\n"), lines("a\nB\nc\n"), 1)
+	tests := []struct {
+		name string
+		old  string
+	}{
+		{"changed context", "x\nb\nc\n"},
+		{"changed removed line", "a\nx\nc\n"},
+		{"too short", "a\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result, err := Apply(lines(test.old), hunks); err == nil {
+				t.Errorf("Apply gives %q, want an error", strings.Join(result, ""))
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicted         bool
+	}{
+		{
+			name: "unchanged",
+			base: "a\nb\n", ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "only ours",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "only theirs",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "a\nb\nC\n",
+		},
+		{
+			name: "separate changes",
+			base: "1\n2\n3\n4\n5\n", ours: "one\n2\n3\n4\n5\n", theirs: "1\n2\n3\n4\nfive\n",
+			want: "one\n2\n3\n4\nfive\n",
+		},
+		{
+			name: "identical changes",
+			base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nX\nc\n",
+			want: "a\nX\nc\n",
+		},
+		{
+			name: "conflict",
+			base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:       "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\nc\n",
+			conflicted: true,
+		},
+		{
+			name: "conflict without final newline",
+			base: "a\nb", ours: "a\nours", theirs: "a\ntheirs",
+			want:       "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\n",
+			conflicted: true,
+		},
+		{
+			name: "delete and modify",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nB\nc\n",
+			want:       "a\n<<<<<<< HEAD\n=======\nB\n>>>>>>> topic\nc\n",
+			conflicted: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, conflicted := Merge(lines(test.base), lines(test.ours), lines(test.theirs), "HEAD", "topic")
+			if got := strings.Join(result, ""); got != test.want {
+				t.Errorf("Merge gives %q, want %q", got, test.want)
+			}
+			if conflicted != test.conflicted {
+				t.Errorf("Merge reports conflicted = %v, want %v", conflicted, test.conflicted)
+			}
+		})
+	}
+}
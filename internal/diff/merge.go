@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+// Merge combines the changes made to base in ours and in theirs and returns the lines of the
+// result. Changes to separate parts of the file are both kept. Changes that overlap or touch
+// conflict unless they are identical; each conflict is written out between "<<<<<<<",
+// "=======" and ">>>>>>>" markers followed by the given labels, and Merge reports whether
+// there were any.
+func Merge(base, ours, theirs []string, oursLabel, theirsLabel string) ([]string, bool) {
+	type change struct {
+		hunk  Hunk
+		start int // Index of the first base line the hunk replaces
+		end   int // Index after the last base line the hunk replaces
+		ours  bool
+	}
+	var changes []change
+	for _, side := range []struct {
+		version []string
+		ours    bool
+	}{{ours, true}, {theirs, false}} {
+		for _, hunk := range Hunks(base, side.version, 0) {
+			start := hunk.OldStart - 1
+			changes = append(changes, change{hunk: hunk, start: start, end: start + hunk.OldLines, ours: side.ours})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].start < changes[j].start
+	})
+
+	// region returns the lines of base[start:end] with the hunks applied
+	region := func(start, end int, hunks []Hunk) []string {
+		var lines []string
+		pos := start
+		for _, hunk := range hunks {
+			lines = append(lines, base[pos:hunk.OldStart-1]...)
+			for _, line := range hunk.Lines {
+				if line.Kind == '+' {
+					lines = append(lines, line.Text)
+				}
+			}
+			pos = hunk.OldStart - 1 + hunk.OldLines
+		}
+		return append(lines, base[pos:end]...)
+	}
+
+	var result []string
+	conflicted := false
+	pos := 0
+	for i := 0; i < len(changes); {
+		// Group the changes that overlap or touch, which only happens across the two sides
+		start, end := changes[i].start, changes[i].end
+		var oursHunks, theirsHunks []Hunk
+		for ; i < len(changes) && changes[i].start <= end; i++ {
+			if changes[i].end > end {
+				end = changes[i].end
+			}
+			if changes[i].ours {
+				oursHunks = append(oursHunks, changes[i].hunk)
+			} else {
+				theirsHunks = append(theirsHunks, changes[i].hunk)
+			}
+		}
+		result = append(result, base[pos:start]...)
+		pos = end
+
+		oursLines, theirsLines := region(start, end, oursHunks), region(start, end, theirsHunks)
+		switch {
+		case len(theirsHunks) == 0:
+			result = append(result, oursLines...)
+		case len(oursHunks) == 0, strings.Join(oursLines, "") == strings.Join(theirsLines, ""):
+			result = append(result, theirsLines...)
+		default:
+			conflicted = true
+			result = append(result, "<<<<<<< "+oursLabel+"\n")
+			result = append(result, terminated(oursLines)...)
+			result = append(result, "=======\n")
+			result = append(result, terminated(theirsLines)...)
+			result = append(result, ">>>>>>> "+theirsLabel+"\n")
+		}
+	}
+	return append(result, base[pos:]...), conflicted
+}
+
+// terminated returns the lines with a newline added to the last one if it has none, so that a
+// conflict marker can follow it.
+func terminated(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	lines = append([]string(nil), lines...)
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,68 @@
+// Package editor lets the user edit text in their preferred editor.
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Command returns the editor command to run: $GITX_EDITOR, $VISUAL or $EDITOR, in that order,
+// falling back to vi (notepad on Windows).
+func Command() string {
+	for _, name := range []string{"GITX_EDITOR", "VISUAL", "EDITOR"} {
+		if command := os.Getenv(name); command != "" {
+			return command
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// Edit writes content to a temporary file called name, opens it in the user's editor and
+// returns the edited content once the editor exits.
+func Edit(name string, content []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "gitx-edit-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, content, 0600); err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", name, err)
+	}
+	if err := Run(file); err != nil {
+		return nil, err
+	}
+
+	edited, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	return edited, nil
+}
+
+// Run opens the file in the user's editor and waits for it to exit. The editor command is run
+// through the shell, so it may contain arguments.
+func Run(file string) error {
+	command := Command()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command+" "+file)
+	} else {
+		cmd = exec.Command("sh", "-c", command+` "$@"`, command, file)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("there was a problem with the editor '%s': %w", command, err)
+	}
+	return nil
+}
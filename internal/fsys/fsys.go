@@ -0,0 +1,35 @@
+package fsys
+
+import (
+	"io"
+	"io/fs"
+)
+
+// FS is a writable file system. Like io/fs, names are unrooted, slash-separated paths
+// such as "refs/heads/main", and "." is the root of the file system.
+type FS interface {
+	fs.StatFS
+	fs.ReadFileFS
+	fs.ReadDirFS
+
+	// Create creates or truncates the named file and returns a writer for its content.
+	Create(name string) (io.WriteCloser, error)
+	// WriteFile writes data to the named file, creating it if necessary.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	// AppendFile appends data to the named file, creating it if necessary.
+	AppendFile(name string, data []byte, perm fs.FileMode) error
+	// MkdirAll creates a directory along with any necessary parents.
+	MkdirAll(name string, perm fs.FileMode) error
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+	// RemoveAll removes the named file or directory and any children it contains.
+	RemoveAll(name string) error
+	// Rename moves oldname to newname, replacing newname if it is a file.
+	Rename(oldname, newname string) error
+}
+
+// Exists reports whether the named file or directory exists.
+func Exists(fsys FS, name string) bool {
+	_, err := fsys.Stat(name)
+	return err == nil
+}
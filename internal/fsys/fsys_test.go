@@ -0,0 +1,201 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// backends returns a fresh instance of every FS implementation.
+func backends(t *testing.T) map[string]FS {
+	return map[string]FS{
+		"mem": NewMemFS(),
+		"os":  NewOSFS(t.TempDir()),
+	}
+}
+
+// names returns the names of the entries of the directory.
+func names(t *testing.T, fsys FS, dir string) []string {
+	t.Helper()
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []string
+	for _, entry := range entries {
+		result = append(result, entry.Name())
+	}
+	return result
+}
+
+func TestReadWrite(t *testing.T) {
+	for name, fsys := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := fsys.MkdirAll("a/b", 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.MkdirAll("a/b", 0755); err != nil {
+				t.Errorf("MkdirAll of an existing directory: %v", err)
+			}
+			if err := fsys.WriteFile("a/b/c.txt", []byte("one"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.WriteFile("a/b/c.txt", []byte("two"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			w, err := fsys.Create("a/d.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte("three")); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			for file, want := range map[string]string{"a/b/c.txt": "two", "a/d.txt": "three"} {
+				data, err := fsys.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Errorf("ReadFile(%q) = %q, want %q", file, data, want)
+				}
+			}
+
+			info, err := fsys.Stat("a/b/c.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Name() != "c.txt" || info.Size() != 3 || info.IsDir() {
+				t.Errorf("Stat(a/b/c.txt) = %s %d %v", info.Name(), info.Size(), info.IsDir())
+			}
+			if info, err := fsys.Stat("a/b"); err != nil || !info.IsDir() {
+				t.Errorf("Stat(a/b) = %v, %v, want a directory", info, err)
+			}
+			if got := names(t, fsys, "a"); !slices.Equal(got, []string{"b", "d.txt"}) {
+				t.Errorf("ReadDir(a) = %q, want [b d.txt]", got)
+			}
+
+			if err := fstest.TestFS(fsys, "a/b/c.txt", "a/d.txt"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAppendFile(t *testing.T) {
+	for name, fsys := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, data := range []string{"one\n", "two\n"} {
+				if err := fsys.AppendFile("log", []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if data, err := fsys.ReadFile("log"); err != nil || string(data) != "one\ntwo\n" {
+				t.Errorf("ReadFile(log) = %q, %v, want %q", data, err, "one\ntwo\n")
+			}
+		})
+	}
+}
+
+func TestRenameAndRemove(t *testing.T) {
+	for name, fsys := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := fsys.MkdirAll("a/b", 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range []string{"a/b/c.txt", "a/d.txt"} {
+				if err := fsys.WriteFile(file, []byte(file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Renaming a directory moves everything below it
+			if err := fsys.Rename("a", "z"); err != nil {
+				t.Fatal(err)
+			}
+			if Exists(fsys, "a") {
+				t.Error("a still exists after renaming it")
+			}
+			if data, err := fsys.ReadFile("z/b/c.txt"); err != nil || string(data) != "a/b/c.txt" {
+				t.Errorf("ReadFile(z/b/c.txt) = %q, %v", data, err)
+			}
+			if err := fsys.Rename("z/d.txt", "z/b/c.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := fsys.ReadFile("z/b/c.txt"); err != nil || string(data) != "a/d.txt" {
+				t.Errorf("ReadFile(z/b/c.txt) after replacing it = %q, %v", data, err)
+			}
+
+			if err := fsys.Remove("z/b"); err == nil {
+				t.Error("Remove of a non-empty directory succeeds")
+			}
+			if err := fsys.Remove("z/b/c.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.Remove("z/b"); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.WriteFile("z/e.txt", nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.RemoveAll("z"); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.RemoveAll("z"); err != nil {
+				t.Errorf("RemoveAll of a missing directory: %v", err)
+			}
+			if got := names(t, fsys, "."); len(got) != 0 {
+				t.Errorf("ReadDir(.) = %q after RemoveAll, want nothing", got)
+			}
+		})
+	}
+}
+
+func TestNotExist(t *testing.T) {
+	for name, fsys := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			operations := map[string]func() error{
+				"ReadFile": func() error { _, err := fsys.ReadFile("missing"); return err },
+				"Stat":     func() error { _, err := fsys.Stat("missing"); return err },
+				"ReadDir":  func() error { _, err := fsys.ReadDir("missing"); return err },
+				"Open":     func() error { _, err := fsys.Open("missing"); return err },
+				"Remove":   func() error { return fsys.Remove("missing") },
+				"Rename":   func() error { return fsys.Rename("missing", "other") },
+				"WriteFile in a missing directory": func() error {
+					return fsys.WriteFile("missing/file", nil, 0644)
+				},
+				"AppendFile in a missing directory": func() error {
+					return fsys.AppendFile("missing/file", nil, 0644)
+				},
+			}
+			for operation, run := range operations {
+				if err := run(); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("%s returns %v, want fs.ErrNotExist", operation, err)
+				}
+			}
+			if Exists(fsys, "missing") {
+				t.Error("Exists(missing) = true")
+			}
+		})
+	}
+}
+
+func TestInvalidPath(t *testing.T) {
+	for name, fsys := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, invalid := range []string{"/abs", "../up", "a//b"} {
+				if _, err := fsys.ReadFile(invalid); !errors.Is(err, fs.ErrInvalid) {
+					t.Errorf("ReadFile(%q) returns %v, want fs.ErrInvalid", invalid, err)
+				}
+				if err := fsys.WriteFile(invalid, nil, 0644); !errors.Is(err, fs.ErrInvalid) {
+					t.Errorf("WriteFile(%q) returns %v, want fs.ErrInvalid", invalid, err)
+				}
+			}
+		})
+	}
+}
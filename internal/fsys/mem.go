@@ -0,0 +1,361 @@
+package fsys
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNotEmpty is returned when removing a directory that still has children.
+var errNotEmpty = errors.New("directory not empty")
+
+// memFS is an FS held entirely in memory. It is safe for concurrent use.
+type memFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode // Keyed by name, "." being the root directory
+}
+
+// memNode is a file or directory stored in a memFS.
+type memNode struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS returns an empty in-memory FS.
+func NewMemFS() FS {
+	return &memFS{
+		nodes: map[string]*memNode{
+			".": {mode: fs.ModeDir | 0755, modTime: time.Now()},
+		},
+	}
+}
+
+func (m *memFS) info(name string, node *memNode) fs.FileInfo {
+	return &memFileInfo{name: path.Base(name), size: int64(len(node.data)), mode: node.mode, modTime: node.modTime}
+}
+
+// children returns the names of the direct children of the directory, sorted.
+func (m *memFS) children(dir string) []string {
+	var names []string
+	for name := range m.nodes {
+		if name != "." && path.Dir(name) == dir {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup returns the node for name, validating the name first.
+func (m *memFS) lookup(op, name string) (*memNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	node, ok := m.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, nil
+}
+
+// checkParent verifies that the parent directory of name exists.
+func (m *memFS) checkParent(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	parent, ok := m.nodes[path.Dir(name)]
+	if !ok {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := m.info(name, node)
+	if node.mode.IsDir() {
+		var entries []fs.DirEntry
+		for _, child := range m.children(name) {
+			entries = append(entries, fs.FileInfoToDirEntry(m.info(child, m.nodes[child])))
+		}
+		return &memDir{info: info, entries: entries}, nil
+	}
+
+	data := append([]byte(nil), node.data...)
+	return &memFile{info: info, reader: bytes.NewReader(data)}, nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return m.info(name, node), nil
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var entries []fs.DirEntry
+	for _, child := range m.children(name) {
+		entries = append(entries, fs.FileInfoToDirEntry(m.info(child, m.nodes[child])))
+	}
+	return entries, nil
+}
+
+func (m *memFS) Create(name string) (io.WriteCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := m.checkParent("create", name); err != nil {
+		return nil, err
+	}
+	if node, ok := m.nodes[name]; ok && node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	return &memWriter{fs: m, name: name}, nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.writeFile("write", name, data, perm)
+}
+
+func (m *memFS) AppendFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if node, ok := m.nodes[name]; ok && !node.mode.IsDir() {
+		data = append(append([]byte(nil), node.data...), data...)
+	}
+	return m.writeFile("append", name, data, perm)
+}
+
+func (m *memFS) writeFile(op, name string, data []byte, perm fs.FileMode) error {
+	if err := m.checkParent(op, name); err != nil {
+		return err
+	}
+	if node, ok := m.nodes[name]; ok {
+		if node.mode.IsDir() {
+			return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+		}
+		perm = node.mode
+	}
+	m.nodes[name] = &memNode{data: append([]byte(nil), data...), mode: perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *memFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return nil
+	}
+
+	current := ""
+	for _, part := range strings.Split(name, "/") {
+		current = path.Join(current, part)
+		if node, ok := m.nodes[current]; ok {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: current, Err: fs.ErrExist}
+			}
+			continue
+		}
+		m.nodes[current] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	if node.mode.IsDir() && len(m.children(name)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+func (m *memFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	for key := range m.nodes {
+		if key == "." {
+			continue
+		}
+		if name == "." || key == name || strings.HasPrefix(key, name+"/") {
+			delete(m.nodes, key)
+		}
+	}
+	return nil
+}
+
+func (m *memFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("rename", oldname)
+	if err != nil {
+		return err
+	}
+	if err := m.checkParent("rename", newname); err != nil {
+		return err
+	}
+	if target, ok := m.nodes[newname]; ok && target.mode.IsDir() {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	}
+	if node.mode.IsDir() && strings.HasPrefix(newname, oldname+"/") {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrInvalid}
+	}
+
+	// Directories are moved along with everything below them
+	for key, child := range m.nodes {
+		if key == oldname || strings.HasPrefix(key, oldname+"/") {
+			delete(m.nodes, key)
+			m.nodes[newname+strings.TrimPrefix(key, oldname)] = child
+		}
+	}
+	return nil
+}
+
+// memFileInfo describes a memNode.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memFileInfo) Sys() any           { return nil }
+
+// memFile is an open regular file of a memFS.
+type memFile struct {
+	info   fs.FileInfo
+	reader *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open directory of a memFS.
+type memDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// memWriter buffers the content written to a file and stores it when closed.
+type memWriter struct {
+	fs     *memFS
+	name   string
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *memWriter) Write(b []byte) (int, error) {
+	if w.closed {
+		return 0, fs.ErrClosed
+	}
+	return w.buf.Write(b)
+}
+
+func (w *memWriter) Close() error {
+	if w.closed {
+		return fs.ErrClosed
+	}
+	w.closed = true
+
+	w.fs.mu.Lock()
+	defer w.fs.mu.Unlock()
+	return w.fs.writeFile("create", w.name, w.buf.Bytes(), 0644)
+}
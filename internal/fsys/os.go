@@ -0,0 +1,133 @@
+package fsys
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// osFS is an FS backed by a directory of the operating system's file system.
+type osFS struct {
+	root string
+}
+
+// NewOSFS returns an FS for the tree of files rooted at the directory root.
+func NewOSFS(root string) FS {
+	return &osFS{root: root}
+}
+
+// IsOS reports whether fsys is backed by the operating system's file system, as the FS of
+// NewOSFS is, so that its files can be handed to other programs.
+func IsOS(fsys FS) bool {
+	_, ok := fsys.(*osFS)
+	return ok
+}
+
+// path converts a slash-separated name into a path under the root directory.
+func (f *osFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(f.root, filepath.FromSlash(name)), nil
+}
+
+func (f *osFS) Open(name string) (fs.File, error) {
+	path, err := f.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (f *osFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := f.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func (f *osFS) ReadFile(name string) ([]byte, error) {
+	path, err := f.path("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (f *osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := f.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(path)
+}
+
+func (f *osFS) Create(name string) (io.WriteCloser, error) {
+	path, err := f.path("create", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func (f *osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	path, err := f.path("write", name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+func (f *osFS) AppendFile(name string, data []byte, perm fs.FileMode) error {
+	path, err := f.path("append", name)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *osFS) MkdirAll(name string, perm fs.FileMode) error {
+	path, err := f.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, perm)
+}
+
+func (f *osFS) Remove(name string) error {
+	path, err := f.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (f *osFS) RemoveAll(name string) error {
+	path, err := f.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (f *osFS) Rename(oldname, newname string) error {
+	oldPath, err := f.path("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newPath, err := f.path("rename", newname)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
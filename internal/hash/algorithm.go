@@ -0,0 +1,75 @@
+package hash
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	stdhash "hash"
+	"strings"
+)
+
+// Algorithm is a hash function that objects, commits and trees are identified with. Each
+// repository uses a single algorithm, chosen when it is created.
+type Algorithm struct {
+	Name string // Name of the algorithm as recorded in the repository config
+	New  func() stdhash.Hash
+	Size int // Size of a digest in bytes
+}
+
+var (
+	// SHA1 is the algorithm of repositories that do not choose one.
+	SHA1 = &Algorithm{Name: "sha1", New: sha1.New, Size: sha1.Size}
+	// SHA256 is the algorithm of repositories created with --object-format=sha256.
+	SHA256 = &Algorithm{Name: "sha256", New: sha256.New, Size: sha256.Size}
+)
+
+// Lookup returns the algorithm with the given name. An empty name means SHA1.
+func Lookup(name string) (*Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "", SHA1.Name:
+		return SHA1, nil
+	case SHA256.Name:
+		return SHA256, nil
+	default:
+		return nil, fmt.Errorf("unknown object format %q", name)
+	}
+}
+
+// HexLen returns the length of an ID in hexadecimal.
+func (a *Algorithm) HexLen() int {
+	return 2 * a.Size
+}
+
+// Sum returns the hexadecimal digest of data.
+func (a *Algorithm) Sum(data []byte) string {
+	h := a.New()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ZeroID returns the ID made of zeros that stands for a missing object.
+func (a *Algorithm) ZeroID() string {
+	return strings.Repeat("0", a.HexLen())
+}
+
+// ValidID reports whether id is a full lowercase hexadecimal ID of the algorithm.
+func (a *Algorithm) ValidID(id string) bool {
+	if len(id) != a.HexLen() {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckID returns an error unless id is a full hexadecimal ID of the algorithm.
+func (a *Algorithm) CheckID(id string) error {
+	if !a.ValidID(id) {
+		return fmt.Errorf("invalid %s object ID %q: expected %d hexadecimal characters", a.Name, id, a.HexLen())
+	}
+	return nil
+}
@@ -1,71 +1,160 @@
 package hash
 
 import (
-	"crypto/sha1"
+	"GitX/internal/fsys"
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
-// SHA1Hash calculates the SHA-1 hash of the given file's content in Git blob format and stores the blob.
-func SHA1Hash(filePath string) (string, error) {
-	// Open the file for reading
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+// TempObjectPrefix starts the names of the temporary files in objects/ that blobs are written
+// to before their ID is known. Files left over by an interrupted write are not objects.
+const TempObjectPrefix = "tmp_obj_"
+
+// HashObject computes the ID with the given algorithm of the blob whose content of the given
+// size is read from r. The content is streamed, so large files are never held in memory. The
+// blob is only written to the store when store is not nil. A negative size means the length is
+// not known in advance, as for standard input; the content is then spooled to the store with
+// HashStream, since the blob header starts with the length.
+func HashObject(alg *Algorithm, store fsys.FS, r io.Reader, size int64) (string, error) {
+	if size < 0 {
+		if store == nil {
+			return "", errors.New("no object store to spool content of unknown length to")
+		}
+		return HashStream(alg, store, r, true)
 	}
-	defer file.Close()
 
-	// Get file info to retrieve its size
-	info, err := file.Stat()
+	header := fmt.Sprintf("blob %d\x00", size)
+	h := alg.New()
+	h.Write([]byte(header))
+	content := io.Reader(io.LimitReader(r, size))
+
+	// The object is written under a temporary name while hashing and renamed once its ID is known
+	var tempFile string
+	var object io.WriteCloser
+	if store != nil {
+		if err := store.MkdirAll("objects", fs.ModePerm); err != nil {
+			return "", err
+		}
+		var err error
+		if tempFile, object, err = createTempObject(store); err != nil {
+			return "", err
+		}
+		defer store.Remove(tempFile)
+		defer object.Close()
+		if _, err := object.Write([]byte(header)); err != nil {
+			return "", err
+		}
+		content = io.TeeReader(content, object)
+	}
+
+	written, err := io.Copy(h, content)
 	if err != nil {
 		return "", err
 	}
+	if written != size {
+		return "", fmt.Errorf("content is %d bytes instead of the expected %d", written, size)
+	}
+	id := hex.EncodeToString(h.Sum(nil))
 
-	// Create a new SHA-1 hash instance
-	h := sha1.New()
+	if store != nil {
+		if err := object.Close(); err != nil {
+			return "", err
+		}
+		objectDir := path.Join("objects", id[:2])
+		objectFile := path.Join(objectDir, id[2:])
+		if fsys.Exists(store, objectFile) {
+			return id, nil
+		}
+		if err := store.MkdirAll(objectDir, fs.ModePerm); err != nil {
+			return "", err
+		}
+		if err := store.Rename(tempFile, objectFile); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
 
-	// Write the blob header to the hash instance
-	header := fmt.Sprintf("blob %d\x00", info.Size())
-	if _, err := h.Write([]byte(header)); err != nil {
+// HashStream computes the ID with the given algorithm of the blob whose content, of a length not
+// known in advance, is read from r. The content is first spooled to a temporary file in objects/
+// of the store to learn its length, so it is never held in memory. The blob is only kept in the
+// store when write is set.
+func HashStream(alg *Algorithm, store fsys.FS, r io.Reader, write bool) (string, error) {
+	if err := store.MkdirAll("objects", fs.ModePerm); err != nil {
 		return "", err
 	}
-
-	// Write the file content to the hash instance
-	if _, err := io.Copy(h, file); err != nil {
+	spoolFile, spool, err := createTempObject(store)
+	if err != nil {
+		return "", err
+	}
+	defer store.Remove(spoolFile)
+	if _, err := io.Copy(spool, r); err != nil {
+		spool.Close()
 		return "", err
 	}
+	if err := spool.Close(); err != nil {
+		return "", err
+	}
+	if !write {
+		return hashFile(alg, nil, store, spoolFile)
+	}
+	return hashFile(alg, store, store, spoolFile)
+}
 
-	// Calculate the hash
-	hashed := h.Sum(nil)
+// tempObjects counts the temporary files created, so that names taken in the same clock tick
+// still differ.
+var tempObjects atomic.Uint64
 
-	// Encode the hashed result to hexadecimal string
-	hashedString := hex.EncodeToString(hashed)
+// createTempObject creates a file with a new temporary name in objects/ of the store.
+func createTempObject(store fsys.FS) (string, io.WriteCloser, error) {
+	name := strconv.FormatInt(time.Now().UnixNano(), 36) + "_" + strconv.FormatUint(tempObjects.Add(1), 36)
+	tempFile := path.Join("objects", TempObjectPrefix+name)
+	file, err := store.Create(tempFile)
+	return tempFile, file, err
+}
 
-	// Store the blob in the object database
-	objectDir := filepath.Join(".gitx", "objects", hashedString[:2])
-	objectFile := filepath.Join(objectDir, hashedString[2:])
-	if err := os.MkdirAll(objectDir, os.ModePerm); err != nil {
-		return "", err
+// FileID calculates the hash of the given file's content in Git blob format without storing
+// anything.
+func FileID(alg *Algorithm, files fs.FS, filePath string) (string, error) {
+	return hashFile(alg, nil, files, filePath)
+}
+
+// StoreFile calculates the hash of the given file's content in Git blob format and stores the
+// blob under objects/ in the store.
+func StoreFile(alg *Algorithm, store fsys.FS, files fs.FS, filePath string) (string, error) {
+	if store == nil {
+		return "", errors.New("no object store to write to")
 	}
+	return hashFile(alg, store, files, filePath)
+}
 
-	// Reopen the file for reading
-	file.Seek(0, 0)
-	blobFile, err := os.Create(objectFile)
+// hashFile streams a file of files through HashObject.
+func hashFile(alg *Algorithm, store fsys.FS, files fs.FS, filePath string) (string, error) {
+	file, err := files.Open(filePath)
 	if err != nil {
 		return "", err
 	}
-	defer blobFile.Close()
+	defer file.Close()
 
-	// Write the blob header and content to the object file
-	if _, err := blobFile.Write([]byte(header)); err != nil {
+	info, err := file.Stat()
+	if err != nil {
 		return "", err
 	}
-	if _, err := io.Copy(blobFile, file); err != nil {
-		return "", err
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", filePath)
 	}
+	return HashObject(alg, store, file, info.Size())
+}
 
-	return hashedString, nil
+// StoreBlob stores content as a blob under objects/ in the store and returns its hash.
+func StoreBlob(alg *Algorithm, store fsys.FS, content []byte) (string, error) {
+	return HashObject(alg, store, bytes.NewReader(content), int64(len(content)))
 }
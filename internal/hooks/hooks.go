@@ -0,0 +1,63 @@
+// Package hooks runs the executable hooks that let users extend repository operations.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Options describes how a hook is run.
+type Options struct {
+	Dir   string   // Directory the hook runs in, the root of the working tree
+	Args  []string // Arguments passed to the hook
+	Stdin string   // Data written to the hook's standard input
+	Env   []string // Variables added to the environment, as "NAME=value"
+}
+
+// Find returns the path of the hook called name in dir, and whether it exists and can be run.
+// Hooks that are not executable are ignored, so that disabling one only takes a chmod.
+func Find(dir, name string) (string, bool) {
+	hookPath := filepath.Join(dir, name)
+	info, err := os.Stat(hookPath)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return "", false
+	}
+	return hookPath, true
+}
+
+// Run runs the hook at hookPath and waits for it to exit. Its output goes to standard error, so
+// it does not mix with the output of the command. An error is returned if the hook cannot be
+// started or exits with a non-zero status.
+func Run(hookPath string, opts Options) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// Hooks are usually shell scripts, which Windows cannot run directly
+		cmd = exec.Command("sh", append([]string{hookPath}, opts.Args...)...)
+	} else {
+		cmd = exec.Command(hookPath, opts.Args...)
+	}
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdin = strings.NewReader(opts.Stdin)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("exited with status %d", exitErr.ExitCode())
+	}
+	if errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot run %s: %w", hookPath, err)
+	}
+	return err
+}
@@ -0,0 +1,272 @@
+// Package ignore implements gitignore-compatible pattern matching for .gitxignore files.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single line of an ignore file.
+type Pattern struct {
+	Source string // File the pattern was read from
+	Line   int    // Line number within Source, starting at 1
+	Text   string // The pattern as written in the file
+	Negate bool   // The pattern starts with "!" and re-includes matching paths
+
+	base     string // Slash-separated directory the pattern is relative to, "" for the root
+	dirOnly  bool   // The pattern ends with "/" and only matches directories
+	anchored bool   // The pattern contains a "/" and matches the full path below base
+	re       *regexp.Regexp
+}
+
+// ParsePatterns parses the content of an ignore file read from source. Patterns are relative
+// to base, the slash-separated directory holding the file ("" for the repository root).
+// Blank lines and comments are skipped.
+func ParsePatterns(data []byte, source, base string) []*Pattern {
+	var patterns []*Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		if pattern := parsePattern(scanner.Text(), source, base, line); pattern != nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// parsePattern parses a single line, returning nil for blank lines and comments.
+func parsePattern(text, source, base string, line int) *Pattern {
+	text = strings.TrimSuffix(text, "\r")
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+
+	pattern := &Pattern{Source: source, Line: line, Text: text, base: base}
+
+	// Trailing spaces are ignored unless they are escaped with a backslash
+	expr := text
+	for strings.HasSuffix(expr, " ") && !strings.HasSuffix(expr, "\\ ") {
+		expr = strings.TrimSuffix(expr, " ")
+	}
+
+	if strings.HasPrefix(expr, "!") {
+		pattern.Negate = true
+		expr = expr[1:]
+	} else if strings.HasPrefix(expr, "\\!") || strings.HasPrefix(expr, "\\#") {
+		expr = expr[1:]
+	}
+
+	if strings.HasSuffix(expr, "/") {
+		pattern.dirOnly = true
+		expr = strings.TrimRight(expr, "/")
+	}
+	if expr == "" {
+		return nil
+	}
+
+	// A slash at the beginning or in the middle anchors the pattern to base
+	if strings.Contains(expr, "/") {
+		pattern.anchored = true
+		expr = strings.TrimPrefix(expr, "/")
+	}
+
+	re, err := regexp.Compile("^" + translate(expr) + "$")
+	if err != nil {
+		return nil
+	}
+	pattern.re = re
+	return pattern
+}
+
+// translate converts a glob with gitignore's "**" rules into a regular expression.
+func translate(glob string) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/") && i == 0:
+			// Leading "**/" matches in all directories
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**/"):
+			// "/**/" matches zero or more directories
+			re.WriteString("/(?:.*/)?")
+			i += 3
+		case glob[i:] == "/**":
+			// Trailing "/**" matches everything inside
+			re.WriteString("/.*")
+			i += 2
+		case c == '*':
+			// Other consecutive asterisks are regular asterisks
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			class, n := translateClass(glob[i:])
+			if n == 0 {
+				re.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			re.WriteString(class)
+			i += n - 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return re.String()
+}
+
+// translateClass converts the bracket expression at the start of glob and returns it with
+// the number of bytes consumed, or 0 if the bracket is not closed.
+func translateClass(glob string) (string, int) {
+	var class strings.Builder
+	class.WriteString("[")
+	i := 1
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		class.WriteString("^")
+		i++
+	}
+	// A "]" right after the opening bracket is a literal
+	if i < len(glob) && glob[i] == ']' {
+		class.WriteString("\\]")
+		i++
+	}
+	for ; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case ']':
+			class.WriteString("]")
+			return class.String(), i + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				class.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		case '[', '^':
+			class.WriteString("\\" + string(c))
+		default:
+			class.WriteByte(c)
+		}
+	}
+	return "", 0
+}
+
+// Match reports whether the pattern matches the slash-separated path name, ignoring negation.
+func (p *Pattern) Match(name string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	rel := name
+	if p.base != "" {
+		if !strings.HasPrefix(name, p.base+"/") {
+			return false
+		}
+		rel = name[len(p.base)+1:]
+	}
+
+	if p.anchored {
+		return p.re.MatchString(rel)
+	}
+	return p.re.MatchString(path.Base(rel))
+}
+
+// Matcher decides whether paths in a working tree are ignored. Ignore files are read lazily
+// from each directory the first time a path below it is matched.
+type Matcher struct {
+	files    fs.FS
+	fileName string
+	base     []*Pattern
+	dirs     map[string][]*Pattern
+}
+
+// NewMatcher returns a Matcher reading the per-directory ignore files called fileName from files.
+// The base patterns, such as global excludes, have lower precedence than any of those files.
+func NewMatcher(files fs.FS, fileName string, base ...*Pattern) *Matcher {
+	return &Matcher{
+		files:    files,
+		fileName: fileName,
+		base:     base,
+		dirs:     make(map[string][]*Pattern),
+	}
+}
+
+// Match returns the pattern that decides whether the slash-separated path name is ignored,
+// or nil if no pattern matches. The returned pattern may be a negation, in which case the
+// path is explicitly not ignored. A path inside an ignored directory is matched by the
+// pattern that ignores the directory, since files cannot be re-included below it.
+func (m *Matcher) Match(name string, isDir bool) *Pattern {
+	name = strings.Trim(name, "/")
+	if name == "" || name == "." {
+		return nil
+	}
+
+	components := strings.Split(name, "/")
+	for i := 1; i < len(components); i++ {
+		dir := strings.Join(components[:i], "/")
+		if pattern := m.match(dir, true); pattern != nil && !pattern.Negate {
+			return pattern
+		}
+	}
+	return m.match(name, isDir)
+}
+
+// Ignored reports whether the slash-separated path name is ignored.
+func (m *Matcher) Ignored(name string, isDir bool) bool {
+	pattern := m.Match(name, isDir)
+	return pattern != nil && !pattern.Negate
+}
+
+// match applies the patterns in order of increasing precedence, the last match winning.
+func (m *Matcher) match(name string, isDir bool) *Pattern {
+	var matched *Pattern
+	for _, pattern := range m.base {
+		if pattern.Match(name, isDir) {
+			matched = pattern
+		}
+	}
+
+	// Files in deeper directories take precedence over the ones above them
+	dir := ""
+	rest := name
+	for {
+		for _, pattern := range m.load(dir) {
+			if pattern.Match(name, isDir) {
+				matched = pattern
+			}
+		}
+		component, remaining, found := strings.Cut(rest, "/")
+		if !found {
+			break
+		}
+		dir = path.Join(dir, component)
+		rest = remaining
+	}
+	return matched
+}
+
+// load returns the patterns of the ignore file in the slash-separated directory dir.
+func (m *Matcher) load(dir string) []*Pattern {
+	if patterns, ok := m.dirs[dir]; ok {
+		return patterns
+	}
+
+	var patterns []*Pattern
+	if m.files != nil {
+		source := path.Join(dir, m.fileName)
+		if data, err := fs.ReadFile(m.files, source); err == nil {
+			patterns = ParsePatterns(data, source, dir)
+		}
+	}
+	m.dirs[dir] = patterns
+	return patterns
+}
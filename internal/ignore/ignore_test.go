@@ -0,0 +1,148 @@
+package ignore
+
+import (
+	"GitX/internal/fsys"
+	"path"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+		{"/build", "build", false, true},
+		{"/build", "src/build", false, false},
+		{"doc/*.txt", "doc/notes.txt", false, true},
+		{"doc/*.txt", "doc/api/notes.txt", false, false},
+		{"doc/**/*.txt", "doc/api/notes.txt", false, true},
+		{"doc/**/*.txt", "doc/notes.txt", false, true},
+		{"**/temp", "a/b/temp", false, true},
+		{"**/temp", "temp", false, true},
+		{"a/**", "a/b/c", false, true},
+		{"a/**", "a", true, false},
+		{"file?.c", "file1.c", false, true},
+		{"file?.c", "file10.c", false, false},
+		{"[abc].txt", "b.txt", false, true},
+		{"[!abc].txt", "b.txt", false, false},
+		{"[a-c].txt", "c.txt", false, true},
+		{`\#notes`, "#notes", false, true},
+		{`\!important`, "!important", false, true},
+		{"trailing\\ ", "trailing ", false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			patterns := ParsePatterns([]byte(test.pattern+"\n"), ".gitxignore", "")
+			if len(patterns) != 1 {
+				t.Fatalf("parsed %d patterns, want 1", len(patterns))
+			}
+			if got := patterns[0].Match(test.name, test.isDir); got != test.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", test.name, test.isDir, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePatternsSkipsCommentsAndBlankLines(t *testing.T) {
+	patterns := ParsePatterns([]byte("# comment\n\n*.o\n   \n!keep.o\n"), ".gitxignore", "")
+	if len(patterns) != 2 {
+		t.Fatalf("parsed %d patterns, want 2", len(patterns))
+	}
+	if patterns[0].Text != "*.o" || patterns[0].Line != 3 || patterns[0].Negate {
+		t.Errorf("first pattern is %+v", patterns[0])
+	}
+	if patterns[1].Text != "!keep.o" || patterns[1].Line != 5 || !patterns[1].Negate {
+		t.Errorf("second pattern is %+v", patterns[1])
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	files := fsys.NewMemFS()
+	ignoreFiles := map[string]string{
+		".gitxignore":         "*.log\n!important.log\nbuild/\n/root-only\nvendor/\n",
+		"src/.gitxignore":     "*.tmp\n!debug.log\ngenerated\n",
+		"src/lib/.gitxignore": "!*.tmp\n",
+	}
+	for name, content := range ignoreFiles {
+		if err := files.MkdirAll(path.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := files.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global := ParsePatterns([]byte("*.swp\n*.bak\n"), "global", "")
+	exclude := ParsePatterns([]byte("!keep.bak\n"), ".gitx/info/exclude", "")
+	matcher := NewMatcher(files, ".gitxignore", append(global, exclude...)...)
+
+	tests := []struct {
+		name    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"important.log", false, false},
+		{"src/app.log", false, true},
+		{"src/debug.log", false, false},
+		{"debug.log", false, true},
+		{"build", true, true},
+		{"build/out.o", false, true},
+		{"src/build/out.o", false, true},
+		{"root-only", false, true},
+		{"src/root-only", false, false},
+		{"src/cache.tmp", false, true},
+		{"cache.tmp", false, false},
+		{"src/lib/cache.tmp", false, false},
+		{"src/generated", true, true},
+		{"src/generated/code.go", false, true},
+		{"file.swp", false, true},
+		{"old.bak", false, true},
+		{"keep.bak", false, false},
+		// Files cannot be re-included inside an ignored directory
+		{"vendor/important.log", false, true},
+		{"main.go", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matcher.Ignored(test.name, test.isDir); got != test.ignored {
+				t.Errorf("Ignored(%q) = %v, want %v", test.name, got, test.ignored)
+			}
+		})
+	}
+}
+
+func TestMatcherReportsDecidingPattern(t *testing.T) {
+	files := fsys.NewMemFS()
+	if err := files.WriteFile(".gitxignore", []byte("*.log\n!keep.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	matcher := NewMatcher(files, ".gitxignore")
+
+	tests := []struct {
+		name string
+		line int // 0 when no pattern matches
+	}{
+		{"a.log", 1},
+		{"keep.log", 2},
+		{"a.txt", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pattern := matcher.Match(test.name, false)
+			switch {
+			case test.line == 0 && pattern != nil:
+				t.Errorf("Match(%q) = line %d, want none", test.name, pattern.Line)
+			case test.line != 0 && (pattern == nil || pattern.Line != test.line || pattern.Source != ".gitxignore"):
+				t.Errorf("Match(%q) = %+v, want line %d of .gitxignore", test.name, pattern, test.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Package merkle builds Merkle trees over lists of commits, so that a single root hash stands for
+// a whole history, and produces and verifies proofs that a commit is part of such a history.
+//
+// Leaves hash 0x00 followed by the commit ID and inner nodes hash 0x01 followed by the hashes of
+// their two children, both as hexadecimal text, so a leaf can never be passed off as an inner
+// node. Nodes are paired from left to right on each level; an unpaired last node moves up to the
+// next level unchanged.
+package merkle
+
+import (
+	"GitX/internal/hash"
+	"errors"
+	"fmt"
+)
+
+// Prefixes separating the hashes of leaves from the hashes of inner nodes.
+const (
+	leafPrefix  = 0x00
+	innerPrefix = 0x01
+)
+
+// MerkleNode is a node of a Merkle tree. Leaves hold the ID of their commit; inner nodes hold
+// their two children.
+type MerkleNode struct {
+	Hash     string
+	CommitID string // Only set on leaves
+	Left     *MerkleNode
+	Right    *MerkleNode
+}
+
+// NewMerkleNode returns a leaf for commitID when left and right are nil, and otherwise the inner
+// node joining left and right.
+func NewMerkleNode(alg *hash.Algorithm, left, right *MerkleNode, commitID string) *MerkleNode {
+	if left == nil && right == nil {
+		return &MerkleNode{Hash: leafHash(alg, commitID), CommitID: commitID}
+	}
+	return &MerkleNode{Hash: innerHash(alg, left.Hash, right.Hash), Left: left, Right: right}
+}
+
+// NewMerkleTree builds the tree over the commit IDs, in the given order, and returns its root.
+func NewMerkleTree(alg *hash.Algorithm, commitIDs []string) (*MerkleNode, error) {
+	if len(commitIDs) == 0 {
+		return nil, errors.New("cannot build a Merkle tree without commits")
+	}
+
+	nodes := make([]*MerkleNode, len(commitIDs))
+	for i, id := range commitIDs {
+		nodes[i] = NewMerkleNode(alg, nil, nil, id)
+	}
+	for len(nodes) > 1 {
+		level := make([]*MerkleNode, 0, (len(nodes)+1)/2)
+		for i := 0; i < len(nodes); i += 2 {
+			if i+1 < len(nodes) {
+				level = append(level, NewMerkleNode(alg, nodes[i], nodes[i+1], ""))
+			} else {
+				level = append(level, nodes[i])
+			}
+		}
+		nodes = level
+	}
+	return nodes[0], nil
+}
+
+// ProofStep is a sibling hash on the path from a leaf to the root.
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // The sibling is on the left of the path
+}
+
+// Proof shows that a commit is the leaf at Index of a tree with Size leaves and the given Root.
+// It holds everything needed to check it, so it can be verified without the repository.
+type Proof struct {
+	Algorithm string      `json:"algorithm"`
+	Commit    string      `json:"commit"`
+	Index     int         `json:"index"`
+	Size      int         `json:"size"`
+	Root      string      `json:"root"`
+	Path      []ProofStep `json:"path"`
+}
+
+// Prove returns the proof that the commit at index is part of the tree over commitIDs.
+func Prove(alg *hash.Algorithm, commitIDs []string, index int) (*Proof, error) {
+	if index < 0 || index >= len(commitIDs) {
+		return nil, fmt.Errorf("leaf %d is out of range for %d commits", index, len(commitIDs))
+	}
+	root, err := NewMerkleTree(alg, commitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &Proof{
+		Algorithm: alg.Name,
+		Commit:    commitIDs[index],
+		Index:     index,
+		Size:      len(commitIDs),
+		Root:      root.Hash,
+		Path:      []ProofStep{},
+	}
+	// Walk down from the root, splitting the leaves the same way the levels were paired
+	node, offset, size := root, 0, len(commitIDs)
+	var steps []ProofStep
+	for node.Left != nil {
+		leftSize := leftLeaves(size)
+		if index-offset < leftSize {
+			steps = append(steps, ProofStep{Hash: node.Right.Hash})
+			node, size = node.Left, leftSize
+		} else {
+			steps = append(steps, ProofStep{Hash: node.Left.Hash, Left: true})
+			node, offset, size = node.Right, offset+leftSize, size-leftSize
+		}
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		proof.Path = append(proof.Path, steps[i])
+	}
+	return proof, nil
+}
+
+// Verify checks that the proof is consistent: hashing its commit with the sibling hashes of its
+// path gives its root, and the path has the shape of a tree of its size. Verify does not know
+// whether the root is genuine; callers compare it with a published root.
+func (p *Proof) Verify() error {
+	alg, err := hash.Lookup(p.Algorithm)
+	if err != nil {
+		return err
+	}
+	if err := alg.CheckID(p.Commit); err != nil {
+		return err
+	}
+	if p.Index < 0 || p.Index >= p.Size {
+		return fmt.Errorf("leaf %d is out of range for %d commits", p.Index, p.Size)
+	}
+	for _, step := range p.Path {
+		if err := alg.CheckID(step.Hash); err != nil {
+			return fmt.Errorf("invalid proof hash: %w", err)
+		}
+	}
+
+	// The sides of the siblings are implied by the index and the size
+	var sides []bool
+	offset, size := 0, p.Size
+	for size > 1 {
+		leftSize := leftLeaves(size)
+		if p.Index-offset < leftSize {
+			sides = append(sides, false)
+			size = leftSize
+		} else {
+			sides = append(sides, true)
+			offset, size = offset+leftSize, size-leftSize
+		}
+	}
+	if len(sides) != len(p.Path) {
+		return fmt.Errorf("proof has %d steps instead of %d", len(p.Path), len(sides))
+	}
+
+	current := leafHash(alg, p.Commit)
+	for i, step := range p.Path {
+		if step.Left != sides[len(sides)-1-i] {
+			return fmt.Errorf("proof step %d is on the wrong side", i+1)
+		}
+		if step.Left {
+			current = innerHash(alg, step.Hash, current)
+		} else {
+			current = innerHash(alg, current, step.Hash)
+		}
+	}
+	if current != p.Root {
+		return fmt.Errorf("proof leads to root %s instead of %s", current, p.Root)
+	}
+	return nil
+}
+
+// PrefixSize returns the number n of leading commit IDs whose tree has the given root, so that
+// a history that only grew since the root was published is recognized. found is false if no
+// prefix has that root.
+func PrefixSize(alg *hash.Algorithm, commitIDs []string, root string) (n int, found bool) {
+	// The roots of the perfect subtrees covering the prefix, largest first, like a binary counter
+	type subtree struct {
+		hash string
+		size int
+	}
+	var frontier []subtree
+	for i, id := range commitIDs {
+		frontier = append(frontier, subtree{leafHash(alg, id), 1})
+		for len(frontier) > 1 && frontier[len(frontier)-2].size == frontier[len(frontier)-1].size {
+			left, right := frontier[len(frontier)-2], frontier[len(frontier)-1]
+			frontier = append(frontier[:len(frontier)-2], subtree{innerHash(alg, left.hash, right.hash), left.size * 2})
+		}
+
+		prefixRoot := frontier[len(frontier)-1].hash
+		for j := len(frontier) - 2; j >= 0; j-- {
+			prefixRoot = innerHash(alg, frontier[j].hash, prefixRoot)
+		}
+		if prefixRoot == root {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// leftLeaves returns how many of size leaves are under the left child of their root: the
+// largest power of two below size, which is how pairing from the left splits them.
+func leftLeaves(size int) int {
+	left := 1
+	for left*2 < size {
+		left *= 2
+	}
+	return left
+}
+
+// leafHash returns the hash of the leaf for the commit.
+func leafHash(alg *hash.Algorithm, commitID string) string {
+	return alg.Sum(append([]byte{leafPrefix}, commitID...))
+}
+
+// innerHash returns the hash of the inner node with the given children.
+func innerHash(alg *hash.Algorithm, left, right string) string {
+	data := append([]byte{innerPrefix}, left...)
+	return alg.Sum(append(data, right...))
+}
@@ -0,0 +1,107 @@
+package merkle
+
+import (
+	"GitX/internal/hash"
+	"fmt"
+	"testing"
+)
+
+// commitIDs returns n distinct commit IDs.
+func commitIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = hash.SHA256.Sum([]byte(fmt.Sprintf("commit %d", i)))
+	}
+	return ids
+}
+
+func TestProveVerifies(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		ids := commitIDs(size)
+		root, err := NewMerkleTree(hash.SHA256, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for index := range ids {
+			t.Run(fmt.Sprintf("leaf %d of %d", index, size), func(t *testing.T) {
+				proof, err := Prove(hash.SHA256, ids, index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if proof.Root != root.Hash || proof.Commit != ids[index] || proof.Size != size {
+					t.Errorf("proof is for root %s, commit %s, size %d", proof.Root, proof.Commit, proof.Size)
+				}
+				if err := proof.Verify(); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func TestProveOutOfRange(t *testing.T) {
+	ids := commitIDs(3)
+	for _, index := range []int{-1, 3} {
+		if _, err := Prove(hash.SHA256, ids, index); err == nil {
+			t.Errorf("Prove(%d) of 3 commits succeeded", index)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedProofs(t *testing.T) {
+	ids := commitIDs(6)
+	other := hash.SHA256.Sum([]byte("other"))
+	tests := []struct {
+		name   string
+		tamper func(p *Proof)
+	}{
+		{"other commit", func(p *Proof) { p.Commit = other }},
+		{"other root", func(p *Proof) { p.Root = other }},
+		{"changed sibling", func(p *Proof) { p.Path[0].Hash = other }},
+		{"flipped side", func(p *Proof) { p.Path[0].Left = !p.Path[0].Left }},
+		{"dropped step", func(p *Proof) { p.Path = p.Path[:len(p.Path)-1] }},
+		{"extra step", func(p *Proof) { p.Path = append(p.Path, ProofStep{Hash: other}) }},
+		{"other index", func(p *Proof) { p.Index = 4 }},
+		{"index out of range", func(p *Proof) { p.Index = p.Size }},
+		{"other size", func(p *Proof) { p.Size = 4 }},
+		{"invalid hash", func(p *Proof) { p.Path[0].Hash = "not a hash" }},
+		{"unknown algorithm", func(p *Proof) { p.Algorithm = "md5" }},
+		{"wrong algorithm", func(p *Proof) { p.Algorithm = hash.SHA1.Name }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proof, err := Prove(hash.SHA256, ids, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.tamper(proof)
+			if err := proof.Verify(); err == nil {
+				t.Error("tampered proof verifies")
+			}
+		})
+	}
+}
+
+func TestPrefixSize(t *testing.T) {
+	ids := commitIDs(7)
+	for n := 1; n <= len(ids); n++ {
+		root, err := NewMerkleTree(hash.SHA256, ids[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The history grew since the root was published
+		if got, found := PrefixSize(hash.SHA256, ids, root.Hash); !found || got != n {
+			t.Errorf("PrefixSize of the root of %d commits = %d, %v", n, got, found)
+		}
+	}
+
+	// A rewritten history no longer starts with the published one
+	root, err := NewMerkleTree(hash.SHA256, ids[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := append([]string{hash.SHA256.Sum([]byte("rewritten"))}, ids[1:]...)
+	if n, found := PrefixSize(hash.SHA256, rewritten, root.Hash); found {
+		t.Errorf("rewritten history has a prefix of %d commits with the published root", n)
+	}
+}
@@ -0,0 +1,200 @@
+// Package patch lets the user pick hunks of a diff interactively. It is shared by the commands
+// that work on parts of files, such as add -p, reset -p and stash -p.
+package patch
+
+import (
+	"GitX/internal/diff"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Selector prompts for each hunk of a diff and records which ones the user picks.
+type Selector struct {
+	In     *bufio.Reader
+	Out    io.Writer
+	Action string // Verb used in the prompt, such as "Stage", "Unstage" or "Stash"
+
+	// Edit lets the user change a hunk in an editor. The "e" choice is not offered if it is nil.
+	Edit func(name string, content []byte) ([]byte, error)
+}
+
+// NewSelector returns a Selector reading answers from in and writing the hunks and prompts to out.
+func NewSelector(in io.Reader, out io.Writer, action string) *Selector {
+	return &Selector{In: bufio.NewReader(in), Out: out, Action: action}
+}
+
+// Select shows the hunks of the diff of path one at a time and asks which ones to pick.
+// old holds the lines the hunks apply to, and is used to check edited hunks. The picked hunks
+// are returned in order. quit reports that the user asked to stop; the hunks picked before
+// that are still returned, and no further files should be offered.
+func (s *Selector) Select(path string, old []string, hunks []diff.Hunk) (picked []diff.Hunk, quit bool, err error) {
+	fmt.Fprintf(s.Out, "diff --gitx a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+
+	hunks = append([]diff.Hunk(nil), hunks...)
+	decisions := make([]bool, len(hunks))
+
+	for i := 0; i < len(hunks); {
+		hunk := hunks[i]
+		canSplit := len(diff.Split(hunk)) > 1
+
+		options := "y,n,q,a,d"
+		if canSplit {
+			options += ",s"
+		}
+		if s.Edit != nil {
+			options += ",e"
+		}
+		options += ",?"
+
+		hunk.Write(s.Out)
+		fmt.Fprintf(s.Out, "(%d/%d) %s this hunk [%s]? ", i+1, len(hunks), s.Action, options)
+
+		answer, err := s.In.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "" && errors.Is(err, io.EOF) {
+			// Running out of input is the same as quitting
+			fmt.Fprintln(s.Out)
+			answer = "q"
+		}
+		if answer == "" {
+			continue
+		}
+
+		switch answer[0] {
+		case 'y':
+			decisions[i] = true
+			i++
+		case 'n':
+			i++
+		case 'a':
+			for ; i < len(hunks); i++ {
+				decisions[i] = true
+			}
+		case 'd':
+			i = len(hunks)
+		case 'q':
+			return pickedHunks(hunks, decisions), true, nil
+		case 's':
+			if !canSplit {
+				fmt.Fprintln(s.Out, "Sorry, cannot split this hunk")
+				continue
+			}
+			parts := diff.Split(hunk)
+			fmt.Fprintf(s.Out, "Split into %d hunks.\n", len(parts))
+			hunks = append(hunks[:i], append(parts, hunks[i+1:]...)...)
+			decisions = append(decisions[:i], append(make([]bool, len(parts)), decisions[i+1:]...)...)
+		case 'e':
+			if s.Edit == nil {
+				s.help(canSplit)
+				continue
+			}
+			edited, err := s.editHunk(old, hunk)
+			if err != nil {
+				fmt.Fprintln(s.Out, err)
+				continue
+			}
+			if edited == nil {
+				// Removing every line of the hunk means not picking it
+				i++
+				continue
+			}
+			hunks[i] = *edited
+			decisions[i] = true
+			i++
+		default:
+			s.help(canSplit)
+		}
+	}
+
+	return pickedHunks(hunks, decisions), false, nil
+}
+
+// help explains the choices offered by the prompt.
+func (s *Selector) help(canSplit bool) {
+	action := strings.ToLower(s.Action)
+	fmt.Fprintf(s.Out, "y - %s this hunk\n", action)
+	fmt.Fprintf(s.Out, "n - do not %s this hunk\n", action)
+	fmt.Fprintf(s.Out, "q - quit; do not %s this hunk or any of the remaining ones\n", action)
+	fmt.Fprintf(s.Out, "a - %s this hunk and all later hunks in the file\n", action)
+	fmt.Fprintf(s.Out, "d - do not %s this hunk or any of the later hunks in the file\n", action)
+	if canSplit {
+		fmt.Fprintln(s.Out, "s - split the current hunk into smaller hunks")
+	}
+	if s.Edit != nil {
+		fmt.Fprintln(s.Out, "e - manually edit the current hunk")
+	}
+	fmt.Fprintln(s.Out, "? - print help")
+}
+
+// editHunk lets the user edit the hunk and returns the result, or nil if every line was removed.
+// An edited hunk that no longer applies to old is rejected.
+func (s *Selector) editHunk(old []string, hunk diff.Hunk) (*diff.Hunk, error) {
+	var content strings.Builder
+	content.WriteString("# Manual hunk edit mode - see bottom for a quick guide.\n")
+	hunk.Write(&content)
+	content.WriteString("# ---\n")
+	content.WriteString("# To remove '-' lines, make them ' ' lines (context).\n")
+	content.WriteString("# To remove '+' lines, delete them.\n")
+	content.WriteString("# Lines starting with # will be removed.\n")
+	content.WriteString("# If the patch applies cleanly, the edited hunk will immediately be marked\n")
+	content.WriteString("# for " + strings.ToLower(s.Action) + "ing. If it does not apply cleanly, you will be given\n")
+	content.WriteString("# an opportunity to edit again. If all lines of the hunk are removed,\n")
+	content.WriteString("# then the edit is aborted and the hunk is left unchanged.\n")
+
+	edited, err := s.Edit("gitx-hunk-edit.diff", []byte(content.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []diff.Line
+	for _, text := range diff.SplitLines(edited) {
+		switch {
+		case strings.HasPrefix(text, "#"), strings.HasPrefix(text, "@@"):
+			continue
+		case strings.HasPrefix(text, "\\"):
+			// "\ No newline at end of file" applies to the line before it
+			if len(lines) > 0 {
+				lines[len(lines)-1].Text = strings.TrimSuffix(lines[len(lines)-1].Text, "\n")
+			}
+			continue
+		case text == "\n":
+			// Editors often strip the leading space of empty context lines
+			text = " \n"
+		}
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		switch text[0] {
+		case ' ', '-', '+':
+			lines = append(lines, diff.Line{Kind: text[0], Text: text[1:]})
+		default:
+			return nil, fmt.Errorf("your edited hunk has an invalid line: %s", strings.TrimSpace(text))
+		}
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	result := diff.NewHunk(hunk.OldStart, hunk.NewStart, lines)
+	if _, err := diff.Apply(old, []diff.Hunk{result}); err != nil {
+		return nil, errors.New("your edited hunk does not apply; edit again or answer n to skip it")
+	}
+	return &result, nil
+}
+
+// pickedHunks returns the hunks whose decision is true.
+func pickedHunks(hunks []diff.Hunk, decisions []bool) []diff.Hunk {
+	var picked []diff.Hunk
+	for i, hunk := range hunks {
+		if decisions[i] {
+			picked = append(picked, hunk)
+		}
+	}
+	return picked
+}
@@ -0,0 +1,366 @@
+// Package signing signs and verifies data with Ed25519 keys in the SSH signature format, so that
+// signatures made by GitX can also be checked with "ssh-keygen -Y verify", and the other way
+// around.
+//
+// Private keys are read from unencrypted OpenSSH key files, as written by
+// "ssh-keygen -t ed25519", or from PKCS#8 PEM files, as written by
+// "openssl genpkey -algorithm ed25519". Trusted public keys are listed in an allowed signers
+// file in the format of ssh-keygen(1).
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Namespace is the SSH signature namespace of commits and tags, the same one Git uses.
+const Namespace = "git"
+
+const (
+	keyType        = "ssh-ed25519"
+	sigMagic       = "SSHSIG"
+	sigVersion     = 1
+	sigHash        = "sha512"
+	armorBegin     = "-----BEGIN SSH SIGNATURE-----"
+	armorEnd       = "-----END SSH SIGNATURE-----"
+	armorLineWidth = 70
+	opensshMagic   = "openssh-key-v1\x00"
+)
+
+// LoadPrivateKey reads the Ed25519 private key in the file at keyPath. A leading "~/" stands for
+// the user's home directory.
+func LoadPrivateKey(keyPath string) (ed25519.PrivateKey, error) {
+	if rest, ok := strings.CutPrefix(keyPath, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		keyPath = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading signing key: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey parses an unencrypted OpenSSH or PKCS#8 PEM Ed25519 private key.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not in PEM format")
+	}
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY":
+		return parseOpenSSHKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key is a %T, not an Ed25519 key", key)
+		}
+		return edKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %q", block.Type)
+	}
+}
+
+// parseOpenSSHKey parses the body of an "openssh-key-v1" private key file.
+func parseOpenSSHKey(data []byte) (ed25519.PrivateKey, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(opensshMagic))
+	if !ok {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+	r := &reader{data: rest}
+	cipher, kdf, _ := r.bytes(), r.bytes(), r.bytes()
+	count := r.uint32()
+	for i := uint32(0); i < count; i++ {
+		r.bytes()
+	}
+	private := &reader{data: r.bytes()}
+	if r.err != nil {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+	if string(cipher) != "none" || string(kdf) != "none" {
+		return nil, errors.New("encrypted signing keys are not supported")
+	}
+	if count != 1 {
+		return nil, fmt.Errorf("signing key file holds %d keys instead of one", count)
+	}
+
+	if private.uint32() != private.uint32() {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+	if kind := private.bytes(); string(kind) != keyType {
+		return nil, fmt.Errorf("signing key is a %s key, not an Ed25519 key", kind)
+	}
+	public, key := private.bytes(), private.bytes()
+	if private.err != nil || len(public) != ed25519.PublicKeySize || len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// Sign signs message in namespace and returns the armored SSH signature.
+func Sign(key ed25519.PrivateKey, namespace string, message []byte) string {
+	signature := ed25519.Sign(key, signedData(namespace, sigHash, message))
+
+	var blob bytes.Buffer
+	blob.WriteString(sigMagic)
+	binary.Write(&blob, binary.BigEndian, uint32(sigVersion))
+	writeString(&blob, publicKeyBlob(key.Public().(ed25519.PublicKey)))
+	writeString(&blob, []byte(namespace))
+	writeString(&blob, nil)
+	writeString(&blob, []byte(sigHash))
+	var sig bytes.Buffer
+	writeString(&sig, []byte(keyType))
+	writeString(&sig, signature)
+	writeString(&blob, sig.Bytes())
+
+	encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+	lines := []string{armorBegin}
+	for len(encoded) > armorLineWidth {
+		lines = append(lines, encoded[:armorLineWidth])
+		encoded = encoded[armorLineWidth:]
+	}
+	return strings.Join(append(lines, encoded, armorEnd), "\n")
+}
+
+// Verify checks the armored SSH signature of message in namespace and returns the public key
+// that made it. Whether that key is trusted is up to the caller.
+func Verify(armored, namespace string, message []byte) (ed25519.PublicKey, error) {
+	body, ok := strings.CutPrefix(strings.TrimSpace(armored), armorBegin)
+	if !ok {
+		return nil, errors.New("not an SSH signature")
+	}
+	body, ok = strings.CutSuffix(body, armorEnd)
+	if !ok {
+		return nil, errors.New("not an SSH signature")
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", err)
+	}
+
+	rest, ok := bytes.CutPrefix(blob, []byte(sigMagic))
+	if !ok {
+		return nil, errors.New("invalid SSH signature")
+	}
+	r := &reader{data: rest}
+	version := r.uint32()
+	publicBlob, sigNamespace, _, hashName, sigBlob := r.bytes(), r.bytes(), r.bytes(), r.bytes(), r.bytes()
+	if r.err != nil || version != sigVersion {
+		return nil, errors.New("invalid SSH signature")
+	}
+	if string(sigNamespace) != namespace {
+		return nil, fmt.Errorf("signature is for namespace %q instead of %q", sigNamespace, namespace)
+	}
+	if string(hashName) != sigHash && string(hashName) != "sha256" {
+		return nil, fmt.Errorf("unsupported signature hash %q", hashName)
+	}
+
+	public, err := parsePublicKeyBlob(publicBlob)
+	if err != nil {
+		return nil, err
+	}
+	sig := &reader{data: sigBlob}
+	kind, signature := sig.bytes(), sig.bytes()
+	if sig.err != nil || string(kind) != keyType {
+		return nil, fmt.Errorf("unsupported signature type %q", kind)
+	}
+
+	if !ed25519.Verify(public, signedData(namespace, string(hashName), message), signature) {
+		return nil, errors.New("signature does not match the data")
+	}
+	return public, nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, as ssh-keygen prints it.
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKeyBlob(key))
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// AuthorizedKey returns the key in the "ssh-ed25519 <base64>" form of allowed signers files.
+func AuthorizedKey(key ed25519.PublicKey) string {
+	return keyType + " " + base64.StdEncoding.EncodeToString(publicKeyBlob(key))
+}
+
+// AllowedSigner is an entry of an allowed signers file.
+type AllowedSigner struct {
+	Principals []string // Identities the key signs for, such as email addresses
+	Key        ed25519.PublicKey
+}
+
+// ParseAllowedSigners parses an allowed signers file. Each line holds comma-separated
+// principals, optional options and a public key. Keys that are not Ed25519 keys, and keys limited
+// to namespaces other than git, are skipped.
+func ParseAllowedSigners(data []byte) ([]AllowedSigner, error) {
+	var signers []AllowedSigner
+	for number, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("allowed signers line %d: expected principals and a key", number+1)
+		}
+
+		principals, rest := fields[0], fields[1:]
+		if !isKeyType(rest[0]) {
+			// Options, such as namespaces="git"
+			if !allowsNamespace(rest[0], Namespace) {
+				continue
+			}
+			rest = rest[1:]
+		}
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("allowed signers line %d: expected a key", number+1)
+		}
+		if rest[0] != keyType {
+			continue
+		}
+		blob, err := base64.StdEncoding.DecodeString(rest[1])
+		if err != nil {
+			return nil, fmt.Errorf("allowed signers line %d: invalid key: %w", number+1, err)
+		}
+		key, err := parsePublicKeyBlob(blob)
+		if err != nil {
+			return nil, fmt.Errorf("allowed signers line %d: %w", number+1, err)
+		}
+		signers = append(signers, AllowedSigner{Principals: strings.Split(principals, ","), Key: key})
+	}
+	return signers, nil
+}
+
+// Principals returns the principals the key is allowed to sign for.
+func Principals(signers []AllowedSigner, key ed25519.PublicKey) []string {
+	var principals []string
+	for _, signer := range signers {
+		if signer.Key.Equal(key) {
+			principals = append(principals, signer.Principals...)
+		}
+	}
+	return principals
+}
+
+// isKeyType reports whether field names an SSH key type rather than options.
+func isKeyType(field string) bool {
+	for _, prefix := range []string{"ssh-", "ecdsa-", "sk-"} {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsNamespace reports whether the options of an allowed signers line allow namespace.
+func allowsNamespace(options, namespace string) bool {
+	for _, option := range strings.Split(options, ",") {
+		value, ok := strings.CutPrefix(option, "namespaces=")
+		if !ok {
+			continue
+		}
+		for _, allowed := range strings.Split(strings.Trim(value, `"`), ",") {
+			if allowed == namespace || allowed == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// signedData returns the data an SSH signature of message in namespace covers, with the message
+// hashed with hashName, "sha512" or "sha256".
+func signedData(namespace, hashName string, message []byte) []byte {
+	var digest []byte
+	if hashName == "sha256" {
+		sum := sha256.Sum256(message)
+		digest = sum[:]
+	} else {
+		sum := sha512.Sum512(message)
+		digest = sum[:]
+	}
+
+	var data bytes.Buffer
+	data.WriteString(sigMagic)
+	writeString(&data, []byte(namespace))
+	writeString(&data, nil)
+	writeString(&data, []byte(hashName))
+	writeString(&data, digest)
+	return data.Bytes()
+}
+
+// publicKeyBlob returns the key in the SSH wire format.
+func publicKeyBlob(key ed25519.PublicKey) []byte {
+	var blob bytes.Buffer
+	writeString(&blob, []byte(keyType))
+	writeString(&blob, key)
+	return blob.Bytes()
+}
+
+// parsePublicKeyBlob parses an Ed25519 key in the SSH wire format.
+func parsePublicKeyBlob(blob []byte) (ed25519.PublicKey, error) {
+	r := &reader{data: blob}
+	kind, key := r.bytes(), r.bytes()
+	if r.err != nil {
+		return nil, errors.New("invalid public key")
+	}
+	if string(kind) != keyType {
+		return nil, fmt.Errorf("unsupported key type %q", kind)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// writeString writes b as an SSH string: its length as a 32-bit big-endian integer, then b.
+func writeString(buf *bytes.Buffer, b []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(b)))
+	buf.Write(b)
+}
+
+// reader reads the SSH wire format. After the first error, every read returns zero values and
+// err holds the error.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uint32() uint32 {
+	if r.err != nil || len(r.data) < 4 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+// bytes reads an SSH string.
+func (r *reader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil || uint32(len(r.data)) < n {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
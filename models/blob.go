@@ -1,8 +1,8 @@
 package models
 
 import (
-	"crypto/sha1"
-	"fmt"
+	"GitX/internal/hash"
+	"bytes"
 	"os"
 )
 
@@ -12,28 +12,25 @@ type Blob struct {
 	Content string // The content of the file
 }
 
-// NewBlob creates a new Blob from a file.
-func NewBlob(filePath string) (*Blob, error) {
+// NewBlob creates a new Blob from a file, identified with the given hash algorithm.
+func NewBlob(alg *hash.Algorithm, filePath string) (*Blob, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	id, err := hash.HashObject(alg, nil, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
 
 	blob := &Blob{
-		ID:      calculateHash(content),
+		ID:      id,
 		Content: string(content),
 	}
 
 	return blob, nil
 }
 
-// calculateHash calculates the SHA-1 hash of the content.
-func calculateHash(content []byte) string {
-	hasher := sha1.New()
-	hasher.Write(content)
-	return fmt.Sprintf("%x", hasher.Sum(nil))
-}
-
 // GetID returns the ID of the blob.
 func (b *Blob) GetID() string {
 	return b.ID
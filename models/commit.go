@@ -13,6 +13,10 @@ type Commit struct {
 	Timestamp time.Time
 	Files     map[string]string
 	// Additional fields
-	Committer    string
+	Committer string
+	// CommitTime is when Committer made the commit; zero when it is the author's Timestamp
+	CommitTime time.Time
+	// GPGSignature is the SSH signature of the commit, empty if unsigned. It keeps Git's
+	// name, since Git stores SSH signatures in the same gpgsig header.
 	GPGSignature string
 }
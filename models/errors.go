@@ -0,0 +1,29 @@
+package models
+
+import "errors"
+
+// Errors returned by repository operations. Callers should test for them with errors.Is,
+// since they are usually wrapped with details such as the offending path or ref.
+var (
+	// ErrNotARepository is returned when no .gitx directory can be found.
+	ErrNotARepository = errors.New("not a gitx repository")
+	// ErrRefNotFound is returned when a branch or other reference does not exist.
+	ErrRefNotFound = errors.New("reference not found")
+	// ErrBranchExists is returned when creating a branch whose name is already taken.
+	ErrBranchExists = errors.New("branch already exists")
+	// ErrTagExists is returned when creating a tag whose name is already taken.
+	ErrTagExists = errors.New("tag already exists")
+	// ErrConflict is returned when changes cannot be combined without manual resolution.
+	ErrConflict = errors.New("conflict")
+	// ErrNothingToCommit is returned when the index matches the current commit.
+	ErrNothingToCommit = errors.New("nothing to commit")
+	// ErrObjectNotFound is returned when an object is missing from the object store.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrIgnored is returned when adding a path that is ignored by a .gitxignore pattern.
+	ErrIgnored = errors.New("path is ignored")
+	// ErrIdentityUnknown is returned when recording a commit or tag while user.name or
+	// user.email is not set.
+	ErrIdentityUnknown = errors.New("author identity unknown")
+	// ErrHookFailed is returned when a hook exits with a non-zero status to abort an operation.
+	ErrHookFailed = errors.New("hook failed")
+)
@@ -1,10 +1,17 @@
 package models
 
-import "time"
+import (
+	"GitX/internal/fsys"
+	"GitX/internal/hash"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 // File represents a file in the repository.
 type File struct {
-	Path string
+	Path    string
 	Content string
 }
 
@@ -16,27 +23,107 @@ type Branch struct {
 
 // Repository represents the entire repository.
 type Repository struct {
-	Directory string
-	Branches  []Branch
-	HEAD      *Branch
+	Directory string  // Root of the working tree
+	GitxDir   string  // Location of the .gitx directory
+	WorkTree  fsys.FS // Files of the working tree, relative to Directory
+	Store     fsys.FS // Contents of the .gitx directory, relative to GitxDir
+	// ReadHostFile reads a file of the user outside of the repository, such as the global
+	// excludes file, by its path on the host. It is nil for repositories that never touch the
+	// disk, which have no such files.
+	ReadHostFile func(name string) ([]byte, error)
+	// ObjectFormat is the hash algorithm identifying the repository's objects; nil means SHA-1
+	ObjectFormat *hash.Algorithm
+	Branches     []Branch
+	HEAD         *Branch
+}
+
+// Hash returns the hash algorithm identifying the repository's objects.
+func (r *Repository) Hash() *hash.Algorithm {
+	if r.ObjectFormat == nil {
+		return hash.SHA1
+	}
+	return r.ObjectFormat
+}
+
+// Path returns the location of the given elements inside the .gitx directory, for display.
+// Repository contents are read and written through Store instead.
+func (r *Repository) Path(elem ...string) string {
+	return filepath.Join(append([]string{r.GitxDir}, elem...)...)
+}
+
+// WorkPath returns the location in the working tree of a slash-separated path relative to its root, for display.
+func (r *Repository) WorkPath(relPath string) string {
+	return filepath.Join(r.Directory, filepath.FromSlash(relPath))
+}
+
+// RelPath converts a path to the slash-separated form relative to the working tree root
+// that is recorded in the index and in trees.
+func (r *Repository) RelPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(r.Directory, absPath)
+	if err != nil {
+		return "", err
+	}
+	if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside repository at %s", path, r.Directory)
+	}
+	return filepath.ToSlash(relPath), nil
 }
 
 // Reflog represents a reference log entry in the repository.
 type Reflog struct {
 	ID        string
+	OldID     string // Commit the ref pointed to before the update, empty for a new ref
+	Ref       string // Ref that was updated, such as "refs/heads/main"
 	Author    string
 	Timestamp time.Time
 	Message   string
 	// Add other necessary fields here
 }
 
+// MerkleNote records the Merkle root of a branch's history at one commit, so that later
+// histories can be checked against it.
+type MerkleNote struct {
+	Algorithm string // Hash algorithm of the root
+	Root      string // Root of the Merkle tree over the history
+	Size      int    // Number of commits in the history
+	Commit    string // Newest commit of the history, which the note is attached to
+	Ref       string // Branch the root was recorded from, such as "refs/heads/main"
+	Tag       string // Annotated tag the root was recorded in, empty for a note
+	Author    string
+	Timestamp time.Time
+}
+
 // GitXConfig represents your configuration settings.
 type GitXConfig struct {
 	UserName  string `toml:"user.name"`
 	UserEmail string `toml:"user.email"`
+	// ExcludesFile is the global ignore file applied to every repository
+	ExcludesFile string `toml:"core.excludesFile,omitempty"`
+	// HooksPath is the directory hooks are run from instead of the hooks directory of .gitx
+	HooksPath string `toml:"core.hooksPath,omitempty"`
+	// SigningKey is the Ed25519 private key file commits and tags are signed with
+	SigningKey string `toml:"user.signingkey,omitempty"`
+	// GPGSign makes every commit signed, as if -S was given
+	GPGSign bool `toml:"commit.gpgSign,omitempty"`
+	// AllowedSignersFile lists the public keys whose signatures are trusted
+	AllowedSignersFile string `toml:"gpg.ssh.allowedSignersFile,omitempty"`
+	// ObjectFormat is the hash algorithm of the repository, chosen at init; empty means sha1
+	ObjectFormat string `toml:"extensions.objectFormat,omitempty"`
+	// Branches holds the settings of each branch, keyed by branch name
+	Branches map[string]BranchConfig `toml:"branch,omitempty"`
 	// Add other fields as needed
 }
 
+// BranchConfig represents the configuration settings of a single branch.
+type BranchConfig struct {
+	// Merge is the upstream branch the branch is compared with, such as "refs/heads/main"
+	Merge string `toml:"merge,omitempty"`
+}
+
 // IndexEntry represents an entry in the INDEX file.
 type IndexEntry struct {
 	Mode string `json:"mode"` // Mode field representing file mode
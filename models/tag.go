@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Tag is an annotated tag: a named, dated message about a commit, optionally signed.
+// Lightweight tags have no Tag object; their ref holds the commit ID directly.
+type Tag struct {
+	ID        string
+	Object    string // Commit the tag points to
+	Name      string
+	Tagger    string
+	Timestamp time.Time
+	Message   string
+	Signature string // SSH signature of the tag, empty if unsigned
+}
@@ -4,12 +4,12 @@ package models
 type TreeEntry struct {
 	Name string // The name of the entry
 	Mode string // The file permissions mode
-	ID   string // The SHA-1 hash of the entry
+	ID   string // The hash of the entry
 	Type string // The type of the entry: "blob" or "tree"
 }
 
 // Tree represents a directory in the repository.
 type Tree struct {
-	ID      string      // The SHA-1 hash of the tree
+	ID      string      // The hash of the tree
 	Entries []TreeEntry // The entries in the directory
 }
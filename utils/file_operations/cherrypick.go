@@ -0,0 +1,237 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Actions of the sequencer, which name the operation in messages and in the reflog.
+const (
+	actionCherryPick = "cherry-pick"
+	actionRevert     = "revert"
+)
+
+// PickOptions configures CherryPick and Revert.
+type PickOptions struct {
+	// Mainline is the number, starting from 1, of the parent of a merge commit that its changes
+	// are taken relative to. It must be set for merge commits, and only for them.
+	Mainline int
+	// RecordOrigin adds a "(cherry picked from commit ...)" line to the message of picked commits.
+	RecordOrigin bool
+}
+
+// PickResult describes what CherryPick, Revert and the sequencer commands did.
+type PickResult struct {
+	Commits []*models.Commit // Commits created, in order
+	Skipped []string         // Commits left out, because their changes were already on the branch or they were skipped
+	// Stopped is the commit whose changes conflicted, nil if the sequence completed. The
+	// conflicts must be resolved and staged before continuing.
+	Stopped   *models.Commit
+	Conflicts []string // Paths left with conflict markers in the working tree, sorted
+}
+
+// CherryPick applies the changes made by each commit named by revs, in order, to the current
+// branch, committing each with its original author and message. The changes are combined
+// with the branch by a three-way merge against the commit's parent. When a commit conflicts,
+// the sequence stops with the conflicts in the working tree; it is resumed with
+// ContinueSequence or SkipSequence, or undone with AbortSequence.
+func CherryPick(repo *models.Repository, revs []string, opts PickOptions) (*PickResult, error) {
+	return startSequence(repo, actionCherryPick, revs, opts)
+}
+
+// Revert undoes the changes made by each commit named by revs, in order, by committing their
+// reverse on the current branch. Conflicts stop the sequence like in CherryPick.
+func Revert(repo *models.Repository, revs []string, opts PickOptions) (*PickResult, error) {
+	return startSequence(repo, actionRevert, revs, opts)
+}
+
+// pickParent returns the ID of the parent the changes of the commit are taken relative to, or
+// an empty string for a root commit.
+func pickParent(commit *models.Commit, mainline int) (string, error) {
+	switch {
+	case len(commit.Parent) > 1 && mainline == 0:
+		return "", fmt.Errorf("commit %s is a merge but no mainline was given", commit.ID)
+	case len(commit.Parent) <= 1 && mainline != 0:
+		return "", fmt.Errorf("mainline was specified but commit %s is not a merge", commit.ID)
+	case mainline < 0 || mainline > len(commit.Parent):
+		return "", fmt.Errorf("commit %s does not have parent %d", commit.ID, mainline)
+	case mainline != 0:
+		return commit.Parent[mainline-1].ID, nil
+	case len(commit.Parent) == 1:
+		return commit.Parent[0].ID, nil
+	}
+	return "", nil
+}
+
+// applyPick merges the changes of the commit, or their reverse, into the INDEX and the working
+// tree. The paths that conflict are left in the working tree with conflict markers and keep
+// their HEAD version in the INDEX; they are returned sorted.
+func applyPick(repo *models.Repository, action string, commit *models.Commit, mainline int) ([]string, error) {
+	parentID, err := pickParent(commit, mainline)
+	if err != nil {
+		return nil, err
+	}
+	parentFiles := make(map[string]string)
+	if parentID != "" {
+		if parentFiles, err = revisionFiles(repo, parentID); err != nil {
+			return nil, err
+		}
+	}
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	base, theirs := parentFiles, treeFiles(commit.Tree)
+	label := fmt.Sprintf("%s... %s", commit.ID[:7], subject)
+	if action == actionRevert {
+		base, theirs = theirs, base
+		label = "parent of " + label
+	}
+	merged, conflicts, err := mergeTrees(repo, base, headFiles, theirs, "HEAD", label)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conflicted files are written out below, so they must not hold local changes
+	var overwritten []string
+	for filePath := range conflicts {
+		workID, exists, err := workTreeBlobID(repo, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing %s: %w", filePath, err)
+		}
+		if exists && workID != headFiles[filePath] || !exists && headFiles[filePath] != "" {
+			overwritten = append(overwritten, filePath)
+		}
+	}
+	if len(overwritten) > 0 {
+		sort.Strings(overwritten)
+		return nil, fmt.Errorf("%w: your local changes to the following files would be overwritten by %s:\n\t%s",
+			models.ErrConflict, action, strings.Join(overwritten, "\n\t"))
+	}
+
+	if err := updateWorkTree(repo, headFiles, merged, action); err != nil {
+		return nil, err
+	}
+	for _, filePath := range sortedKeys(conflicts) {
+		if err := repo.WorkTree.MkdirAll(path.Dir(filePath), fs.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating directory for %s: %w", filePath, err)
+		}
+		if err := repo.WorkTree.WriteFile(filePath, conflicts[filePath], 0644); err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", filePath, err)
+		}
+	}
+	return sortedKeys(conflicts), nil
+}
+
+// pickMessage returns the message of the commit that picks, or reverts, the commit.
+func pickMessage(action string, commit *models.Commit, opts PickOptions) string {
+	if action != actionRevert {
+		if opts.RecordOrigin {
+			return commit.Message + fmt.Sprintf("\n\n(cherry picked from commit %s)", commit.ID)
+		}
+		return commit.Message
+	}
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	message := fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s", subject, commit.ID)
+	if opts.Mainline != 0 {
+		message += fmt.Sprintf(", reversing\nchanges made to %s", commit.Parent[opts.Mainline-1].ID)
+	}
+	return message + "."
+}
+
+// commitPick commits the INDEX on top of the current branch with the given message, naming
+// the action in the reflog. If author is not nil, the new commit keeps its author and date,
+// like picked commits do. It returns nil if the INDEX matches HEAD, since the pick then
+// changes nothing.
+func commitPick(repo *models.Repository, action string, author *models.Commit, message string) (*models.Commit, error) {
+	branch, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return nil, err
+	}
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := vcs_operations.CreateTreeFromIndex(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tree from INDEX: %w", err)
+	}
+	if maps.Equal(treeFiles(tree), headFiles) {
+		return nil, nil
+	}
+
+	var parents []*models.Commit
+	if headID != "" {
+		parents = append(parents, &models.Commit{ID: headID})
+	}
+	picked, err := newCommit(repo, tree, parents, message)
+	if err != nil {
+		return nil, err
+	}
+	if author != nil {
+		picked.Author, picked.Timestamp = author.Author, author.Timestamp
+	}
+	if err := writeCommit(repo, branch, headID, picked, action, false); err != nil {
+		return nil, err
+	}
+	return picked, nil
+}
+
+// hasConflictMarkers reports whether the content still holds the markers of a conflict.
+func hasConflictMarkers(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("<<<<<<<")) || bytes.HasPrefix(line, []byte(">>>>>>>")) {
+			return true
+		}
+	}
+	return false
+}
+
+// unresolvedConflicts returns the paths of the conflicts that are not yet resolved: the
+// working tree file differs from the INDEX, or the staged version still has conflict markers.
+func unresolvedConflicts(repo *models.Repository, conflicts []string) ([]string, error) {
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	index := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		index[entry.Path] = entry.Hash
+	}
+
+	var unresolved []string
+	for _, filePath := range conflicts {
+		workID, exists, err := workTreeBlobID(repo, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing %s: %w", filePath, err)
+		}
+		indexID, staged := index[filePath]
+		if exists != staged || exists && workID != indexID {
+			unresolved = append(unresolved, filePath)
+			continue
+		}
+		if staged {
+			content, err := vcs_operations.ReadBlob(repo, indexID)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", filePath, err)
+			}
+			if hasConflictMarkers(content) {
+				unresolved = append(unresolved, filePath)
+			}
+		}
+	}
+	return unresolved, nil
+}
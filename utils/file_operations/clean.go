@@ -0,0 +1,166 @@
+package file_operations
+
+import (
+	"GitX/internal/ignore"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// CleanOptions controls which untracked files CleanHandler removes.
+type CleanOptions struct {
+	DryRun         bool // Report what would be removed without removing anything (-n)
+	Force          bool // Actually remove the files; required unless DryRun is set (-f)
+	Directories    bool // Also remove untracked directories (-d)
+	IncludeIgnored bool // Also remove ignored files (-x)
+	OnlyIgnored    bool // Only remove ignored files (-X)
+}
+
+// CleanHandler removes the untracked files selected by the pathspecs, or all of them if none are
+// given. Ignored files are kept unless opts.IncludeIgnored or opts.OnlyIgnored is set, and
+// untracked directories are only entered with opts.Directories. The .gitx directory is never
+// touched. The removed paths are returned, directories with a trailing slash.
+func CleanHandler(repo *models.Repository, paths []string, opts CleanOptions) ([]string, error) {
+	if !opts.Force && !opts.DryRun {
+		return nil, errors.New("refusing to clean without -f or -n")
+	}
+	if opts.IncludeIgnored && opts.OnlyIgnored {
+		return nil, errors.New("-x and -X cannot be used together")
+	}
+	if len(paths) == 0 {
+		paths = []string{repo.Directory}
+	}
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	tracked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		tracked[entry.Path] = true
+		for dir := path.Dir(entry.Path); dir != "."; dir = path.Dir(dir) {
+			tracked[dir] = true
+		}
+	}
+
+	matcher, err := LoadIgnoreMatcher(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cleaner{repo: repo, opts: opts, tracked: tracked, matcher: matcher, specs: specs}
+	candidates, _, err := c.collect(".")
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return candidates, nil
+	}
+	for _, candidate := range candidates {
+		if err := repo.WorkTree.RemoveAll(strings.TrimSuffix(candidate, "/")); err != nil {
+			return nil, fmt.Errorf("error removing %s: %w", candidate, err)
+		}
+	}
+	return candidates, nil
+}
+
+// cleaner walks the working tree looking for files to clean.
+type cleaner struct {
+	repo    *models.Repository
+	opts    CleanOptions
+	tracked map[string]bool // Tracked files and the directories holding them
+	matcher *ignore.Matcher
+	specs   []*pathspec
+}
+
+// collect returns the files and directories to remove below dir, and whether everything below
+// it is to be removed so that the directory can be removed as a whole.
+func (c *cleaner) collect(dir string) ([]string, bool, error) {
+	dirEntries, err := c.repo.WorkTree.ReadDir(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading %s: %w", dir, err)
+	}
+
+	var candidates []string
+	whole := true
+	for _, dirEntry := range dirEntries {
+		name := path.Join(dir, dirEntry.Name())
+
+		if !dirEntry.IsDir() {
+			if c.tracked[name] || !c.wanted(c.matcher.Ignored(name, false)) {
+				whole = false
+				continue
+			}
+			if c.selected(name) {
+				candidates = append(candidates, name)
+			} else {
+				whole = false
+			}
+			continue
+		}
+
+		// Never clean the repository itself or a nested repository
+		if name == ".gitx" || c.isRepository(name) {
+			whole = false
+			continue
+		}
+		ignored := c.matcher.Ignored(name, true)
+		if !c.tracked[name] && !c.opts.Directories {
+			whole = false
+			continue
+		}
+		if ignored && !c.opts.IncludeIgnored && !c.opts.OnlyIgnored {
+			whole = false
+			continue
+		}
+
+		below, belowWhole, err := c.collect(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if !c.tracked[name] && belowWhole && c.selected(name) {
+			// An untracked directory whose content all goes is removed at once
+			candidates = append(candidates, name+"/")
+			continue
+		}
+		candidates = append(candidates, below...)
+		whole = false
+	}
+	return candidates, whole, nil
+}
+
+// wanted reports whether an untracked file with the given ignored state is to be cleaned.
+func (c *cleaner) wanted(ignored bool) bool {
+	switch {
+	case c.opts.OnlyIgnored:
+		return ignored
+	case c.opts.IncludeIgnored:
+		return true
+	default:
+		return !ignored
+	}
+}
+
+// selected reports whether the path is selected by one of the pathspecs.
+func (c *cleaner) selected(name string) bool {
+	for _, spec := range c.specs {
+		if spec.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRepository reports whether the directory holds a nested repository.
+func (c *cleaner) isRepository(dir string) bool {
+	info, err := c.repo.WorkTree.Stat(path.Join(dir, ".gitx"))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,64 @@
+package file_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCleanHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		opts    CleanOptions
+		removed []string
+	}{
+		{"dry run", nil, CleanOptions{DryRun: true}, []string{"u.txt"}},
+		{"force", nil, CleanOptions{Force: true}, []string{"u.txt"}},
+		{"directories", nil, CleanOptions{Force: true, Directories: true}, []string{"dir/", "u.txt"}},
+		{"ignored too", nil, CleanOptions{Force: true, IncludeIgnored: true}, []string{"u.txt", "x.log"}},
+		{"only ignored", nil, CleanOptions{Force: true, OnlyIgnored: true}, []string{"x.log"}},
+		{"pathspec", []string{"/dir"}, CleanOptions{Force: true, Directories: true}, []string{"dir/"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", IgnoreFileName: "*.log\n"})
+			untracked := []string{"u.txt", "dir/v.txt", "x.log"}
+			for _, name := range untracked {
+				writeFile(t, repo, name, name+"\n")
+			}
+
+			removed, err := CleanHandler(repo, test.paths, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(removed, test.removed) {
+				t.Errorf("removed %q, want %q", removed, test.removed)
+			}
+
+			gone := make(map[string]bool)
+			if !test.opts.DryRun {
+				for _, name := range removed {
+					gone[name] = true
+				}
+				gone["dir/v.txt"] = gone["dir/"]
+			}
+			for _, name := range append(untracked, "a.txt") {
+				if exists := readFile(t, repo, name) != ""; exists == gone[name] {
+					t.Errorf("%s exists = %v", name, exists)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanRequiresForce(t *testing.T) {
+	repo := newTestRepo(t)
+	writeFile(t, repo, "u.txt", "u\n")
+	if _, err := CleanHandler(repo, nil, CleanOptions{}); err == nil {
+		t.Error("CleanHandler without -f or -n succeeds")
+	}
+	if got := readFile(t, repo, "u.txt"); got != "u\n" {
+		t.Error("CleanHandler without -f removes files")
+	}
+}
@@ -0,0 +1,192 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommitAmend(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	second := commitFiles(t, repo, "second", map[string]string{"b.txt": "b\n"})
+
+	writeFile(t, repo, "c.txt", "c\n")
+	addFile(t, repo, "c.txt")
+	amended, err := CommitHandler(repo, "", CommitOptions{Amend: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.Message != "second" {
+		t.Errorf("amended message = %q, want the message of the amended commit", amended.Message)
+	}
+	if len(amended.Parent) != 1 || amended.Parent[0].ID != first.ID {
+		t.Errorf("amended commit has parents %v, want %s", amended.Parent, first.ID)
+	}
+	if !amended.Timestamp.Equal(second.Timestamp) || amended.Author != second.Author {
+		t.Errorf("amended commit is by %s at %v, want the author of the amended commit", amended.Author, amended.Timestamp)
+	}
+	if head := headID(t, repo); head != amended.ID {
+		t.Errorf("HEAD = %s, want the amended commit %s", head, amended.ID)
+	}
+	if len(amended.Tree.Entries) != 3 {
+		t.Errorf("amended tree has %d entries, want a.txt, b.txt and c.txt", len(amended.Tree.Entries))
+	}
+
+	// Amending with only a message rewords the commit
+	reworded, err := CommitHandler(repo, "reworded", CommitOptions{Amend: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reworded.Message != "reworded" || reworded.Tree.ID != amended.Tree.ID {
+		t.Errorf("reworded commit %q has tree %s, want %q with tree %s", reworded.Message, reworded.Tree.ID, "reworded", amended.Tree.ID)
+	}
+}
+
+func TestCommitAmendToEmpty(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	commitFiles(t, repo, "second", map[string]string{"b.txt": "b\n"})
+	if _, err := RemoveHandler(repo, []string{workPath(repo, "b.txt")}, RemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CommitHandler(repo, "", CommitOptions{Amend: true}); !errors.Is(err, models.ErrNothingToCommit) {
+		t.Errorf("amending to the parent's tree: %v, want %v", err, models.ErrNothingToCommit)
+	}
+	amended, err := CommitHandler(repo, "", CommitOptions{Amend: true, AllowEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.Tree.ID != first.Tree.ID {
+		t.Errorf("amended tree = %s, want the parent's %s", amended.Tree.ID, first.Tree.ID)
+	}
+}
+
+func TestCommitAllowEmpty(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+
+	if _, err := CommitHandler(repo, "empty", CommitOptions{}); !errors.Is(err, models.ErrNothingToCommit) {
+		t.Errorf("commit without changes: %v, want %v", err, models.ErrNothingToCommit)
+	}
+	commit, err := CommitHandler(repo, "empty", CommitOptions{AllowEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Tree.ID != first.Tree.ID || commit.Parent[0].ID != first.ID {
+		t.Errorf("empty commit has tree %s and parent %s, want %s and %s", commit.Tree.ID, commit.Parent[0].ID, first.Tree.ID, first.ID)
+	}
+}
+
+func TestCommitAll(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	before := indexHashes(t, repo)
+	writeFile(t, repo, "a.txt", "changed\n")
+	if err := repo.WorkTree.Remove("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "new.txt", "new\n")
+
+	if _, err := CommitHandler(repo, "all", CommitOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	index := indexHashes(t, repo)
+	if _, ok := index["b.txt"]; ok {
+		t.Error("-a did not stage the deletion of b.txt")
+	}
+	if _, ok := index["new.txt"]; ok {
+		t.Error("-a staged the untracked new.txt")
+	}
+	if index["a.txt"] == before["a.txt"] {
+		t.Error("-a did not stage the change to a.txt")
+	}
+}
+
+func TestCommitFixup(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "Add a\n\nWith a body", map[string]string{"a.txt": "a\n"})
+	commitFiles(t, repo, "second", map[string]string{"b.txt": "b\n"})
+
+	writeFile(t, repo, "a.txt", "fixed\n")
+	addFile(t, repo, "a.txt")
+	commit, err := CommitHandler(repo, "", CommitOptions{Fixup: "HEAD~1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "fixup! Add a" {
+		t.Errorf("message = %q, want %q", commit.Message, "fixup! Add a")
+	}
+
+	writeFile(t, repo, "a.txt", "fixed again\n")
+	addFile(t, repo, "a.txt")
+	if commit, err = CommitHandler(repo, "details", CommitOptions{Fixup: "HEAD~2"}); err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "fixup! Add a\n\ndetails" {
+		t.Errorf("message = %q, want the given message as the body", commit.Message)
+	}
+	if _, err := CommitHandler(repo, "", CommitOptions{Fixup: "HEAD", Amend: true, AllowEmpty: true}); err == nil {
+		t.Error("--fixup with --amend succeeded")
+	}
+}
+
+func TestCommitEdit(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+
+	var template string
+	edit := func(text string) func(string, []byte) ([]byte, error) {
+		return func(name string, content []byte) ([]byte, error) {
+			template = string(content)
+			return []byte(text), nil
+		}
+	}
+	writeFile(t, repo, "b.txt", "b\n")
+	addFile(t, repo, "b.txt")
+	if _, err := CommitHandler(repo, "", CommitOptions{Edit: edit("# only a comment\n\n")}); err == nil {
+		t.Error("commit with an empty edited message succeeded")
+	}
+	commit, err := CommitHandler(repo, "", CommitOptions{Edit: edit("\nSubject\n# comment\n\nBody\n\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "Subject\n\nBody" {
+		t.Errorf("message = %q, want the edited message without comments", commit.Message)
+	}
+
+	// An amended commit's message is the starting point
+	if _, err := CommitHandler(repo, "", CommitOptions{Amend: true, Edit: edit("Reworded\n")}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(template, "Subject\n\nBody\n") {
+		t.Errorf("message file of an amend starts with %q, want the amended message", template)
+	}
+}
+
+func TestCommitIdentity(t *testing.T) {
+	repo := newTestRepo(t)
+	commit := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	if commit.Author != "Test User <test@example.com>" || commit.Committer != commit.Author {
+		t.Errorf("commit by %q, committed by %q, want the configured identity", commit.Author, commit.Committer)
+	}
+
+	t.Setenv("GITX_AUTHOR_NAME", "Other Author")
+	t.Setenv("GITX_AUTHOR_EMAIL", "other@example.com")
+	commit = commitFiles(t, repo, "second", map[string]string{"b.txt": "b\n"})
+	if commit.Author != "Other Author <other@example.com>" || commit.Committer != "Test User <test@example.com>" {
+		t.Errorf("commit by %q, committed by %q, want the author from the environment", commit.Author, commit.Committer)
+	}
+
+	// Without an email, nobody can be credited
+	t.Setenv("GITX_AUTHOR_NAME", "")
+	t.Setenv("GITX_AUTHOR_EMAIL", "")
+	setConfig(t, repo, "user.email", "")
+	writeFile(t, repo, "c.txt", "c\n")
+	addFile(t, repo, "c.txt")
+	if _, err := CommitHandler(repo, "third", CommitOptions{}); !errors.Is(err, models.ErrIdentityUnknown) {
+		t.Errorf("commit without user.email: %v, want %v", err, models.ErrIdentityUnknown)
+	}
+}
@@ -1,198 +1,204 @@
 package file_operations
 
 import (
+	"GitX/internal/diff"
+	"GitX/internal/fsys"
 	"GitX/internal/hash"
+	"GitX/internal/ignore"
+	"GitX/internal/patch"
 	"GitX/models"
-	"GitX/utils/metadata_operations"
 	"GitX/utils/vcs_operations"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"github.com/BurntSushi/toml"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
-	"github.com/BurntSushi/toml"
 )
 
 // InitHandler initializes a new GitX repository by creating the necessary directories and files
-func InitHandler(directory string) {
+func InitHandler(repo *models.Repository) error {
 	// Create repository directory
-	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
-		log.Fatalf("Error creating repository directory: %v", err)
+	if err := repo.WorkTree.MkdirAll(".", fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating repository directory: %w", err)
 	}
 
 	// Create .gitx directory inside the repository directory
-	gitxDir := filepath.Join(directory, ".gitx")
-	if err := os.MkdirAll(gitxDir, os.ModePerm); err != nil {
-		log.Fatalf("Error creating .gitx directory: %v", err)
+	if err := repo.Store.MkdirAll(".", fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating .gitx directory: %w", err)
 	}
 
 	// Create metadata file
-	metadataFile := filepath.Join(gitxDir, "metadata.json")
-	if _, err := os.Create(metadataFile); err != nil {
-		log.Fatalf("Error creating metadata file: %v", err)
+	metadataFile := "metadata.json"
+	if err := repo.Store.WriteFile(metadataFile, nil, 0644); err != nil {
+		return fmt.Errorf("error creating metadata file: %w", err)
 	}
 
 	// Create HEAD file
-	headFile := filepath.Join(gitxDir, "HEAD")
-	if err := os.WriteFile(headFile, []byte("refs/heads/main"), 0644); err != nil {
-		log.Fatalf("Error creating HEAD file: %v", err)
+	headFile := "HEAD"
+	if err := repo.Store.WriteFile(headFile, []byte("refs/heads/main"), 0644); err != nil {
+		return fmt.Errorf("error creating HEAD file: %w", err)
 	}
 
 	// Create refs/heads directory
-	refsHeadsDir := filepath.Join(gitxDir, "refs", "heads")
-	if err := os.MkdirAll(refsHeadsDir, os.ModePerm); err != nil {
-		log.Fatalf("Error creating refs/heads directory: %v", err)
+	refsHeadsDir := path.Join("refs", "heads")
+	if err := repo.Store.MkdirAll(refsHeadsDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating refs/heads directory: %w", err)
 	}
 
 	// Verify refs/heads directory creation
-	if _, err := os.Stat(refsHeadsDir); os.IsNotExist(err) {
-		log.Fatalf("refs/heads directory does not exist after creation: %v", err)
+	if _, err := repo.Store.Stat(refsHeadsDir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("refs/heads directory does not exist after creation: %w", err)
 	}
 
 	// Create main branch file
-	mainBranchFile := filepath.Join(refsHeadsDir, "main")
-	if _, err := os.Create(mainBranchFile); err != nil {
-		log.Fatalf("Error creating main branch file: %v", err)
+	mainBranchFile := path.Join(refsHeadsDir, "main")
+	if err := repo.Store.WriteFile(mainBranchFile, nil, 0644); err != nil {
+		return fmt.Errorf("error creating main branch file: %w", err)
 	}
 
 	// Create commits directory
-	commitsDir := filepath.Join(gitxDir, "commits")
-	if err := os.MkdirAll(commitsDir, os.ModePerm); err != nil {
-		log.Fatalf("Error creating commits directory: %v", err)
+	commitsDir := "commits"
+	if err := repo.Store.MkdirAll(commitsDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating commits directory: %w", err)
 	}
 
 	// Create objects directory
-	objectsDir := filepath.Join(gitxDir, "objects")
-	if err := os.MkdirAll(objectsDir, os.ModePerm); err != nil {
-		log.Fatalf("Error creating objects directory: %v", err)
+	objectsDir := "objects"
+	if err := repo.Store.MkdirAll(objectsDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating objects directory: %w", err)
+	}
+
+	// Create the hooks directory, which starts out empty so that no hook runs
+	hooksDir := "hooks"
+	if err := repo.Store.MkdirAll(hooksDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating hooks directory: %w", err)
 	}
 
 	// Set up ignore file
-	ignoreFile := filepath.Join(directory, ".gitxignore")
-	if _, err := os.Create(ignoreFile); err != nil {
-		log.Fatalf("Error creating ignore file: %v", err)
+	ignoreFile := IgnoreFileName
+	if err := repo.WorkTree.WriteFile(ignoreFile, nil, 0644); err != nil {
+		return fmt.Errorf("error creating ignore file: %w", err)
 	}
 
-	// Create config file with default contents in TOML format
-	configFile := filepath.Join(gitxDir, "config.toml")
-	config := models.GitXConfig{
-		UserName:  "Your Name",
-		UserEmail: "your.email@example.com",
+	// Create the repository-local exclude file, which is not shared with other clones
+	infoDir := "info"
+	if err := repo.Store.MkdirAll(infoDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating info directory: %w", err)
 	}
-	err := UpdateConfig(configFile, &config)
+	excludeContent := []byte("# Patterns listed here are ignored like the ones in .gitxignore,\n# but only in this repository.\n")
+	if err := repo.Store.WriteFile(path.Join(infoDir, "exclude"), excludeContent, 0644); err != nil {
+		return fmt.Errorf("error creating exclude file: %w", err)
+	}
+
+	// Create config file in TOML format. The identity is left for the user to set, so that
+	// commits are not attributed to a placeholder.
+	configFile := "config.toml"
+	config := models.GitXConfig{}
+	if repo.Hash() != hash.SHA1 {
+		config.ObjectFormat = repo.Hash().Name
+	}
+	err := UpdateConfig(repo.Store, configFile, &config)
 	if err != nil {
-		log.Fatalf("Error creating config file: %v", err)
+		return fmt.Errorf("error creating config file: %w", err)
 	}
 
 	// Create description file
-	descriptionFile := filepath.Join(gitxDir, "description")
+	descriptionFile := "description"
 	descriptionContent := []byte("Unnamed repository; edit this file to name the repository.\n")
-	if err := os.WriteFile(descriptionFile, descriptionContent, 0644); err != nil {
-		log.Fatalf("Error creating description file: %v", err)
+	if err := repo.Store.WriteFile(descriptionFile, descriptionContent, 0644); err != nil {
+		return fmt.Errorf("error creating description file: %w", err)
 	}
 
 	// Create INDEX file
-	indexFile := filepath.Join(gitxDir, "INDEX")
-	if _, err := os.Create(indexFile); err != nil {
-		log.Fatalf("Error creating INDEX file: %v", err)
+	indexFile := "INDEX"
+	if err := repo.Store.WriteFile(indexFile, nil, 0644); err != nil {
+		return fmt.Errorf("error creating INDEX file: %w", err)
 	}
 
 	// Create an initial commit
-	initialCommit := createInitialCommit()
+	initialCommit, err := createInitialCommit(repo)
+	if err != nil {
+		return err
+	}
 
 	// Write the initial commit to the commits directory
 	initialCommitData, err := json.Marshal(initialCommit)
 	if err != nil {
-		log.Fatalf("Error serializing initial commit data: %v", err)
+		return fmt.Errorf("error serializing initial commit data: %w", err)
 	}
-	initialCommitFilePath := filepath.Join(commitsDir, initialCommit.ID)
-	if err := os.WriteFile(initialCommitFilePath, initialCommitData, 0644); err != nil {
-		log.Fatalf("Error writing initial commit file: %v", err)
+	initialCommitFilePath := path.Join(commitsDir, initialCommit.ID)
+	if err := repo.Store.WriteFile(initialCommitFilePath, initialCommitData, 0644); err != nil {
+		return fmt.Errorf("error writing initial commit file: %w", err)
+	}
+
+	if err := vcs_operations.AddToCommitGraph(repo, &initialCommit); err != nil {
+		return fmt.Errorf("error creating commit-graph: %w", err)
 	}
 
-	if err := vcs_operations.UpdateHEAD("refs/heads/main"); err != nil {
-		log.Fatalf("Error updating HEAD with main branch reference: %v", err)
+	if err := vcs_operations.UpdateHEAD(repo, "refs/heads/main"); err != nil {
+		return fmt.Errorf("error updating HEAD with main branch reference: %w", err)
 	}
 
-	mainBranchRefPath := filepath.Join(gitxDir, "refs", "heads", "main")
-	fmt.Println(mainBranchRefPath)
-	if err := os.WriteFile(mainBranchRefPath, []byte(initialCommit.ID), 0644); err != nil {
-		log.Fatalf("Error creating main branch ref file: %v", err)
+	if err := repo.Store.WriteFile(mainBranchFile, []byte(initialCommit.ID), 0644); err != nil {
+		return fmt.Errorf("error creating main branch ref file: %w", err)
 	}
 
-	fmt.Printf("Initialized empty repository in %s\n", directory)
-	fmt.Println("Please configure your user information using the following commands:")
-	fmt.Println("  gitx config user.name 'Your Name'")
-	fmt.Println("  gitx config user.email 'your.email@example.com'")
+	return nil
 }
 
 // ConfigHandler reads and updates configuration settings.
-func ConfigHandler(key, value string) {
-	configFile := filepath.Join(".gitx", "config.toml")
-
-	// Load existing config
-	config, err := LoadConfig(configFile)
-	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
-	}
-
-	// Update config based on the key
-	switch key {
-	case "user.name":
-		config.UserName = value
-	case "user.email":
-		config.UserEmail = value
-	default:
-		log.Fatalf("Unknown config key: %s", key)
-	}
+func ConfigHandler(repo *models.Repository, key, value string) error {
+	return ConfigHandlerWithFilePath(repo.Store, "config.toml", key, value)
+}
 
-	// Write updated config back to file
-	err = UpdateConfig(configFile, config)
+// LoadObjectFormat returns the hash algorithm recorded in the repository config of store.
+func LoadObjectFormat(store fsys.FS) (*hash.Algorithm, error) {
+	config, err := vcs_operations.LoadConfig(store, "config.toml")
 	if err != nil {
-		log.Fatalf("Error updating config: %v", err)
+		return nil, fmt.Errorf("error loading config: %w", err)
 	}
-
-	fmt.Printf("Config updated successfully!\n")
+	return hash.Lookup(config.ObjectFormat)
 }
 
-// LoadConfig reads the configuration from a file.
-func LoadConfig(filePath string) (*models.GitXConfig, error) {
-	config := &models.GitXConfig{}
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// If the config file does not exist, return an empty config with no error
-		return config, nil
+// SetUpstream makes the branch track the upstream branch, which status compares it with.
+// An empty upstream removes the setting.
+func SetUpstream(repo *models.Repository, branchName, upstream string) error {
+	if _, err := vcs_operations.ReadBranchRef(repo, branchName); err != nil {
+		return fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, branchName)
 	}
-
-	if _, err := toml.DecodeFile(filePath, config); err != nil {
-		return nil, err
+	merge := ""
+	if upstream != "" {
+		if _, err := vcs_operations.ReadBranchRef(repo, upstream); err != nil {
+			return fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, upstream)
+		}
+		merge = "refs/heads/" + upstream
 	}
-	return config, nil
+	return ConfigHandler(repo, "branch."+branchName+".merge", merge)
 }
 
 // UpdateConfig writes the updated configuration back to the file.
-func UpdateConfig(filePath string, config *models.GitXConfig) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := toml.NewEncoder(file)
+func UpdateConfig(store fsys.FS, filePath string, config *models.GitXConfig) error {
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
 	if err := encoder.Encode(config); err != nil {
 		return err
 	}
 
-	return nil
+	return store.WriteFile(filePath, buf.Bytes(), 0644)
 }
 
 // ConfigHandlerWithFilePath reads and updates configuration settings from the specified config file path.
-func ConfigHandlerWithFilePath(configFilePath, key, value string) {
+func ConfigHandlerWithFilePath(store fsys.FS, configFilePath, key, value string) error {
 	// Load existing config
-	config, err := LoadConfig(configFilePath)
+	config, err := vcs_operations.LoadConfig(store, configFilePath)
 	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		return fmt.Errorf("error loading config: %w", err)
 	}
 
 	// Update config based on the key
@@ -201,320 +207,642 @@ func ConfigHandlerWithFilePath(configFilePath, key, value string) {
 		config.UserName = value
 	case "user.email":
 		config.UserEmail = value
+	case "core.excludesFile":
+		config.ExcludesFile = value
+	case "core.hooksPath":
+		config.HooksPath = value
+	case "user.signingkey":
+		config.SigningKey = value
+	case "commit.gpgSign":
+		// An empty value removes the setting
+		sign := false
+		if value != "" {
+			if sign, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("invalid boolean value for %s: %s", key, value)
+			}
+		}
+		config.GPGSign = sign
+	case "gpg.ssh.allowedSignersFile":
+		config.AllowedSignersFile = value
+	case "extensions.objectFormat":
+		// Existing objects would all need new IDs
+		return fmt.Errorf("%s can only be chosen when the repository is created", key)
 	default:
-		log.Fatalf("Unknown config key: %s", key)
+		// Branch settings are keyed as "branch.<name>.<setting>"
+		branchName, found := strings.CutSuffix(strings.TrimPrefix(key, "branch."), ".merge")
+		if !strings.HasPrefix(key, "branch.") || !found || branchName == "" {
+			return fmt.Errorf("unknown config key: %s", key)
+		}
+		if config.Branches == nil {
+			config.Branches = make(map[string]models.BranchConfig)
+		}
+		branchConfig := config.Branches[branchName]
+		branchConfig.Merge = value
+		config.Branches[branchName] = branchConfig
+		if branchConfig == (models.BranchConfig{}) {
+			delete(config.Branches, branchName)
+		}
 	}
 
 	// Write updated config back to file
-	err = UpdateConfig(configFilePath, config)
+	err = UpdateConfig(store, configFilePath, config)
 	if err != nil {
-		log.Fatalf("Error updating config: %v", err)
+		return fmt.Errorf("error updating config: %w", err)
 	}
 
-	fmt.Printf("Config updated successfully!\n")
+	return nil
 }
 
-// AddHandler adds a file to the index for staging, following Git conventions.
-func AddHandler(indexFilePath, absFilePath string) error {
-	// Calculate the SHA-1 hash of the file
-	hashValue, err := hash.SHA1Hash(absFilePath)
-	if err != nil {
-		return fmt.Errorf("error calculating hash for file %s: %w", absFilePath, err)
-	}
+// AddOptions controls which paths AddPathspecs stages.
+type AddOptions struct {
+	All    bool // Also stage deletions, and the whole working tree when no pathspec is given (-A)
+	Update bool // Only stage files that are already tracked, including their deletion (-u)
+	DryRun bool // Report the changes without writing the INDEX or any object (-n)
+	Force  bool // Also add ignored files (-f)
+}
+
+// AddChange is a path whose INDEX entry was (or, in a dry run, would be) added, updated or removed.
+type AddChange struct {
+	Path    string
+	Removed bool
+}
+
+// AddHandler adds a file, or every file below a directory, to the index for staging.
+// Files are recorded by their slash-separated path relative to the working tree root.
+func AddHandler(repo *models.Repository, filePath string) error {
+	_, err := AddPathspecs(repo, []string{filePath}, AddOptions{})
+	return err
+}
 
-	// Normalize the file path to use forward slashes
-	normalizedPath := filepath.ToSlash(absFilePath)
+// AddPathspecs stages the files selected by the pathspecs, which are file system paths that may
+// name directories or contain glob characters. Ignored files are skipped, and naming one
+// explicitly fails with models.ErrIgnored, unless opts.Force is set. The changes made to the
+// INDEX are returned sorted by path.
+func AddPathspecs(repo *models.Repository, paths []string, opts AddOptions) ([]AddChange, error) {
+	wholeTree := len(paths) == 0
+	if wholeTree {
+		if !opts.All && !opts.Update {
+			return nil, fmt.Errorf("nothing specified, nothing added")
+		}
+		paths = []string{repo.Directory}
+	}
 
-	// Open the INDEX file for appending or create it if not exists
-	indexFile, err := os.OpenFile(indexFilePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	specs, err := compilePathspecs(repo, paths)
 	if err != nil {
-		return fmt.Errorf("error opening INDEX file: %w", err)
+		return nil, err
+	}
+	matched := make([]bool, len(specs))
+	selected := func(name string) bool {
+		found := false
+		for i, spec := range specs {
+			if spec.match(name) {
+				matched[i] = true
+				found = true
+			}
+		}
+		return found
 	}
-	defer indexFile.Close()
 
-	// Format the entry to write into the INDEX file
-	entry := fmt.Sprintf("100644 %s %d\t%s\n", hashValue, 0, normalizedPath)
+	// Read the current entries, if the INDEX file exists yet
+	index := make(map[string]*models.IndexEntry)
+	if fsys.Exists(repo.Store, "INDEX") {
+		entries, err := vcs_operations.ReadIndexFile(repo)
+		if err != nil {
+			return nil, fmt.Errorf("error reading INDEX file: %w", err)
+		}
+		for _, entry := range entries {
+			index[entry.Path] = entry
+		}
+	}
 
-	// Write the entry into the INDEX file
-	if _, err := indexFile.WriteString(entry); err != nil {
-		return fmt.Errorf("error writing to INDEX file: %w", err)
+	matcher, err := LoadIgnoreMatcher(repo)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
+	// Naming an ignored path explicitly is an error, unless it is already tracked
+	if !opts.Force && !opts.Update {
+		for _, spec := range specs {
+			if !spec.literal() || spec.spec == "." {
+				continue
+			}
+			isDir := false
+			if info, err := repo.WorkTree.Stat(spec.spec); err == nil {
+				isDir = info.IsDir()
+			}
+			if !matcher.Ignored(spec.spec, isDir) {
+				continue
+			}
+			tracked := false
+			for trackedPath := range index {
+				if spec.match(trackedPath) {
+					tracked = true
+					break
+				}
+			}
+			if !tracked {
+				return nil, fmt.Errorf("%w: %s", models.ErrIgnored, spec.spec)
+			}
+		}
+	}
 
-// UpdateIndex updates the index file with the provided file path.
-func UpdateIndex(indexFile, filePath string) error {
-	// Open the index file for appending
-	f, err := os.OpenFile(indexFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	// Ignored directories are only walked when forced, but tracked files inside them still count
+	walkMatcher := matcher
+	if opts.Force {
+		walkMatcher = nil
+	}
+	files, err := getAllFilesInDir(repo.WorkTree, walkMatcher)
 	if err != nil {
-		return fmt.Errorf("failed to open index file: %w", err)
+		return nil, fmt.Errorf("error retrieving files from working directory: %w", err)
+	}
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		present[file] = true
 	}
-	defer f.Close()
+	for trackedPath := range index {
+		if info, err := repo.WorkTree.Stat(trackedPath); err == nil && !info.IsDir() && !present[trackedPath] {
+			files = append(files, trackedPath)
+			present[trackedPath] = true
+		}
+	}
+	sort.Strings(files)
 
-	// Write the file path to the index file
-	_, err = fmt.Fprintf(f, "%s\n", filePath)
-	if err != nil {
-		return fmt.Errorf("failed to write to index file: %w", err)
+	// A dry run only computes the IDs, so that no object is written
+	hashFile := func(file string) (string, error) {
+		if opts.DryRun {
+			return hash.FileID(repo.Hash(), repo.WorkTree, file)
+		}
+		return hash.StoreFile(repo.Hash(), repo.Store, repo.WorkTree, file)
 	}
 
-	return nil
-}
+	var changes []AddChange
+	for _, file := range files {
+		if !selected(file) {
+			continue
+		}
+		entry, tracked := index[file]
+		if !tracked && (opts.Update || (!opts.Force && matcher.Ignored(file, false))) {
+			continue
+		}
 
-// CommitHandler creates a commit object, compresses the file content, stores the compressed file,
-// updates metadata, and updates the HEAD reference.
-func CommitHandler(message string) {
-	// Create the commits directory if it doesn't exist
-	commitsDir := ".gitx/commits"
-	if _, err := os.Stat(commitsDir); os.IsNotExist(err) {
-		os.MkdirAll(commitsDir, os.ModePerm)
+		hashValue, err := hashFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating hash for file %s: %w", file, err)
+		}
+		if tracked && entry.Hash == hashValue {
+			continue
+		}
+
+		index[file] = &models.IndexEntry{Mode: "100644", Type: "blob", Hash: hashValue, Path: file}
+		changes = append(changes, AddChange{Path: file})
 	}
 
-	headFile := ".gitx/HEAD"
-	headContent, err := os.ReadFile(headFile)
-	if err != nil {
-		log.Fatalf("Error reading HEAD file: %v", err)
+	// Tracked files missing from the working tree are only removed with -A or -u
+	for _, trackedPath := range sortedKeys(index) {
+		if present[trackedPath] || !selected(trackedPath) {
+			continue
+		}
+		if opts.All || opts.Update {
+			delete(index, trackedPath)
+			changes = append(changes, AddChange{Path: trackedPath, Removed: true})
+		}
+	}
+
+	for i, spec := range specs {
+		if !matched[i] && !wholeTree {
+			return nil, fmt.Errorf("pathspec '%s' did not match any files", spec.spec)
+		}
 	}
 
-	headBranch := strings.TrimSpace(strings.TrimPrefix(string(headContent), "refs/heads/"))
-	if headBranch == string(headContent) {
-		headBranch = strings.TrimSpace(string(headContent))
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	if opts.DryRun || len(changes) == 0 {
+		return changes, nil
 	}
 
-	branchRefPath := filepath.Join(".gitx", "refs", "heads", headBranch)
-	branchRefPath = filepath.Clean(branchRefPath) // Ensure the path is clean
+	// Write the entries back into the INDEX file
+	entries := make([]*models.IndexEntry, 0, len(index))
+	for _, entry := range index {
+		entries = append(entries, entry)
+	}
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		return nil, fmt.Errorf("error writing to INDEX file: %w", err)
+	}
 
-	parentCommitHash, err := os.ReadFile(branchRefPath)
+	return changes, nil
+}
+
+// AddPatch lets the user pick which hunks of the differences between the INDEX and the working
+// tree to stage, for the tracked files selected by the pathspecs, or all of them if none are
+// given. The picked hunks are applied to the staged version of each file and the result is
+// written to the INDEX as a new blob.
+func AddPatch(repo *models.Repository, paths []string, selector *patch.Selector) error {
+	if len(paths) == 0 {
+		paths = []string{repo.Directory}
+	}
+	specs, err := compilePathspecs(repo, paths)
 	if err != nil {
-		log.Fatalf("Error reading branch ref file: %v", err)
+		return err
 	}
 
-	var parentCommit *models.Commit
-	if len(parentCommitHash) > 0 {
-		parentCommit, err = vcs_operations.GetCommitByHash(strings.TrimSpace(string(parentCommitHash)))
+	var entries []*models.IndexEntry
+	if fsys.Exists(repo.Store, "INDEX") {
+		entries, err = vcs_operations.ReadIndexFile(repo)
 		if err != nil {
-			log.Fatalf("Error retrieving parent commit: %v", err)
+			return fmt.Errorf("error reading INDEX file: %w", err)
 		}
-	} else {
-		// If there is no parent commit, create an initial commit for the main branch
-		initialCommit := createInitialCommit()
+	}
 
-		// Write the initial commit to the commits directory
-		initialCommitData, err := json.Marshal(initialCommit)
-		if err != nil {
-			log.Fatalf("Error serializing initial commit data: %v", err)
+	offered, staged := false, false
+	for _, entry := range entries {
+		selected := false
+		for _, spec := range specs {
+			selected = selected || spec.match(entry.Path)
 		}
-		initialCommitFilePath := filepath.Join(commitsDir, initialCommit.ID)
-		if err := os.WriteFile(initialCommitFilePath, initialCommitData, 0644); err != nil {
-			log.Fatalf("Error writing initial commit file: %v", err)
+		if !selected {
+			continue
 		}
 
-		if err := vcs_operations.UpdateHEAD("refs/heads/main"); err != nil {
-			log.Fatalf("Error updating HEAD with main branch reference: %v", err)
+		// Deleted files are left to add -u, since there are no lines to pick from
+		working, err := repo.WorkTree.ReadFile(entry.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", entry.Path, err)
+		}
+		index, err := vcs_operations.ReadBlob(repo, entry.Hash)
+		if err != nil {
+			return fmt.Errorf("error reading staged version of %s: %w", entry.Path, err)
+		}
+		if bytes.Equal(index, working) {
+			continue
+		}
+		if bytes.IndexByte(index, 0) >= 0 || bytes.IndexByte(working, 0) >= 0 {
+			fmt.Fprintf(selector.Out, "Binary file %s differs, use gitx add to stage it\n", entry.Path)
+			continue
 		}
 
-		mainBranchRefPath := filepath.Join(".gitx", "refs", "heads", "main")
-		if err := os.WriteFile(mainBranchRefPath, []byte(initialCommit.ID), 0644); err != nil {
-			log.Fatalf("Error creating main branch ref file: %v", err)
+		old := diff.SplitLines(index)
+		offered = true
+		picked, quit, err := selector.Select(entry.Path, old, diff.Hunks(old, diff.SplitLines(working), 3))
+		if err != nil {
+			return err
 		}
 
-		parentCommit = &initialCommit
+		if len(picked) > 0 {
+			lines, err := diff.Apply(old, picked)
+			if err != nil {
+				return fmt.Errorf("error applying hunks to %s: %w", entry.Path, err)
+			}
+			hashValue, err := hash.StoreBlob(repo.Hash(), repo.Store, []byte(strings.Join(lines, "")))
+			if err != nil {
+				return fmt.Errorf("error storing blob for %s: %w", entry.Path, err)
+			}
+			entry.Hash = hashValue
+			staged = true
+		}
+		if quit {
+			break
+		}
 	}
 
-	tree, err := vcs_operations.CreateTreeFromIndex(".gitx/index")
-	if err != nil {
-		log.Fatalf("Error creating tree from INDEX: %v", err)
+	if !offered {
+		fmt.Fprintln(selector.Out, "No changes.")
 	}
-
-	newCommit := models.Commit{
-		ID:        "",
-		Parent:    []*models.Commit{},
-		Tree:      tree,
-		Message:   message,
-		Author:    vcs_operations.GetCurrentUser(),
-		Timestamp: time.Now(),
+	if !staged {
+		return nil
 	}
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
 
-	if parentCommit != nil {
-		newCommit.Parent = append(newCommit.Parent, parentCommit)
+// CommitOptions configures CommitHandler.
+type CommitOptions struct {
+	// Sign signs the commit with the key of user.signingkey. Commits are also signed when
+	// commit.gpgSign is set.
+	Sign bool
+	// NoVerify skips the pre-commit and commit-msg hooks.
+	NoVerify bool
+	// All stages the changes to tracked files, including their deletion, before committing.
+	All bool
+	// AllowEmpty creates the commit even if its tree is the same as its parent's.
+	AllowEmpty bool
+	// Amend replaces the tip of the current branch with the new commit, which takes over its
+	// parents. The author is kept, and so is the message if none is given and Edit is nil.
+	Amend bool
+	// ResetAuthor makes the current user the author of an amended commit, as of now.
+	ResetAuthor bool
+	// Fixup names a commit the new one fixes. The message becomes "fixup! " followed by that
+	// commit's subject, and the given message, if any, becomes the body.
+	Fixup string
+	// Edit lets the user write the message in an editor when none is given. It is passed the
+	// name and initial content of the message file and returns the edited content.
+	Edit func(name string, content []byte) ([]byte, error)
+}
+
+// commitMessageHelp ends the message file opened in the editor.
+const commitMessageHelp = `
+# Please enter the commit message for your changes. Lines starting
+# with '#' will be ignored, and an empty message aborts the commit.
+`
+
+// CommitHandler creates a commit object from the INDEX, records it in the commit-graph, and updates
+// the branch reference. It returns models.ErrNothingToCommit when the INDEX matches the parent commit,
+// unless opts.AllowEmpty is set. The pre-commit hook runs first and the commit-msg hook once there
+// is something to commit; the post-commit hook runs after the commit is made.
+func CommitHandler(repo *models.Repository, message string, opts CommitOptions) (*models.Commit, error) {
+	if opts.Amend && opts.Fixup != "" {
+		return nil, errors.New("--fixup cannot be combined with --amend")
+	}
+	if opts.All {
+		if _, err := AddPathspecs(repo, nil, AddOptions{Update: true}); err != nil {
+			return nil, fmt.Errorf("error staging tracked files: %w", err)
+		}
+	}
+	if !opts.NoVerify {
+		if err := runHook(repo, HookPreCommit, nil, ""); err != nil {
+			return nil, err
+		}
 	}
 
-	newCommit.ID, err = vcs_operations.GenerateCommitID(newCommit.Tree, newCommit.Parent, newCommit.Message, newCommit.Author, newCommit.Timestamp)
-	if err != nil {
-		log.Fatalf("Error generating commit ID: %v", err)
+	// Create the commits directory if it doesn't exist
+	commitsDir := "commits"
+	if err := repo.Store.MkdirAll(commitsDir, fs.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating commits directory: %w", err)
 	}
 
-	// Serialize the commit object to JSON
-	commitData, err := json.Marshal(newCommit)
+	headContent, err := repo.Store.ReadFile("HEAD")
 	if err != nil {
-		log.Fatalf("Error serializing commit data: %v", err)
+		return nil, fmt.Errorf("error reading HEAD file: %w", err)
 	}
 
-	// Write Commit Object to File
-	commitFilePath := filepath.Join(commitsDir, newCommit.ID)
-	if err := os.WriteFile(commitFilePath, commitData, 0644); err != nil {
-		log.Fatalf("Error writing commit file: %v", err)
-	}
+	headRef := strings.TrimPrefix(strings.TrimSpace(string(headContent)), "ref: ")
+	headBranch := strings.TrimPrefix(headRef, "refs/heads/")
 
-	// Clear the INDEX file after committing
-	if err := os.Truncate(".gitx/index", 0); err != nil {
-		log.Fatalf("Error clearing INDEX file: %v", err)
+	branchRefPath := path.Join("refs", "heads", headBranch)
+
+	parentCommitHash, err := repo.Store.ReadFile(branchRefPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading branch ref file: %w", err)
 	}
 
-	// Update Metadata with the new commit
-	metadataFile := ".gitx/metadata.json"
-	if err := metadata_operations.UpdateMetadata(metadataFile, newCommit, "", ""); err != nil {
-		log.Fatalf("Error updating metadata: %v", err)
+	var parentCommit *models.Commit
+	if len(strings.TrimSpace(string(parentCommitHash))) > 0 {
+		parentCommit, err = vcs_operations.GetCommitByHash(repo, strings.TrimSpace(string(parentCommitHash)))
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving parent commit: %w", err)
+		}
 	}
 
-	if err := os.WriteFile(branchRefPath, []byte(newCommit.ID), 0644); err != nil {
-		log.Fatalf("Error updating branch ref file: %v", err)
+	tree, err := vcs_operations.CreateTreeFromIndex(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tree from INDEX: %w", err)
 	}
 
-	fmt.Printf("Commit created with ID: %s and message: %s\n", newCommit.ID, newCommit.Message)
-}
+	// An amended commit replaces the tip, so it is compared with the tip's parent
+	oldID := ""
+	var parents []*models.Commit
+	base := parentCommit
+	if parentCommit != nil {
+		oldID = parentCommit.ID
+		parents = []*models.Commit{parentCommit}
+	}
+	if opts.Amend {
+		if parentCommit == nil {
+			return nil, errors.New("there is no commit to amend")
+		}
+		parents, base = parentCommit.Parent, nil
+		if len(parents) == 1 {
+			if base, err = vcs_operations.GetCommitByHash(repo, parents[0].ID); err != nil {
+				return nil, fmt.Errorf("error retrieving parent commit: %w", err)
+			}
+		}
+	}
 
-// createInitialCommit creates the initial commit for the main branch.
-func createInitialCommit() models.Commit {
-	// Create an empty tree
-	emptyTree := vcs_operations.CreateEmptyTree()
+	// The INDEX holds the full snapshot, so an unchanged tree means nothing was staged. Merge
+	// commits are not compared, since the merge itself is the change.
+	if !opts.AllowEmpty && len(parents) <= 1 {
+		baseTreeID := vcs_operations.CreateEmptyTree(repo.Hash()).ID
+		if base != nil && base.Tree != nil {
+			baseTreeID = base.Tree.ID
+		}
+		if tree.ID == baseTreeID {
+			if opts.Amend {
+				return nil, fmt.Errorf("%w: amending would leave the commit empty; use --allow-empty to keep it", models.ErrNothingToCommit)
+			}
+			return nil, models.ErrNothingToCommit
+		}
+	}
 
-	// Set author and committer information
-	author := vcs_operations.GetCurrentUser()
-	committer := author
+	switch {
+	case opts.Fixup != "":
+		fixupID, err := vcs_operations.ResolveRevision(repo, opts.Fixup)
+		if err != nil {
+			return nil, err
+		}
+		target, err := vcs_operations.GetCommitByHash(repo, fixupID)
+		if err != nil {
+			return nil, err
+		}
+		subject, _, _ := strings.Cut(target.Message, "\n")
+		if message != "" {
+			message = "fixup! " + subject + "\n\n" + message
+		} else {
+			message = "fixup! " + subject
+		}
+	case message == "" && opts.Edit != nil:
+		template := ""
+		if opts.Amend {
+			template = parentCommit.Message + "\n"
+		}
+		edited, err := opts.Edit(commitEditMsgFile, []byte(template+commitMessageHelp))
+		if err != nil {
+			return nil, err
+		}
+		message = cleanupMessage(string(edited))
+	case message == "" && opts.Amend:
+		message = parentCommit.Message
+	}
+	if strings.TrimSpace(message) == "" {
+		return nil, errors.New("aborting commit due to empty commit message")
+	}
 
-	// Set commit message and timestamp
-	message := "Initial commit"
-	timestamp := time.Now()
+	if !opts.NoVerify {
+		if message, err = runCommitMsgHook(repo, message); err != nil {
+			return nil, err
+		}
+	}
 
-	// Generate commit ID
-	commitID, err := vcs_operations.GenerateCommitID(emptyTree, nil, author, committer, timestamp)
+	commit, err := newCommit(repo, tree, parents, message)
 	if err != nil {
-		log.Fatalf("Error generating commit ID: %v", err)
+		return nil, err
 	}
-
-	// Create the initial commit object
-	initialCommit := models.Commit{
-		ID:        commitID,
-		Parent:    nil, // No parent commit for the initial commit
-		Tree:      emptyTree,
-		Message:   message,
-		Author:    author,
-		Committer: committer,
-		Timestamp: timestamp,
+	action := "commit"
+	if opts.Amend {
+		action = "commit (amend)"
+		if !opts.ResetAuthor {
+			commit.Author, commit.Timestamp = parentCommit.Author, parentCommit.Timestamp
+		}
+	}
+	if err := writeCommit(repo, headBranch, oldID, commit, action, opts.Sign); err != nil {
+		return nil, err
 	}
 
-	return initialCommit
+	// The commit is made, so a failing post-commit hook cannot undo it
+	hookOldID := oldID
+	if hookOldID == "" {
+		hookOldID = repo.Hash().ZeroID()
+	}
+	_ = runHook(repo, HookPostCommit, nil, fmt.Sprintf("%s %s %s\n", hookOldID, commit.ID, headRef))
+	return commit, nil
 }
 
-// StatusHandler compares the files in the staging area with the tracked files in the metadata and the files in the working directory.
-func StatusHandler() {
-	// Helper function to convert to relative path
-	relativePath := func(path string) string {
-		basePath, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Error getting current working directory: %v", err)
+// cleanupMessage removes the comment lines of a message written in the editor, along with
+// trailing spaces and leading and trailing blank lines.
+func cleanupMessage(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
 		}
-		relPath, err := filepath.Rel(basePath, path)
-		if err != nil {
-			return path // Fallback to absolute path if relative path computation fails
-		}
-		return relPath
 	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
 
-	// Step 1: Retrieve tracked files from metadata
-	trackedFiles, err := metadata_operations.GetTrackedFiles("metadata.json")
+// newCommit returns a commit of the tree with the given parents, authored and committed by the
+// current user now. Parents are stored by ID only, so that a commit does not embed the whole
+// history before it.
+func newCommit(repo *models.Repository, tree *models.Tree, parents []*models.Commit, message string) (*models.Commit, error) {
+	author, err := vcs_operations.GetCurrentUser(repo, vcs_operations.RoleAuthor)
+	if err != nil {
+		return nil, err
+	}
+	committer, err := vcs_operations.GetCurrentUser(repo, vcs_operations.RoleCommitter)
 	if err != nil {
-		log.Fatalf("Error retrieving tracked files: %v", err)
+		return nil, err
+	}
+	now := time.Now()
+	commit := &models.Commit{
+		Parent:     []*models.Commit{},
+		Tree:       tree,
+		Message:    message,
+		Author:     author,
+		Timestamp:  now,
+		Committer:  committer,
+		CommitTime: now,
+	}
+	for _, parent := range parents {
+		commit.Parent = append(commit.Parent, &models.Commit{ID: parent.ID})
 	}
+	return commit, nil
+}
 
-	// Step 2: Read the INDEX file to get the staging area
-	indexFile := ".gitx/index"
-	indexEntries, err := vcs_operations.ReadIndexFile(indexFile)
+// writeCommit stores the commit under its ID, which it sets, records it in the commit-graph,
+// moves the branch from oldID to it and logs the move in the reflog. The reflog message is the
+// action followed by the commit's subject. The commit is signed if sign or commit.gpgSign is set.
+func writeCommit(repo *models.Repository, branch, oldID string, commit *models.Commit, action string, sign bool) error {
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
 	if err != nil {
-		log.Fatalf("Error reading INDEX file: %v", err)
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	if sign || config.GPGSign {
+		if commit.GPGSignature, err = signPayload(repo, vcs_operations.CommitPayload(commit)); err != nil {
+			return fmt.Errorf("error signing commit: %w", err)
+		}
 	}
 
-	stagingArea := make(map[string]string)
-	for _, entry := range indexEntries {
-		relPath := relativePath(entry.Path)
-		stagingArea[relPath] = entry.Hash
+	commit.ID, err = vcs_operations.GenerateCommitID(repo.Hash(), commit)
+	if err != nil {
+		return fmt.Errorf("error generating commit ID: %w", err)
 	}
 
-	// Step 3: Get list of files in the working directory
-	workingDirFiles, err := getAllFilesInDir(".")
+	// Serialize the commit object to JSON
+	commitData, err := json.Marshal(commit)
 	if err != nil {
-		log.Fatalf("Error retrieving files from working directory: %v", err)
+		return fmt.Errorf("error serializing commit data: %w", err)
 	}
 
-	// Helper function to check if a file path is within the .gitx directory
-	isGitxFile := func(path string) bool {
-		return strings.HasPrefix(path, ".gitx"+string(os.PathSeparator))
+	// Write Commit Object to File
+	commitFilePath := path.Join("commits", commit.ID)
+	if err := repo.Store.WriteFile(commitFilePath, commitData, 0644); err != nil {
+		return fmt.Errorf("error writing commit file: %w", err)
 	}
 
-	// Convert tracked files to relative paths for comparison
-	relativeTrackedFiles := make(map[string]string)
-	for path, hash := range trackedFiles {
-		relPath := relativePath(path)
-		relativeTrackedFiles[relPath] = hash
+	if err := vcs_operations.AddToCommitGraph(repo, commit); err != nil {
+		return fmt.Errorf("error updating commit-graph: %w", err)
 	}
 
-	// Debug print to verify tracked files and staging area
-	fmt.Println("Tracked Files:")
-	for path, hash := range relativeTrackedFiles {
-		fmt.Printf("\t%s: %s\n", path, hash)
+	if err := vcs_operations.CreateBranchRef(repo, branch, commit.ID); err != nil {
+		return fmt.Errorf("error updating branch ref file: %w", err)
 	}
 
-	fmt.Println("Index File Entries:")
-	for _, entry := range indexEntries {
-		fmt.Printf("\t%s: %s\n", entry.Path, entry.Hash)
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	return vcs_operations.AppendReflog(repo, "refs/heads/"+branch, oldID, commit.ID, action+": "+subject)
+}
+
+// initialCommitAuthor is the author of the initial commit when no identity is set.
+const initialCommitAuthor = "GitX <gitx@localhost>"
+
+// createInitialCommit creates the initial commit for the main branch, identified with the
+// repository's hash algorithm.
+func createInitialCommit(repo *models.Repository) (models.Commit, error) {
+	alg := repo.Hash()
+	// Create an empty tree
+	emptyTree := vcs_operations.CreateEmptyTree(alg)
+
+	// Set author and committer information. The new repository has no identity configured, so
+	// unless the environment sets one the commit is attributed to GitX itself.
+	author, err := vcs_operations.GetCurrentUser(repo, vcs_operations.RoleAuthor)
+	if err != nil {
+		author = initialCommitAuthor
+	}
+	committer, err := vcs_operations.GetCurrentUser(repo, vcs_operations.RoleCommitter)
+	if err != nil {
+		committer = author
 	}
 
-	// Step 4: Compare files
-	fmt.Println("Changes to be committed:")
-	for filePath, hashValue := range stagingArea {
-		if isGitxFile(filePath) {
-			continue // Skip .gitx files
-		}
-		if _, ok := relativeTrackedFiles[filePath]; !ok {
-			fmt.Printf("\tnew file: %s\n", filePath)
-		} else {
-			if hashValue != relativeTrackedFiles[filePath] {
-				fmt.Printf("\tmodified: %s\n", filePath)
-			}
-		}
+	// Create the initial commit object
+	initialCommit := models.Commit{
+		Parent:    nil, // No parent commit for the initial commit
+		Tree:      emptyTree,
+		Message:   "Initial commit",
+		Author:    author,
+		Committer: committer,
+		Timestamp: time.Now(),
 	}
 
-	fmt.Println("Changes not staged for commit:")
-	for _, file := range workingDirFiles {
-		if isGitxFile(file) {
-			continue // Skip .gitx files
-		}
-		relativeFile := relativePath(file)
-		if _, ok := stagingArea[relativeFile]; !ok {
-			if hashValue, err := hash.SHA1Hash(file); err == nil {
-				if trackedHash, ok := relativeTrackedFiles[relativeFile]; ok {
-					if hashValue != trackedHash {
-						fmt.Printf("\tmodified: %s\n", relativeFile)
-					}
-				} else {
-					fmt.Printf("\tuntracked: %s\n", relativeFile)
-				}
-			} else {
-				log.Printf("Error hashing file %s: %v", file, err)
-			}
-		}
+	// Generate commit ID
+	commitID, err := vcs_operations.GenerateCommitID(alg, &initialCommit)
+	if err != nil {
+		return models.Commit{}, fmt.Errorf("error generating commit ID: %w", err)
 	}
+	initialCommit.ID = commitID
+
+	return initialCommit, nil
 }
 
-// getAllFilesInDir returns a list of all files in a directory.
-func getAllFilesInDir(dirPath string) ([]string, error) {
+// getAllFilesInDir returns the slash-separated paths of all the files in a working tree,
+// skipping the .gitx directory and, if matcher is not nil, the ignored files and directories.
+func getAllFilesInDir(workTree fs.FS, matcher *ignore.Matcher) ([]string, error) {
 	var files []string
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(workTree, ".", func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
+		if path == "." {
+			return nil
+		}
+		if entry.IsDir() {
+			if path == ".gitx" || (matcher != nil && matcher.Ignored(path, true)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if matcher == nil || !matcher.Ignored(path, false) {
 			files = append(files, path)
 		}
 		return nil
@@ -524,3 +852,45 @@ func getAllFilesInDir(dirPath string) ([]string, error) {
 	}
 	return files, nil
 }
+
+// workTreeBlobID returns the blob ID the file at the slash-separated path would have, without
+// writing it to the object store. exists is false if there is no such file.
+func workTreeBlobID(repo *models.Repository, filePath string) (id string, exists bool, err error) {
+	info, err := repo.WorkTree.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	id, err = hash.FileID(repo.Hash(), repo.WorkTree, filePath)
+	if err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+// removeEmptyParents removes the directories above the slash-separated path that became empty,
+// stopping at the root of the working tree.
+func removeEmptyParents(workTree fsys.FS, filePath string) {
+	for dir := path.Dir(filePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		entries, err := workTree.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		if err := workTree.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// newTestRepo creates a repository on in-memory file systems, with an identity configured.
+func newTestRepo(t *testing.T) *models.Repository {
+	t.Helper()
+	return initTestRepo(t, string(filepath.Separator), fsys.NewMemFS(), fsys.NewMemFS())
+}
+
+// newDiskTestRepo creates a repository in a temporary directory, for operations that need
+// real files such as hooks.
+func newDiskTestRepo(t *testing.T) *models.Repository {
+	t.Helper()
+	directory := t.TempDir()
+	return initTestRepo(t, directory, fsys.NewOSFS(directory), fsys.NewOSFS(filepath.Join(directory, ".gitx")))
+}
+
+// initTestRepo initializes a repository rooted at directory, with an identity configured.
+func initTestRepo(t *testing.T, directory string, workTree, store fsys.FS) *models.Repository {
+	t.Helper()
+	repo := &models.Repository{
+		Directory: directory,
+		GitxDir:   filepath.Join(directory, ".gitx"),
+		WorkTree:  workTree,
+		Store:     store,
+	}
+	if err := InitHandler(repo); err != nil {
+		t.Fatal(err)
+	}
+	setConfig(t, repo, "user.name", "Test User")
+	setConfig(t, repo, "user.email", "test@example.com")
+	return repo
+}
+
+// setConfig sets the repository config key to value.
+func setConfig(t *testing.T, repo *models.Repository, key, value string) {
+	t.Helper()
+	if err := ConfigHandler(repo, key, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFile writes content to the working tree file at the slash-separated path name.
+func writeFile(t *testing.T, repo *models.Repository, name, content string) {
+	t.Helper()
+	if err := repo.WorkTree.MkdirAll(path.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.WorkTree.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// workPath returns the absolute path of the working tree file at the slash-separated path name.
+func workPath(repo *models.Repository, name string) string {
+	return filepath.Join(repo.Directory, filepath.FromSlash(name))
+}
+
+// readFile returns the content of the working tree file at name, or "" if it does not exist.
+func readFile(t *testing.T, repo *models.Repository, name string) string {
+	t.Helper()
+	if !fsys.Exists(repo.WorkTree, name) {
+		return ""
+	}
+	content, err := repo.WorkTree.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// addFile stages the working tree file at name.
+func addFile(t *testing.T, repo *models.Repository, name string) {
+	t.Helper()
+	if err := AddHandler(repo, workPath(repo, name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitFiles writes and stages files, which maps paths to contents, and commits them.
+func commitFiles(t *testing.T, repo *models.Repository, message string, files map[string]string) *models.Commit {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeFile(t, repo, name, files[name])
+		addFile(t, repo, name)
+	}
+	commit, err := CommitHandler(repo, message, CommitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+// indexHashes returns the blob IDs staged in the INDEX by path.
+func indexHashes(t *testing.T, repo *models.Repository) map[string]string {
+	t.Helper()
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		hashes[entry.Path] = entry.Hash
+	}
+	return hashes
+}
@@ -0,0 +1,120 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/internal/hooks"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hooks run around commits and merges. A hook is an executable file named after the hook in
+// the hooks directory of the .gitx directory, or in core.hooksPath. It runs at the root of the
+// working tree with GITX_DIR, GITX_WORK_TREE, GITX_INDEX_FILE and GITX_HOOK set.
+const (
+	// HookPreCommit runs before a commit is made, with no arguments. A non-zero exit aborts the commit.
+	HookPreCommit = "pre-commit"
+	// HookCommitMsg runs with the file holding the commit message as its argument, which it
+	// may edit. A non-zero exit aborts the commit.
+	HookCommitMsg = "commit-msg"
+	// HookPostCommit runs after a commit is made, with "<old> <new> <ref>" on standard input,
+	// the commits the branch pointed to before and after. Its exit status is ignored.
+	HookPostCommit = "post-commit"
+	// HookPreMerge runs before a merge commit is made, once the merged files are in the
+	// working tree and the INDEX, with "<head> <merged commit> <revision>" on standard input.
+	// A non-zero exit aborts the merge.
+	HookPreMerge = "pre-merge"
+)
+
+// commitEditMsgFile holds the commit message while the commit-msg hook runs.
+const commitEditMsgFile = "COMMIT_EDITMSG"
+
+// hooksPath returns the directory hooks are run from: core.hooksPath, relative to the root of
+// the working tree, or else the hooks directory of the .gitx directory.
+func hooksPath(repo *models.Repository) (string, error) {
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	dir := config.HooksPath
+	if dir == "" {
+		return repo.Path("hooks"), nil
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo.Directory, dir)
+	}
+	return dir, nil
+}
+
+// findHook returns the path of the hook called name, and whether it should be run. Hooks only
+// run in repositories kept on disk, since they work on the files of the working tree and the
+// .gitx directory.
+func findHook(repo *models.Repository, name string) (string, bool, error) {
+	if !fsys.IsOS(repo.Store) || !fsys.IsOS(repo.WorkTree) {
+		return "", false, nil
+	}
+	dir, err := hooksPath(repo)
+	if err != nil {
+		return "", false, err
+	}
+	hookPath, ok := hooks.Find(dir, name)
+	return hookPath, ok, nil
+}
+
+// runHook runs the hook called name, if there is one. The error wraps models.ErrHookFailed
+// when the hook exits with a non-zero status.
+func runHook(repo *models.Repository, name string, args []string, stdin string) error {
+	hookPath, ok, err := findHook(repo, name)
+	if err != nil || !ok {
+		return err
+	}
+	err = hooks.Run(hookPath, hooks.Options{
+		Dir:   repo.Directory,
+		Args:  args,
+		Stdin: stdin,
+		Env: []string{
+			"GITX_DIR=" + repo.GitxDir,
+			"GITX_WORK_TREE=" + repo.Directory,
+			"GITX_INDEX_FILE=" + repo.Path("INDEX"),
+			"GITX_HOOK=" + name,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s hook %v", models.ErrHookFailed, name, err)
+	}
+	return nil
+}
+
+// runCommitMsgHook passes the message to the commit-msg hook, if there is one, and returns the
+// message as the hook left it.
+func runCommitMsgHook(repo *models.Repository, message string) (string, error) {
+	if _, ok, err := findHook(repo, HookCommitMsg); err != nil || !ok {
+		return message, err
+	}
+	if err := repo.Store.WriteFile(commitEditMsgFile, []byte(message+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("error writing commit message file: %w", err)
+	}
+	if err := runHook(repo, HookCommitMsg, []string{repo.Path(commitEditMsgFile)}, ""); err != nil {
+		return "", err
+	}
+	data, err := repo.Store.ReadFile(commitEditMsgFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading commit message file: %w", err)
+	}
+	message = strings.TrimRight(string(data), "\n")
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("aborting commit due to empty commit message")
+	}
+	return message, nil
+}
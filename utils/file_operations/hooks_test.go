@@ -0,0 +1,152 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeHook installs a shell script as the hook called name in dir.
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPreCommitHook(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	writeHook(t, repo.Path("hooks"), HookPreCommit, "echo rejected >&2\nexit 1\n")
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+	before, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CommitHandler(repo, "first", CommitOptions{}); !errors.Is(err, models.ErrHookFailed) {
+		t.Fatalf("commit with a failing pre-commit hook: %v, want %v", err, models.ErrHookFailed)
+	}
+	if head, err := vcs_operations.GetCurrentHeadCommit(repo); err != nil || head != before {
+		t.Errorf("HEAD after a rejected commit = %q, %v, want %q", head, err, before)
+	}
+	if _, err := CommitHandler(repo, "first", CommitOptions{NoVerify: true}); err != nil {
+		t.Errorf("commit with --no-verify: %v", err)
+	}
+}
+
+func TestCommitMsgHook(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	writeHook(t, repo.Path("hooks"), HookCommitMsg, "echo 'Signed-off-by: Test User' >> \"$1\"\n")
+	writeHook(t, repo.Path("hooks"), HookPostCommit, "cat > \"$GITX_DIR/post-commit.out\"\n")
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+	parent, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit, err := CommitHandler(repo, "first", CommitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nSigned-off-by: Test User"; commit.Message != want {
+		t.Errorf("message = %q, want %q", commit.Message, want)
+	}
+
+	// The post-commit hook gets the parent, the commit and the branch
+	out, err := repo.Store.ReadFile("post-commit.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parent + " " + commit.ID + " refs/heads/main\n"; string(out) != want {
+		t.Errorf("post-commit input = %q, want %q", out, want)
+	}
+}
+
+func TestEmptyCommitMsgAborts(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	writeHook(t, repo.Path("hooks"), HookCommitMsg, ": > \"$1\"\n")
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+
+	if _, err := CommitHandler(repo, "first", CommitOptions{}); err == nil {
+		t.Error("commit with a message emptied by the commit-msg hook succeeded")
+	}
+}
+
+func TestPreMergeHook(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	if err := SwitchHandler(repo, "topic", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	theirs := commitFiles(t, repo, "topic", map[string]string{"b.txt": "b\n"})
+	if err := SwitchHandler(repo, "main", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	head := commitFiles(t, repo, "main", map[string]string{"c.txt": "c\n"})
+	writeHook(t, repo.Path("hooks"), HookPreMerge, "cat > \"$GITX_DIR/pre-merge.out\"\ntest -f b.txt || exit 2\nexit 1\n")
+
+	// The hook sees the merged files, which are taken back when it rejects the merge
+	if _, err := MergeHandler(repo, "topic", MergeOptions{}); !errors.Is(err, models.ErrHookFailed) || !strings.Contains(err.Error(), "status 1") {
+		t.Fatalf("merge with a failing pre-merge hook: %v, want %v with status 1", err, models.ErrHookFailed)
+	}
+	if id := headID(t, repo); id != head.ID {
+		t.Errorf("HEAD after a rejected merge = %s, want %s", id, head.ID)
+	}
+	if readFile(t, repo, "b.txt") != "" {
+		t.Error("b.txt of the rejected merge is left in the working tree")
+	}
+	out, err := repo.Store.ReadFile("pre-merge.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := head.ID + " " + theirs.ID + " topic\n"; string(out) != want {
+		t.Errorf("pre-merge input = %q, want %q", out, want)
+	}
+
+	result, err := MergeHandler(repo, "topic", MergeOptions{NoVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Commit.Parent) != 2 {
+		t.Errorf("merge with --no-verify made %+v, want a merge commit", result.Commit)
+	}
+}
+
+func TestHooksPath(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	setConfig(t, repo, "core.hooksPath", "githooks")
+	writeHook(t, filepath.Join(repo.Directory, "githooks"), HookPreCommit, "exit 1\n")
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+
+	if _, err := CommitHandler(repo, "first", CommitOptions{}); !errors.Is(err, models.ErrHookFailed) {
+		t.Errorf("commit with a failing hook in core.hooksPath: %v, want %v", err, models.ErrHookFailed)
+	}
+}
+
+func TestHooksSkippedInMemory(t *testing.T) {
+	repo := newTestRepo(t)
+	hooksDir := t.TempDir()
+	setConfig(t, repo, "core.hooksPath", hooksDir)
+	writeHook(t, hooksDir, HookPreCommit, "exit 1\n")
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+
+	if _, err := CommitHandler(repo, "first", CommitOptions{}); err != nil {
+		t.Errorf("commit in a repository without files on disk ran the hook: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package file_operations
+
+import (
+	"GitX/internal/ignore"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the per-directory ignore files in the working tree.
+const IgnoreFileName = ".gitxignore"
+
+// LoadIgnoreMatcher returns a matcher for the repository's ignore rules. In order of increasing
+// precedence these are the global excludes file, .gitx/info/exclude and the .gitxignore files
+// of the working tree, deeper files overriding the ones above them. The global excludes file is
+// read with repo.ReadHostFile, so repositories without one only use their own rules.
+func LoadIgnoreMatcher(repo *models.Repository) (*ignore.Matcher, error) {
+	var base []*ignore.Pattern
+
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	if excludesFile := globalExcludesFile(config); excludesFile != "" && repo.ReadHostFile != nil {
+		data, err := repo.ReadHostFile(excludesFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error reading excludes file: %w", err)
+		}
+		base = append(base, ignore.ParsePatterns(data, excludesFile, "")...)
+	}
+
+	data, err := repo.Store.ReadFile(path.Join("info", "exclude"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading info/exclude: %w", err)
+	}
+	base = append(base, ignore.ParsePatterns(data, ".gitx/info/exclude", "")...)
+
+	return ignore.NewMatcher(repo.WorkTree, IgnoreFileName, base...), nil
+}
+
+// globalExcludesFile returns the path of the global excludes file: core.excludesFile if it is
+// configured, otherwise $XDG_CONFIG_HOME/gitx/ignore or ~/.config/gitx/ignore.
+func globalExcludesFile(config *models.GitXConfig) string {
+	if config.ExcludesFile != "" {
+		if rest, found := strings.CutPrefix(config.ExcludesFile, "~/"); found {
+			if home, err := os.UserHomeDir(); err == nil {
+				return filepath.Join(home, rest)
+			}
+		}
+		return config.ExcludesFile
+	}
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "gitx", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "gitx", "ignore")
+	}
+	return ""
+}
+
+// CheckIgnore returns, for each of the given paths, the pattern that decides whether it is
+// ignored, or nil if no pattern matches. A negated pattern means the path is not ignored.
+func CheckIgnore(repo *models.Repository, paths []string) ([]*ignore.Pattern, error) {
+	matcher, err := LoadIgnoreMatcher(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*ignore.Pattern, len(paths))
+	for i, filePath := range paths {
+		relPath, err := repo.RelPath(filePath)
+		if err != nil {
+			return nil, err
+		}
+		isDir := false
+		if info, err := repo.WorkTree.Stat(relPath); err == nil {
+			isDir = info.IsDir()
+		}
+		matches[i] = matcher.Match(relPath, isDir)
+	}
+	return matches, nil
+}
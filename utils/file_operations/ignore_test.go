@@ -0,0 +1,67 @@
+package file_operations
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestCheckIgnore(t *testing.T) {
+	repo := newTestRepo(t)
+	writeFile(t, repo, IgnoreFileName, "*.log\n!keep.log\n")
+	writeFile(t, repo, "sub/"+IgnoreFileName, "*.txt\n")
+	if err := repo.Store.MkdirAll("info", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Store.WriteFile("info/exclude", []byte("build/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "build/out", "")
+	setConfig(t, repo, "core.excludesFile", "/home/user/excludes")
+
+	check := func(want map[string]string) {
+		t.Helper()
+		names := make([]string, 0, len(want))
+		for name := range want {
+			names = append(names, name)
+		}
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = workPath(repo, name)
+		}
+		patterns, err := CheckIgnore(repo, paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range names {
+			got := ""
+			if patterns[i] != nil {
+				got = patterns[i].Text
+			}
+			if got != want[name] {
+				t.Errorf("%s is decided by %q, want %q", name, got, want[name])
+			}
+		}
+	}
+
+	// Without a host file system there is no global excludes file
+	check(map[string]string{
+		"a.log":     "*.log",
+		"keep.log":  "!keep.log",
+		"build":     "build/",
+		"a.txt":     "",
+		"sub/a.txt": "*.txt",
+		"b.tmp":     "",
+	})
+
+	repo.ReadHostFile = func(name string) ([]byte, error) {
+		if name != "/home/user/excludes" {
+			return nil, fs.ErrNotExist
+		}
+		return []byte("*.tmp\n"), nil
+	}
+	check(map[string]string{
+		"b.tmp":     "*.tmp",
+		"sub/b.tmp": "*.tmp",
+		"a.txt":     "",
+	})
+}
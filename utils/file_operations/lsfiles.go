@@ -0,0 +1,128 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+)
+
+// LsFilesOptions configures LsFiles. The files selected by each option are listed together;
+// when none is set, the files in the INDEX are listed.
+type LsFilesOptions struct {
+	Modified bool // Files in the INDEX whose working tree version differs, or is deleted
+	Others   bool // Files of the working tree that are neither in the INDEX nor ignored
+	Ignored  bool // Files of the working tree that are ignored and not in the INDEX
+	// Unmerged selects the files whose conflicts are not yet resolved, in the cherry-pick,
+	// revert or rebase that stopped on them.
+	Unmerged bool
+}
+
+// LsFilesEntry is a file listed by LsFiles.
+type LsFilesEntry struct {
+	Path string
+	// Mode and Hash are those of the INDEX entry, empty for files that are not in the INDEX
+	Mode, Hash string
+	// Stage is 0 for a file without conflicts. The INDEX keeps HEAD's version of a conflicted
+	// file, so an unmerged file has stage 2, "ours", like in Git.
+	Stage int
+}
+
+// LsFiles lists the files selected by opts, sorted by path, restricted to those matching the
+// pathspecs if any are given.
+func LsFiles(repo *models.Repository, paths []string, opts LsFilesOptions) ([]LsFilesEntry, error) {
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return nil, err
+	}
+	indexEntries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	index := make(map[string]*models.IndexEntry, len(indexEntries))
+	for _, entry := range indexEntries {
+		index[entry.Path] = entry
+	}
+
+	selected := make(map[string]LsFilesEntry)
+	add := func(filePath string, stage int) {
+		entry := LsFilesEntry{Path: filePath, Stage: stage}
+		if indexEntry, ok := index[filePath]; ok {
+			entry.Mode, entry.Hash = indexEntry.Mode, indexEntry.Hash
+		}
+		selected[filePath] = entry
+	}
+
+	if !opts.Modified && !opts.Others && !opts.Ignored && !opts.Unmerged {
+		for filePath := range index {
+			add(filePath, 0)
+		}
+	}
+	if opts.Modified {
+		for filePath, entry := range index {
+			workID, exists, err := workTreeBlobID(repo, filePath)
+			if err != nil {
+				return nil, fmt.Errorf("error hashing %s: %w", filePath, err)
+			}
+			if !exists || workID != entry.Hash {
+				add(filePath, 0)
+			}
+		}
+	}
+	if opts.Others || opts.Ignored {
+		matcher, err := LoadIgnoreMatcher(repo)
+		if err != nil {
+			return nil, err
+		}
+		files, err := getAllFilesInDir(repo.WorkTree, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving files from working directory: %w", err)
+		}
+		for _, filePath := range files {
+			if _, tracked := index[filePath]; tracked {
+				continue
+			}
+			if ignored := matcher.Ignored(filePath, false); ignored && opts.Ignored || !ignored && opts.Others {
+				add(filePath, 0)
+			}
+		}
+	}
+	if opts.Unmerged {
+		conflicts, err := pendingConflicts(repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range conflicts {
+			add(filePath, 2)
+		}
+	}
+
+	var entries []LsFilesEntry
+	for _, filePath := range sortedKeys(selected) {
+		if len(specs) == 0 || matchesAny(specs, filePath) {
+			entries = append(entries, selected[filePath])
+		}
+	}
+	return entries, nil
+}
+
+// pendingConflicts returns the paths whose conflicts are not yet resolved in the cherry-pick,
+// revert or rebase in progress.
+func pendingConflicts(repo *models.Repository) ([]string, error) {
+	var conflicts []string
+	if fsys.Exists(repo.Store, sequencerDir) {
+		state, err := loadSequencer(repo, "")
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, state.Conflicts...)
+	}
+	if fsys.Exists(repo.Store, rebaseDir) {
+		state, err := loadRebase(repo)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, state.Conflicts...)
+	}
+	return unresolvedConflicts(repo, conflicts)
+}
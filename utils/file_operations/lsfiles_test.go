@@ -0,0 +1,93 @@
+package file_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLsFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{
+		".gitxignore": "*.log\n",
+		"a.txt":       "a\n",
+		"b.txt":       "b\n",
+		"dir/c.txt":   "c\n",
+	})
+	writeFile(t, repo, "a.txt", "changed\n")
+	if err := repo.WorkTree.Remove("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "new.txt", "new\n")
+	writeFile(t, repo, "out.log", "log\n")
+
+	tests := []struct {
+		name  string
+		paths []string
+		opts  LsFilesOptions
+		want  []string
+	}{
+		{name: "cached", want: []string{".gitxignore", "a.txt", "b.txt", "dir/c.txt"}},
+		{name: "pathspec", paths: []string{workPath(repo, "dir")}, want: []string{"dir/c.txt"}},
+		{name: "modified", opts: LsFilesOptions{Modified: true}, want: []string{"a.txt", "b.txt"}},
+		{name: "others", opts: LsFilesOptions{Others: true}, want: []string{"new.txt"}},
+		{name: "ignored", opts: LsFilesOptions{Ignored: true}, want: []string{"out.log"}},
+		{name: "others and ignored", opts: LsFilesOptions{Others: true, Ignored: true}, want: []string{"new.txt", "out.log"}},
+		{name: "unmerged", opts: LsFilesOptions{Unmerged: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := LsFiles(repo, test.paths, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, entry := range entries {
+				paths = append(paths, entry.Path)
+			}
+			if !reflect.DeepEqual(paths, test.want) {
+				t.Errorf("files %q, want %q", paths, test.want)
+			}
+		})
+	}
+
+	index := indexHashes(t, repo)
+	entries, err := LsFiles(repo, nil, LsFilesOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Hash != index[entry.Path] || entry.Mode == "" || entry.Stage != 0 {
+			t.Errorf("entry %+v, want the INDEX entry at stage 0", entry)
+		}
+	}
+}
+
+func TestLsFilesUnmerged(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "topic change", map[string]string{"a.txt": "1\ntopic\n3\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "main change", map[string]string{"a.txt": "1\nmain\n3\n"})
+	if _, err := CherryPick(repo, []string{topic.ID}, PickOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LsFiles(repo, nil, LsFilesOptions{Unmerged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "a.txt" || entries[0].Stage != 2 || entries[0].Hash == "" {
+		t.Fatalf("unmerged files %+v, want a.txt at stage 2", entries)
+	}
+
+	// Staging the resolution resolves it
+	writeFile(t, repo, "a.txt", "1\nresolved\n3\n")
+	addFile(t, repo, "a.txt")
+	if entries, err = LsFiles(repo, nil, LsFilesOptions{Unmerged: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unmerged files %+v after the resolution is staged, want none", entries)
+	}
+}
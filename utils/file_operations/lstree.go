@@ -0,0 +1,94 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// LsTreeOptions configures LsTree.
+type LsTreeOptions struct {
+	// Recursive lists the files of subdirectories instead of one tree entry per directory.
+	Recursive bool
+}
+
+// LsTree returns the entries of the tree named by treeish, sorted by path: a commit or an
+// annotated tag names the tree of its commit, and "<rev>:<path>" a directory of it. Only the
+// entries directly in the tree are returned, with one tree entry per directory, unless
+// opts.Recursive is set. Paths, relative to the tree, select the entries to return: a path
+// names the entry itself, and a path ending with a slash the entries of that directory.
+func LsTree(repo *models.Repository, treeish string, paths []string, opts LsTreeOptions) ([]models.TreeEntry, error) {
+	object, err := vcs_operations.ReadObject(repo, treeish)
+	if err != nil {
+		return nil, err
+	}
+	var tree *models.Tree
+	switch object.Type {
+	case vcs_operations.ObjectTree:
+		tree = object.Tree
+	case vcs_operations.ObjectCommit:
+		tree = object.Commit.Tree
+	case vcs_operations.ObjectTag:
+		commit, err := vcs_operations.GetCommitByHash(repo, object.Tag.Object)
+		if err != nil {
+			return nil, err
+		}
+		tree = commit.Tree
+	default:
+		return nil, fmt.Errorf("'%s' is a %s, not a tree", treeish, object.Type)
+	}
+
+	if opts.Recursive {
+		var entries []models.TreeEntry
+		for _, entry := range tree.Entries {
+			if len(paths) == 0 || underAnyPath(entry.Name, paths) {
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	}
+
+	if len(paths) == 0 {
+		return vcs_operations.TreeChildren(repo.Hash(), tree), nil
+	}
+	selected := make(map[string]models.TreeEntry)
+	for _, filePath := range paths {
+		listDir := strings.HasSuffix(filePath, "/")
+		filePath = strings.Trim(path.Clean("/"+filePath), "/")
+		dir, name := path.Dir(filePath), path.Base(filePath)
+		if listDir || filePath == "" {
+			dir, name = filePath, ""
+		}
+		if dir == "." {
+			dir = ""
+		}
+		sub := vcs_operations.SubTree(repo.Hash(), tree, dir)
+		if sub == nil {
+			continue
+		}
+		for _, entry := range vcs_operations.TreeChildren(repo.Hash(), sub) {
+			if name == "" || entry.Name == name {
+				entry.Name = path.Join(dir, entry.Name)
+				selected[entry.Name] = entry
+			}
+		}
+	}
+	entries := make([]models.TreeEntry, 0, len(selected))
+	for _, name := range sortedKeys(selected) {
+		entries = append(entries, selected[name])
+	}
+	return entries, nil
+}
+
+// underAnyPath reports whether the slash-separated name is one of the paths or below one of them.
+func underAnyPath(name string, paths []string) bool {
+	for _, filePath := range paths {
+		filePath = strings.Trim(path.Clean("/"+filePath), "/")
+		if filePath == "" || name == filePath || strings.HasPrefix(name, filePath+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package file_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLsTree(t *testing.T) {
+	repo := newTestRepo(t)
+	commit := commitFiles(t, repo, "first", map[string]string{
+		"a.txt":         "a\n",
+		"dir/b.txt":     "b\n",
+		"dir/sub/c.txt": "c\n",
+		"with space":    "d\n",
+	})
+	if _, err := TagHandler(repo, "v1", "", TagOptions{Message: "Release 1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		treeish  string
+		paths    []string
+		opts     LsTreeOptions
+		want     []string
+		wantType []string
+	}{
+		{name: "top level", treeish: "HEAD", want: []string{"a.txt", "dir", "with space"}, wantType: []string{"blob", "tree", "blob"}},
+		{name: "recursive", treeish: "HEAD", opts: LsTreeOptions{Recursive: true}, want: []string{"a.txt", "dir/b.txt", "dir/sub/c.txt", "with space"}},
+		{name: "recursive under a path", treeish: "HEAD", paths: []string{"dir/sub"}, opts: LsTreeOptions{Recursive: true}, want: []string{"dir/sub/c.txt"}},
+		{name: "directory entry", treeish: "HEAD", paths: []string{"dir"}, want: []string{"dir"}},
+		{name: "directory contents", treeish: "HEAD", paths: []string{"dir/"}, want: []string{"dir/b.txt", "dir/sub"}},
+		{name: "missing path", treeish: "HEAD", paths: []string{"missing"}},
+		{name: "subdirectory", treeish: "HEAD:dir", want: []string{"b.txt", "sub"}},
+		{name: "tree ID", treeish: commit.Tree.ID, want: []string{"a.txt", "dir", "with space"}},
+		{name: "annotated tag", treeish: "v1", want: []string{"a.txt", "dir", "with space"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := LsTree(repo, test.treeish, test.paths, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names, types []string
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+				types = append(types, entry.Type)
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("entries %q, want %q", names, test.want)
+			}
+			if test.wantType != nil && !reflect.DeepEqual(types, test.wantType) {
+				t.Errorf("types %q, want %q", types, test.wantType)
+			}
+		})
+	}
+
+	if _, err := LsTree(repo, "HEAD:a.txt", nil, LsTreeOptions{}); err == nil {
+		t.Error("listing a blob succeeded")
+	}
+}
@@ -0,0 +1,206 @@
+package file_operations
+
+import (
+	"GitX/internal/diff"
+	"GitX/internal/hash"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+	"strings"
+)
+
+// MergeResult describes what MergeHandler did.
+type MergeResult struct {
+	Commit      *models.Commit // Commit the current branch points to after the merge
+	Base        string         // Best common ancestor of the two commits, empty for unrelated histories
+	FastForward bool           // The branch was moved forward without creating a merge commit
+	UpToDate    bool           // The merged commit was already part of the current branch
+}
+
+// MergeOptions configures MergeHandler.
+type MergeOptions struct {
+	// NoVerify skips the pre-merge hook.
+	NoVerify bool
+}
+
+// MergeHandler merges the commit named by rev into the current branch. The merge base comes
+// from the commit-graph. When the current branch is behind, it is fast-forwarded; otherwise
+// the changes of both sides since the merge base are combined file by file and line by line,
+// and a merge commit with both parents is created. Nothing is changed if the merge has
+// conflicts, if the INDEX has staged changes, or if local changes are in the way; the error
+// then wraps models.ErrConflict. The pre-merge hook runs before a merge commit is made, and
+// the working tree and INDEX are restored if it rejects the merge.
+func MergeHandler(repo *models.Repository, rev string, opts MergeOptions) (*MergeResult, error) {
+	branch, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return nil, err
+	}
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	theirsID, err := vcs_operations.ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := vcs_operations.GetCommitByHash(repo, theirsID)
+	if err != nil {
+		return nil, err
+	}
+	theirsFiles := treeFiles(theirs.Tree)
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{}
+	if headID != "" {
+		if result.Base, err = vcs_operations.MergeBase(repo, headID, theirsID); err != nil {
+			return nil, fmt.Errorf("error finding merge base: %w", err)
+		}
+	}
+
+	switch {
+	case headID != "" && result.Base == theirsID:
+		result.UpToDate = true
+		result.Commit, err = vcs_operations.GetCommitByHash(repo, headID)
+		return result, err
+
+	case headID == "" || result.Base == headID:
+		if err := updateWorkTree(repo, headFiles, theirsFiles, "merge"); err != nil {
+			return nil, err
+		}
+		if err := vcs_operations.CreateBranchRef(repo, branch, theirsID); err != nil {
+			return nil, fmt.Errorf("error updating branch ref file: %w", err)
+		}
+		if err := vcs_operations.AppendReflog(repo, "refs/heads/"+branch, headID, theirsID, "merge "+rev+": Fast-forward"); err != nil {
+			return nil, err
+		}
+		result.FastForward = true
+		result.Commit = theirs
+		return result, nil
+	}
+
+	// A merge commit is made from the INDEX, which must not hold anything else
+	if err := checkNothingStaged(repo, headFiles, "merge"); err != nil {
+		return nil, err
+	}
+
+	baseFiles := make(map[string]string)
+	if result.Base != "" {
+		if baseFiles, err = revisionFiles(repo, result.Base); err != nil {
+			return nil, err
+		}
+	}
+	merged, conflicts, err := mergeTrees(repo, baseFiles, headFiles, theirsFiles, "HEAD", rev)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: merging %s would leave conflicts in:\n\t%s\nnothing was changed", models.ErrConflict, rev, strings.Join(sortedKeys(conflicts), "\n\t"))
+	}
+
+	if err := updateWorkTree(repo, headFiles, merged, "merge"); err != nil {
+		return nil, err
+	}
+	if !opts.NoVerify {
+		if err := runHook(repo, HookPreMerge, nil, fmt.Sprintf("%s %s %s\n", headID, theirsID, rev)); err != nil {
+			if restoreErr := updateWorkTree(repo, merged, headFiles, "merge"); restoreErr != nil {
+				return nil, fmt.Errorf("%w; error restoring working tree: %v", err, restoreErr)
+			}
+			return nil, err
+		}
+	}
+	tree, err := vcs_operations.CreateTreeFromIndex(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tree from INDEX: %w", err)
+	}
+
+	message := fmt.Sprintf("Merge commit '%s'", rev)
+	if _, err := vcs_operations.ReadBranchRef(repo, rev); err == nil {
+		message = fmt.Sprintf("Merge branch '%s'", rev)
+	}
+	if result.Commit, err = newCommit(repo, tree, []*models.Commit{{ID: headID}, {ID: theirsID}}, message); err != nil {
+		return nil, err
+	}
+	if err := writeCommit(repo, branch, headID, result.Commit, "merge "+rev, false); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkNothingStaged returns an error wrapping models.ErrConflict if the INDEX differs from
+// headFiles, since operations that commit from the INDEX would take the staged changes along.
+func checkNothingStaged(repo *models.Repository, headFiles map[string]string, operation string) error {
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	staged := len(entries) != len(headFiles)
+	for _, entry := range entries {
+		staged = staged || headFiles[entry.Path] != entry.Hash
+	}
+	if staged {
+		return fmt.Errorf("%w: your index has staged changes; commit or stash them before you %s", models.ErrConflict, operation)
+	}
+	return nil
+}
+
+// mergeTrees combines the changes made to the base files on both sides, all mapping paths to
+// blob IDs. Files changed on one side only take that side's version; files changed on both
+// are merged line by line and the result is stored as a new blob. Files that could not be
+// merged keep our version in the result, and are returned separately with the content to
+// leave in the working tree: the file with conflict markers, or the modified version when the
+// other side deleted it.
+func mergeTrees(repo *models.Repository, base, ours, theirs map[string]string, oursLabel, theirsLabel string) (map[string]string, map[string][]byte, error) {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]string{base, ours, theirs} {
+		for filePath := range files {
+			paths[filePath] = true
+		}
+	}
+
+	merged := make(map[string]string)
+	conflicts := make(map[string][]byte)
+	for _, filePath := range sortedKeys(paths) {
+		baseID, oursID, theirsID := base[filePath], ours[filePath], theirs[filePath]
+		id := oursID
+		switch {
+		case oursID == theirsID, baseID == theirsID:
+		case baseID == oursID:
+			id = theirsID
+		case oursID == "" || theirsID == "":
+			// Deleted on one side and modified on the other
+			content, err := vcs_operations.ReadBlob(repo, oursID+theirsID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error reading %s: %w", filePath, err)
+			}
+			conflicts[filePath] = content
+		default:
+			var contents [3][]string
+			for i, blobID := range []string{baseID, oursID, theirsID} {
+				if blobID == "" {
+					continue
+				}
+				content, err := vcs_operations.ReadBlob(repo, blobID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("error reading %s: %w", filePath, err)
+				}
+				contents[i] = diff.SplitLines(content)
+			}
+			lines, conflicted := diff.Merge(contents[0], contents[1], contents[2], oursLabel, theirsLabel)
+			if conflicted {
+				conflicts[filePath] = []byte(strings.Join(lines, ""))
+				break
+			}
+			var err error
+			if id, err = hash.StoreBlob(repo.Hash(), repo.Store, []byte(strings.Join(lines, ""))); err != nil {
+				return nil, nil, fmt.Errorf("error storing blob for %s: %w", filePath, err)
+			}
+		}
+		if id != "" {
+			merged[filePath] = id
+		}
+	}
+	return merged, conflicts, nil
+}
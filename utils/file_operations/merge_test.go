@@ -0,0 +1,104 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"errors"
+	"testing"
+)
+
+// switchBranch checks out the branch, creating it at HEAD with create.
+func switchBranch(t *testing.T, repo *models.Repository, name string, create bool) {
+	t.Helper()
+	if err := SwitchHandler(repo, name, create, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeFastForward(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "topic", map[string]string{"b.txt": "b\n"})
+	switchBranch(t, repo, "main", false)
+
+	result, err := MergeHandler(repo, "topic", MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.FastForward || result.Commit.ID != topic.ID {
+		t.Errorf("merge result %+v, want a fast-forward to %s", result, topic.ID)
+	}
+	if got := readFile(t, repo, "b.txt"); got != "b\n" {
+		t.Errorf("b.txt is %q after the fast-forward, want %q", got, "b\n")
+	}
+
+	if result, err = MergeHandler(repo, "topic", MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !result.UpToDate {
+		t.Errorf("merging again gives %+v, want up to date", result)
+	}
+}
+
+func TestMergeCommit(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n4\n5\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "topic", map[string]string{"a.txt": "1\n2\n3\n4\nfive\n", "b.txt": "b\n"})
+	switchBranch(t, repo, "main", false)
+	main := commitFiles(t, repo, "main", map[string]string{"a.txt": "one\n2\n3\n4\n5\n"})
+
+	result, err := MergeHandler(repo, "topic", MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := result.Commit
+	if len(commit.Parent) != 2 || commit.Parent[0].ID != main.ID || commit.Parent[1].ID != topic.ID {
+		t.Errorf("merge commit has parents %v, want %s and %s", commit.Parent, main.ID, topic.ID)
+	}
+	if commit.Message != "Merge branch 'topic'" {
+		t.Errorf("merge commit message %q, want %q", commit.Message, "Merge branch 'topic'")
+	}
+	if got := readFile(t, repo, "a.txt"); got != "one\n2\n3\n4\nfive\n" {
+		t.Errorf("a.txt is %q, want both changes", got)
+	}
+	if id := headID(t, repo); id != commit.ID {
+		t.Errorf("HEAD = %s, want the merge commit %s", id, commit.ID)
+	}
+	if status, err := GetStatus(repo); err != nil {
+		t.Fatal(err)
+	} else if len(status.Files) > 0 {
+		t.Errorf("merge left changes: %+v", status.Files)
+	}
+}
+
+func TestMergeRefused(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+	switchBranch(t, repo, "topic", true)
+	commitFiles(t, repo, "topic", map[string]string{"a.txt": "1\ntopic\n3\n"})
+	switchBranch(t, repo, "main", false)
+	main := commitFiles(t, repo, "main", map[string]string{"a.txt": "1\nmain\n3\n"})
+
+	// Conflicting changes leave everything as it was
+	if _, err := MergeHandler(repo, "topic", MergeOptions{}); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("merging conflicting changes: %v, want %v", err, models.ErrConflict)
+	}
+	if got := readFile(t, repo, "a.txt"); got != "1\nmain\n3\n" {
+		t.Errorf("a.txt is %q after a refused merge, want it unchanged", got)
+	}
+	if id := headID(t, repo); id != main.ID {
+		t.Errorf("HEAD = %s after a refused merge, want %s", id, main.ID)
+	}
+
+	// So do staged changes, which the merge commit would take along
+	switchBranch(t, repo, "other", true)
+	commitFiles(t, repo, "other", map[string]string{"b.txt": "b\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "main again", map[string]string{"c.txt": "c\n"})
+	writeFile(t, repo, "d.txt", "d\n")
+	addFile(t, repo, "d.txt")
+	if _, err := MergeHandler(repo, "other", MergeOptions{}); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("merging with staged changes: %v, want %v", err, models.ErrConflict)
+	}
+}
@@ -0,0 +1,145 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// MoveHandler moves or renames tracked files and directories in both the working tree and the
+// INDEX. With several sources, the destination must be an existing directory. The moved files
+// keep their blob IDs, so the move shows up as a rename when the snapshots are compared.
+// An existing destination file is only overwritten when force is set. Every move is checked
+// before any is made, and the moves already made are undone if one fails, so either all
+// sources are moved or none is.
+func MoveHandler(repo *models.Repository, sources []string, destination string, force bool) error {
+	target, err := repo.RelPath(destination)
+	if err != nil {
+		return err
+	}
+	targetIsDir := false
+	if info, err := repo.WorkTree.Stat(target); err == nil && info.IsDir() {
+		targetIsDir = true
+	}
+	if len(sources) > 1 && !targetIsDir {
+		return fmt.Errorf("destination '%s' is not a directory", target)
+	}
+
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+
+	var moves []fileMove
+	for _, source := range sources {
+		src, err := repo.RelPath(source)
+		if err != nil {
+			return err
+		}
+		dst := target
+		if targetIsDir {
+			dst = path.Join(target, path.Base(src))
+		}
+		for _, other := range moves {
+			switch {
+			case below(src, other.src) || below(other.src, src):
+				return fmt.Errorf("source is moved twice, source=%s, destination=%s", src, dst)
+			case dst == other.dst:
+				return fmt.Errorf("multiple sources for the same target, source=%s, destination=%s", src, dst)
+			}
+		}
+		if err := checkMove(repo, entries, src, dst, force); err != nil {
+			return err
+		}
+		moves = append(moves, fileMove{src: src, dst: dst})
+	}
+
+	for i, m := range moves {
+		if err := renameInWorkTree(repo, m.src, m.dst); err != nil {
+			undoMoves(repo, moves[:i])
+			return err
+		}
+		entries = moveEntries(entries, m.src, m.dst)
+	}
+
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		undoMoves(repo, moves)
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
+
+// fileMove is a rename of src to dst, both relative to the root of the working tree.
+type fileMove struct {
+	src, dst string
+}
+
+// below reports whether the slash-separated name is dir or a path inside it.
+func below(name, dir string) bool {
+	return name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// checkMove returns an error if src cannot be moved to dst, without changing anything.
+func checkMove(repo *models.Repository, entries []*models.IndexEntry, src, dst string, force bool) error {
+	tracked := false
+	for _, entry := range entries {
+		tracked = tracked || below(entry.Path, src)
+	}
+	switch {
+	case src == "." || !tracked:
+		return fmt.Errorf("not under version control, source=%s, destination=%s", src, dst)
+	case below(dst, src):
+		return fmt.Errorf("can not move directory into itself, source=%s, destination=%s", src, dst)
+	}
+
+	if _, err := repo.WorkTree.Stat(src); err != nil {
+		return fmt.Errorf("bad source, source=%s, destination=%s", src, dst)
+	}
+	if info, err := repo.WorkTree.Stat(dst); err == nil {
+		if !force || info.IsDir() {
+			return fmt.Errorf("destination exists, source=%s, destination=%s", src, dst)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// renameInWorkTree renames src to dst in the working tree, replacing a file at dst.
+func renameInWorkTree(repo *models.Repository, src, dst string) error {
+	if err := repo.WorkTree.MkdirAll(path.Dir(dst), fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory for %s: %w", dst, err)
+	}
+	if err := repo.WorkTree.Rename(src, dst); err != nil {
+		return fmt.Errorf("renaming '%s' failed: %w", src, err)
+	}
+	return nil
+}
+
+// undoMoves renames the moved files back, latest first. Files that were overwritten cannot be
+// brought back.
+func undoMoves(repo *models.Repository, moves []fileMove) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		_ = repo.WorkTree.Rename(moves[i].dst, moves[i].src)
+	}
+}
+
+// moveEntries returns the index entries with the paths below src moved below dst. Entries
+// overwritten by the move are dropped, the moved ones keep their blob IDs.
+func moveEntries(entries []*models.IndexEntry, src, dst string) []*models.IndexEntry {
+	moved := entries[:0]
+	for _, entry := range entries {
+		switch {
+		case below(entry.Path, src):
+			entry.Path = dst + strings.TrimPrefix(entry.Path, src)
+		case below(entry.Path, dst):
+			continue
+		}
+		moved = append(moved, entry)
+	}
+	return moved
+}
@@ -0,0 +1,105 @@
+package file_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		sources     []string
+		destination string
+		force       bool
+		err         bool
+		moved       map[string]string // Destination of each moved file, by source
+	}{
+		{
+			name:        "rename",
+			sources:     []string{"/a.txt"},
+			destination: "/renamed.txt",
+			moved:       map[string]string{"a.txt": "renamed.txt"},
+		},
+		{
+			name:        "into a directory",
+			sources:     []string{"/a.txt", "/b.txt"},
+			destination: "/dir",
+			moved:       map[string]string{"a.txt": "dir/a.txt", "b.txt": "dir/b.txt"},
+		},
+		{
+			name:        "directory",
+			sources:     []string{"/dir"},
+			destination: "/other",
+			moved:       map[string]string{"dir/c.txt": "other/c.txt"},
+		},
+		{
+			name:        "existing destination",
+			sources:     []string{"/a.txt"},
+			destination: "/b.txt",
+			err:         true,
+		},
+		{
+			name:        "forced over an existing destination",
+			sources:     []string{"/a.txt"},
+			destination: "/b.txt",
+			force:       true,
+			moved:       map[string]string{"a.txt": "b.txt"},
+		},
+		{
+			name:        "one untracked source moves nothing",
+			sources:     []string{"/a.txt", "/untracked.txt"},
+			destination: "/dir",
+			err:         true,
+		},
+		{
+			name:        "directory into itself",
+			sources:     []string{"/dir"},
+			destination: "/dir/sub",
+			err:         true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			files := map[string]string{"a.txt": "a\n", "b.txt": "b\n", "dir/c.txt": "c\n"}
+			commitFiles(t, repo, "base", files)
+			writeFile(t, repo, "untracked.txt", "u\n")
+			before := indexHashes(t, repo)
+
+			err := MoveHandler(repo, test.sources, test.destination, test.force)
+			if test.err {
+				if err == nil {
+					t.Fatal("MoveHandler succeeds")
+				}
+				if !reflect.DeepEqual(indexHashes(t, repo), before) {
+					t.Error("a refused move changes the INDEX")
+				}
+				for name, content := range files {
+					if got := readFile(t, repo, name); got != content {
+						t.Errorf("%s = %q after a refused move, want %q", name, got, content)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			index := indexHashes(t, repo)
+			for src, dst := range test.moved {
+				if _, staged := index[src]; staged {
+					t.Errorf("%s is still staged", src)
+				}
+				if index[dst] != before[src] {
+					t.Errorf("%s is staged as %q, want the blob of %s %q", dst, index[dst], src, before[src])
+				}
+				if got := readFile(t, repo, dst); got != files[src] {
+					t.Errorf("%s = %q, want %q", dst, got, files[src])
+				}
+				if readFile(t, repo, src) != "" {
+					t.Errorf("%s is still in the working tree", src)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pathspec selects paths in the working tree. A literal pathspec matches the path itself and
+// everything below it; one containing glob characters is matched against the full path, with
+// "*" also matching across directories like Git does.
+type pathspec struct {
+	spec string
+	re   *regexp.Regexp
+}
+
+// newPathspec compiles a slash-separated pathspec relative to the repository root.
+func newPathspec(spec string) (*pathspec, error) {
+	p := &pathspec{spec: spec}
+	if !strings.ContainsAny(spec, "*?[") {
+		return p, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(spec); i++ {
+		switch c := spec[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(spec[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := spec[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("(/.*)?$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+	return p, nil
+}
+
+// literal reports whether the pathspec names a single path instead of a pattern.
+func (p *pathspec) literal() bool {
+	return p.re == nil
+}
+
+// match reports whether the slash-separated path name is selected by the pathspec.
+func (p *pathspec) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	return p.spec == "." || name == p.spec || strings.HasPrefix(name, p.spec+"/")
+}
+
+// compilePathspecs compiles the pathspecs given as file system paths.
+func compilePathspecs(repo *models.Repository, paths []string) ([]*pathspec, error) {
+	specs := make([]*pathspec, len(paths))
+	for i, filePath := range paths {
+		relPath, err := repo.RelPath(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if specs[i], err = newPathspec(relPath); err != nil {
+			return nil, fmt.Errorf("invalid pathspec '%s': %w", filePath, err)
+		}
+	}
+	return specs, nil
+}
+
+// matchesAny reports whether the slash-separated path name is selected by one of the pathspecs.
+func matchesAny(specs []*pathspec, name string) bool {
+	for _, spec := range specs {
+		if spec.match(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPathspecMatch(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+		want bool
+	}{
+		{"a.txt", "a.txt", true},
+		{"a.txt", "b.txt", false},
+		{".", "src/main.go", true},
+		{"src", "src/main.go", true},
+		{"src", "src/lib/util.go", true},
+		{"src", "srcs/main.go", false},
+		{"src/main.go", "src", false},
+		{"*.go", "main.go", true},
+		{"*.go", "src/lib/util.go", true},
+		{"*.go", "main.go.orig", false},
+		{"src/*.go", "src/lib/util.go", true},
+		{"src/*.go", "test/main.go", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+		{"file?.txt", "file/.txt", false},
+		{"[ab].txt", "a.txt", true},
+		{"[ab].txt", "c.txt", false},
+		{"[!ab].txt", "c.txt", true},
+		{"[!ab].txt", "a.txt", false},
+		{"[a-c]*", "b/deep/file", true},
+		{"doc*", "docs/readme.md", true},
+		{"a.b", "axb", false},
+		{"[unclosed", "[unclosed", true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec+" "+test.name, func(t *testing.T) {
+			spec, err := newPathspec(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.match(test.name); got != test.want {
+				t.Errorf("match(%q) = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAddPathspecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"file", []string{"/a.txt"}, []string{"a.txt"}},
+		{"directory", []string{"/src"}, []string{"src/lib/util.go", "src/main.go"}},
+		{"glob across directories", []string{"/*.go"}, []string{"src/lib/util.go", "src/main.go"}},
+		{"several", []string{"/a.txt", "/src/lib"}, []string{"a.txt", "src/lib/util.go"}},
+		{"everything", []string{"/"}, []string{".gitxignore", "a.txt", "b.md", "src/lib/util.go", "src/main.go"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			for _, name := range []string{"a.txt", "b.md", "src/main.go", "src/lib/util.go"} {
+				writeFile(t, repo, name, name+"\n")
+			}
+			changes, err := AddPathspecs(repo, test.paths, AddOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var added []string
+			for _, change := range changes {
+				added = append(added, change.Path)
+			}
+			if !reflect.DeepEqual(added, test.want) {
+				t.Errorf("added %q, want %q", added, test.want)
+			}
+		})
+	}
+}
+
+func TestAddPathspecsUnmatched(t *testing.T) {
+	repo := newTestRepo(t)
+	writeFile(t, repo, "a.txt", "a\n")
+	if _, err := AddPathspecs(repo, []string{"/*.go"}, AddOptions{}); err == nil {
+		t.Error("adding a pathspec that matches nothing succeeded")
+	}
+}
+
+func TestAddOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		opts  AddOptions
+		want  []AddChange
+	}{
+		{"all", nil, AddOptions{All: true}, []AddChange{{Path: ".gitxignore"}, {Path: "a.txt"}, {Path: "b.txt", Removed: true}, {Path: "c.txt"}}},
+		{"update", nil, AddOptions{Update: true}, []AddChange{{Path: "a.txt"}, {Path: "b.txt", Removed: true}}},
+		{"deletions need all or update", []string{"/"}, AddOptions{}, []AddChange{{Path: ".gitxignore"}, {Path: "a.txt"}, {Path: "c.txt"}}},
+		{"force", []string{"/d.log"}, AddOptions{Force: true}, []AddChange{{Path: "d.log"}}},
+		{"dry run", nil, AddOptions{All: true, DryRun: true}, []AddChange{{Path: ".gitxignore"}, {Path: "a.txt"}, {Path: "b.txt", Removed: true}, {Path: "c.txt"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			writeFile(t, repo, "a.txt", "a\n")
+			writeFile(t, repo, "b.txt", "b\n")
+			if _, err := AddPathspecs(repo, []string{"/a.txt", "/b.txt"}, AddOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, repo, "a.txt", "changed\n")
+			if err := repo.WorkTree.Remove("b.txt"); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, repo, "c.txt", "c\n")
+			writeFile(t, repo, IgnoreFileName, "*.log\n")
+			writeFile(t, repo, "d.log", "d\n")
+			index, err := repo.Store.ReadFile("INDEX")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes, err := AddPathspecs(repo, test.paths, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, test.want) {
+				t.Errorf("changes = %v, want %v", changes, test.want)
+			}
+			after, err := repo.Store.ReadFile("INDEX")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.opts.DryRun && string(after) != string(index) {
+				t.Error("a dry run changes the INDEX")
+			}
+		})
+	}
+}
+
+func TestAddIgnored(t *testing.T) {
+	repo := newTestRepo(t)
+	writeFile(t, repo, IgnoreFileName, "*.log\n")
+	writeFile(t, repo, "d.log", "d\n")
+	if _, err := AddPathspecs(repo, []string{"/d.log"}, AddOptions{}); !errors.Is(err, models.ErrIgnored) {
+		t.Errorf("adding an ignored file returns %v, want ErrIgnored", err)
+	}
+	if _, err := AddPathspecs(repo, nil, AddOptions{}); err == nil {
+		t.Error("adding without a pathspec, -A or -u succeeds")
+	}
+}
@@ -0,0 +1,732 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// rebaseDir holds the state of a rebase in progress: state.json, the todo list of the
+// instructions left to execute, and the done list of those already executed.
+const rebaseDir = "rebase-merge"
+
+// rebaseCommands maps the commands of the todo list, and their abbreviations, to their names.
+var rebaseCommands = map[string]string{
+	"pick": "pick", "p": "pick",
+	"reword": "reword", "r": "reword",
+	"edit": "edit", "e": "edit",
+	"squash": "squash", "s": "squash",
+	"fixup": "fixup", "f": "fixup",
+	"drop": "drop", "d": "drop",
+	"exec": "exec", "x": "exec",
+}
+
+// rebaseTodoHelp ends the todo list opened in the editor.
+const rebaseTodoHelp = `#
+# Commands:
+# p, pick <commit> = use commit
+# r, reword <commit> = use commit, but edit the commit message
+# e, edit <commit> = use commit, but stop for amending
+# s, squash <commit> = use commit, but meld into previous commit
+# f, fixup <commit> = like "squash", but discard this commit's message
+# x, exec <command> = run command (the rest of the line) using shell
+# d, drop <commit> = remove commit
+#
+# These lines can be re-ordered; they are executed from top to bottom.
+# If you remove a line here THAT COMMIT WILL BE LOST.
+# However, if you remove everything, the rebase will be aborted.
+`
+
+// RebaseOptions configures Rebase and the functions that resume it.
+type RebaseOptions struct {
+	// Onto is the commit the commits are replayed on, instead of the upstream.
+	Onto string
+	// Interactive lets the user edit the todo list with Edit before the rebase starts.
+	Interactive bool
+	// Autosquash moves each "fixup! " and "squash! " commit after the commit whose subject it
+	// names, and marks it to be fixed up or squashed into that commit.
+	Autosquash bool
+	// Edit lets the user edit the todo list and the messages of reworded and squashed commits.
+	// It is passed the name and initial content of the file and returns the edited content.
+	// Messages are kept as they are if it is nil.
+	Edit func(name string, content []byte) ([]byte, error)
+	// Stdout and Stderr receive the output of the commands of exec instructions, which is
+	// discarded if they are nil.
+	Stdout, Stderr io.Writer
+}
+
+// RebaseResult describes where a rebase got to.
+type RebaseResult struct {
+	Head     string // Commit the branch points to
+	UpToDate bool   // The branch was already based on the upstream, so nothing was done
+	// Stopped is the instruction of the todo list the rebase stopped at, empty once it has
+	// completed. A rebase stops when a commit conflicts, at edit instructions and when the
+	// command of an exec instruction fails.
+	Stopped   string
+	Conflicts []string // Paths left with conflict markers in the working tree, sorted
+}
+
+// rebaseState is the state of a rebase in progress.
+type rebaseState struct {
+	Branch   string `json:"branch"`
+	Onto     string `json:"onto"`
+	OrigHead string `json:"origHead"` // Commit the branch pointed to before the rebase
+	// Current is the instruction whose commit conflicted or could not be made, which
+	// continuing commits from the INDEX
+	Current   string   `json:"current,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// rebaseStep is an instruction of the todo list.
+type rebaseStep struct {
+	Command string
+	Commit  *models.Commit // Commit the instruction applies to, nil for exec
+	Exec    string         // Command of an exec instruction
+}
+
+// String formats the instruction as a line of the todo list.
+func (s *rebaseStep) String() string {
+	if s.Command == "exec" {
+		return "exec " + s.Exec
+	}
+	subject, _, _ := strings.Cut(s.Commit.Message, "\n")
+	return fmt.Sprintf("%s %s %s", s.Command, s.Commit.ID[:7], subject)
+}
+
+// parseRebaseTodo parses a todo list. Blank lines and lines starting with '#' are ignored.
+func parseRebaseTodo(repo *models.Repository, text string) ([]*rebaseStep, error) {
+	var steps []*rebaseStep
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, rest, _ := strings.Cut(line, " ")
+		command, ok := rebaseCommands[word]
+		if !ok {
+			return nil, fmt.Errorf("line %d of the todo list: unknown command '%s'", number+1, word)
+		}
+		step := &rebaseStep{Command: command}
+		rest = strings.TrimSpace(rest)
+		if command == "exec" {
+			if rest == "" {
+				return nil, fmt.Errorf("line %d of the todo list: missing command to execute", number+1)
+			}
+			step.Exec = rest
+			steps = append(steps, step)
+			continue
+		}
+
+		rev, _, _ := strings.Cut(rest, " ")
+		if rev == "" {
+			return nil, fmt.Errorf("line %d of the todo list: missing commit", number+1)
+		}
+		id, err := vcs_operations.ResolveRevision(repo, rev)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of the todo list: %w", number+1, err)
+		}
+		if step.Commit, err = vcs_operations.GetCommitByHash(repo, id); err != nil {
+			return nil, err
+		}
+		if len(step.Commit.Parent) > 1 {
+			return nil, fmt.Errorf("line %d of the todo list: %s is a merge commit, which cannot be replayed", number+1, rev)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// formatRebaseTodo formats the instructions as a todo list.
+func formatRebaseTodo(steps []*rebaseStep) string {
+	var todo strings.Builder
+	for _, step := range steps {
+		todo.WriteString(step.String() + "\n")
+	}
+	return todo.String()
+}
+
+// autosquash moves each "fixup! " or "squash! " commit after the commit whose subject it names,
+// after any other fixups of that commit, turning it into a fixup or squash instruction.
+func autosquash(steps []*rebaseStep) []*rebaseStep {
+	type fix struct {
+		step   *rebaseStep
+		target string
+	}
+	var fixes []fix
+	var rest []*rebaseStep
+	for _, step := range steps {
+		subject, _, _ := strings.Cut(step.Commit.Message, "\n")
+		if target, ok := strings.CutPrefix(subject, "fixup! "); ok {
+			fixes = append(fixes, fix{&rebaseStep{Command: "fixup", Commit: step.Commit}, target})
+		} else if target, ok := strings.CutPrefix(subject, "squash! "); ok {
+			fixes = append(fixes, fix{&rebaseStep{Command: "squash", Commit: step.Commit}, target})
+		} else {
+			rest = append(rest, step)
+		}
+	}
+
+	var result []*rebaseStep
+	placed := make([]bool, len(fixes))
+	for _, step := range rest {
+		result = append(result, step)
+		subject, _, _ := strings.Cut(step.Commit.Message, "\n")
+		for i, fix := range fixes {
+			if !placed[i] && fix.target == subject {
+				result = append(result, fix.step)
+				placed[i] = true
+			}
+		}
+	}
+	// Fixups whose commit is not being rebased are picked where they were
+	for i, fix := range fixes {
+		if !placed[i] {
+			result = append(result, &rebaseStep{Command: "pick", Commit: fix.step.Commit})
+		}
+	}
+	return result
+}
+
+// loadRebase reads the state of the rebase in progress.
+func loadRebase(repo *models.Repository) (*rebaseState, error) {
+	data, err := repo.Store.ReadFile(path.Join(rebaseDir, "state.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.New("no rebase in progress")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading rebase state: %w", err)
+	}
+	var state rebaseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing rebase state: %w", err)
+	}
+	return &state, nil
+}
+
+// saveRebase writes the state of the rebase.
+func saveRebase(repo *models.Repository, state *rebaseState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing rebase state: %w", err)
+	}
+	if err := repo.Store.MkdirAll(rebaseDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating rebase directory: %w", err)
+	}
+	if err := repo.Store.WriteFile(path.Join(rebaseDir, "state.json"), data, 0644); err != nil {
+		return fmt.Errorf("error writing rebase state: %w", err)
+	}
+	return nil
+}
+
+// readRebaseTodo reads the instructions left to execute.
+func readRebaseTodo(repo *models.Repository) ([]*rebaseStep, error) {
+	data, err := repo.Store.ReadFile(path.Join(rebaseDir, "todo"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading rebase todo list: %w", err)
+	}
+	return parseRebaseTodo(repo, string(data))
+}
+
+// writeRebaseTodo replaces the instructions left to execute.
+func writeRebaseTodo(repo *models.Repository, steps []*rebaseStep) error {
+	if err := repo.Store.WriteFile(path.Join(rebaseDir, "todo"), []byte(formatRebaseTodo(steps)), 0644); err != nil {
+		return fmt.Errorf("error writing rebase todo list: %w", err)
+	}
+	return nil
+}
+
+// Rebase replays the commits of the current branch that upstream does not have on top of
+// upstream, or of opts.Onto, and moves the branch to the result. Merge commits are left out.
+// When upstream is empty, the upstream of the branch is used. The working tree must be clean.
+// Commits are replayed like in CherryPick: each keeps its author and message, and a commit
+// whose changes are already there is dropped. When the rebase stops, it is resumed with
+// ContinueRebase or SkipRebase, or undone with AbortRebase.
+func Rebase(repo *models.Repository, upstream string, opts RebaseOptions) (*RebaseResult, error) {
+	if fsys.Exists(repo.Store, rebaseDir) {
+		return nil, errors.New("a rebase is already in progress; use --continue, --skip or --abort")
+	}
+	if fsys.Exists(repo.Store, sequencerDir) {
+		return nil, errors.New("a cherry-pick or revert is in progress; finish or abort it first")
+	}
+	branch, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return nil, err
+	}
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	if headID == "" {
+		return nil, fmt.Errorf("branch '%s' has no commits to rebase", branch)
+	}
+
+	if upstream == "" {
+		config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+		if err != nil {
+			return nil, fmt.Errorf("error loading config: %w", err)
+		}
+		if upstream = strings.TrimPrefix(config.Branches[branch].Merge, "refs/heads/"); upstream == "" {
+			return nil, fmt.Errorf("branch '%s' has no upstream; name the branch to rebase onto", branch)
+		}
+	}
+	upstreamID, err := vcs_operations.ResolveRevision(repo, upstream)
+	if err != nil {
+		return nil, err
+	}
+	ontoID := upstreamID
+	if opts.Onto != "" {
+		if ontoID, err = vcs_operations.ResolveRevision(repo, opts.Onto); err != nil {
+			return nil, err
+		}
+	}
+
+	status, err := GetStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Files) > 0 {
+		return nil, fmt.Errorf("%w: you have uncommitted changes; commit or stash them before you rebase", models.ErrConflict)
+	}
+
+	if !opts.Interactive {
+		base, err := vcs_operations.MergeBase(repo, upstreamID, headID)
+		if err != nil {
+			return nil, fmt.Errorf("error finding merge base: %w", err)
+		}
+		if base == ontoID {
+			return &RebaseResult{Head: headID, UpToDate: true}, nil
+		}
+	}
+
+	ids, err := vcs_operations.CommitsBetween(repo, upstreamID, headID)
+	if err != nil {
+		return nil, err
+	}
+	var steps []*rebaseStep
+	for _, id := range ids {
+		commit, err := vcs_operations.GetCommitByHash(repo, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(commit.Parent) <= 1 {
+			steps = append(steps, &rebaseStep{Command: "pick", Commit: commit})
+		}
+	}
+	if opts.Autosquash {
+		steps = autosquash(steps)
+	}
+
+	if opts.Interactive && opts.Edit != nil {
+		todo := formatRebaseTodo(steps) + fmt.Sprintf("\n# Rebase %s..%s onto %s (%d commands)\n",
+			upstreamID[:7], headID[:7], ontoID[:7], len(steps)) + rebaseTodoHelp
+		edited, err := opts.Edit("gitx-rebase-todo", []byte(todo))
+		if err != nil {
+			return nil, err
+		}
+		if steps, err = parseRebaseTodo(repo, string(edited)); err != nil {
+			return nil, err
+		}
+		if len(steps) == 0 {
+			return nil, errors.New("nothing to do")
+		}
+		if command := steps[0].Command; command == "squash" || command == "fixup" {
+			return nil, fmt.Errorf("cannot '%s' without a previous commit", command)
+		}
+	}
+
+	state := &rebaseState{Branch: branch, Onto: ontoID, OrigHead: headID}
+	if err := saveRebase(repo, state); err != nil {
+		return nil, err
+	}
+	if err := writeRebaseTodo(repo, steps); err != nil {
+		return nil, err
+	}
+
+	// The branch is moved to the new base, and the commits are replayed on top of it
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	ontoFiles, err := revisionFiles(repo, ontoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateWorkTree(repo, headFiles, ontoFiles, "rebase"); err != nil {
+		if removeErr := repo.Store.RemoveAll(rebaseDir); removeErr != nil {
+			return nil, fmt.Errorf("error removing rebase state: %w", removeErr)
+		}
+		return nil, err
+	}
+	if err := vcs_operations.CreateBranchRef(repo, branch, ontoID); err != nil {
+		return nil, fmt.Errorf("error updating branch ref file: %w", err)
+	}
+	if err := vcs_operations.AppendReflog(repo, "refs/heads/"+branch, headID, ontoID, "rebase (start): checkout "+ontoID[:7]); err != nil {
+		return nil, err
+	}
+	return runRebase(repo, state, opts)
+}
+
+// runRebase executes the instructions of the todo list in order, until one stops the rebase.
+// The rebase state is removed once the list is done.
+func runRebase(repo *models.Repository, state *rebaseState, opts RebaseOptions) (*RebaseResult, error) {
+	for {
+		steps, err := readRebaseTodo(repo)
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) == 0 {
+			break
+		}
+		step := steps[0]
+		if err := writeRebaseTodo(repo, steps[1:]); err != nil {
+			return nil, err
+		}
+		if err := appendRebaseDone(repo, step); err != nil {
+			return nil, err
+		}
+
+		result, err := executeRebaseStep(repo, state, step, opts)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			return result, nil
+		}
+	}
+
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("rebase (finish): refs/heads/%s onto %s", state.Branch, state.Onto)
+	if err := vcs_operations.AppendReflog(repo, "refs/heads/"+state.Branch, state.OrigHead, headID, message); err != nil {
+		return nil, err
+	}
+	if err := repo.Store.RemoveAll(rebaseDir); err != nil {
+		return nil, fmt.Errorf("error removing rebase state: %w", err)
+	}
+	return &RebaseResult{Head: headID}, nil
+}
+
+// appendRebaseDone adds the instruction to the list of those executed.
+func appendRebaseDone(repo *models.Repository, step *rebaseStep) error {
+	donePath := path.Join(rebaseDir, "done")
+	done, err := repo.Store.ReadFile(donePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading rebase done list: %w", err)
+	}
+	if err := repo.Store.WriteFile(donePath, append(done, step.String()+"\n"...), 0644); err != nil {
+		return fmt.Errorf("error writing rebase done list: %w", err)
+	}
+	return nil
+}
+
+// executeRebaseStep executes an instruction. It returns a result if the instruction stops the
+// rebase, with the state saved so that it can be resumed.
+func executeRebaseStep(repo *models.Repository, state *rebaseState, step *rebaseStep, opts RebaseOptions) (*RebaseResult, error) {
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	switch step.Command {
+	case "drop":
+		return nil, nil
+	case "exec":
+		if err := runExec(repo, step.Exec, opts); err != nil {
+			return &RebaseResult{Head: headID, Stopped: step.String()}, nil
+		}
+		return nil, nil
+	}
+
+	// A commit already on top of HEAD is kept as it is, so that its ID does not change
+	commit := step.Commit
+	if (step.Command == "pick" || step.Command == "edit") && len(commit.Parent) == 1 && commit.Parent[0].ID == headID {
+		if err := fastForward(repo, state.Branch, headID, commit, "rebase (fast-forward)"); err != nil {
+			return nil, err
+		}
+	} else {
+		conflicts, err := applyPick(repo, "rebase", commit, 0)
+		if err != nil {
+			// Nothing was changed, so the instruction is put back to be tried again
+			return nil, restoreRebaseStep(repo, step, err)
+		}
+		if len(conflicts) > 0 {
+			state.Current, state.Conflicts = step.String(), conflicts
+			if err := saveRebase(repo, state); err != nil {
+				return nil, err
+			}
+			return &RebaseResult{Head: headID, Stopped: step.String(), Conflicts: conflicts}, nil
+		}
+		if err := commitRebaseStep(repo, step, opts); err != nil {
+			state.Current = step.String()
+			if saveErr := saveRebase(repo, state); saveErr != nil {
+				return nil, saveErr
+			}
+			return nil, err
+		}
+	}
+
+	if step.Command == "edit" {
+		if headID, err = vcs_operations.GetCurrentHeadCommit(repo); err != nil {
+			return nil, err
+		}
+		return &RebaseResult{Head: headID, Stopped: step.String()}, nil
+	}
+	return nil, nil
+}
+
+// restoreRebaseStep puts the instruction back at the start of the todo list and returns err.
+func restoreRebaseStep(repo *models.Repository, step *rebaseStep, err error) error {
+	steps, readErr := readRebaseTodo(repo)
+	if readErr != nil {
+		return readErr
+	}
+	if writeErr := writeRebaseTodo(repo, append([]*rebaseStep{step}, steps...)); writeErr != nil {
+		return writeErr
+	}
+	return fmt.Errorf("could not apply %s: %w", step.Commit.ID[:7], err)
+}
+
+// fastForward moves the branch from headID to the commit, which is a child of it, updating the
+// INDEX and the working tree.
+func fastForward(repo *models.Repository, branch, headID string, commit *models.Commit, action string) error {
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	if err := updateWorkTree(repo, headFiles, treeFiles(commit.Tree), "rebase"); err != nil {
+		return err
+	}
+	if err := vcs_operations.CreateBranchRef(repo, branch, commit.ID); err != nil {
+		return fmt.Errorf("error updating branch ref file: %w", err)
+	}
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	return vcs_operations.AppendReflog(repo, "refs/heads/"+branch, headID, commit.ID, action+": "+subject)
+}
+
+// commitRebaseStep commits the INDEX, which holds the changes of the instruction's commit
+// applied to HEAD, as the instruction says.
+func commitRebaseStep(repo *models.Repository, step *rebaseStep, opts RebaseOptions) error {
+	commit := step.Commit
+	switch step.Command {
+	case "squash", "fixup":
+		return squashRebaseStep(repo, step, opts)
+	case "reword":
+		message := commit.Message
+		if opts.Edit != nil {
+			edited, err := opts.Edit(commitEditMsgFile, []byte(message+"\n"+commitMessageHelp))
+			if err != nil {
+				return err
+			}
+			if message = cleanupMessage(string(edited)); message == "" {
+				return errors.New("aborting commit due to empty commit message")
+			}
+		}
+		_, err := commitPick(repo, "rebase (reword)", commit, message)
+		return err
+	default:
+		_, err := commitPick(repo, "rebase ("+step.Command+")", commit, commit.Message)
+		return err
+	}
+}
+
+// squashRebaseStep melds the INDEX into HEAD, replacing it with a commit that keeps its author.
+// A squash adds the message of the instruction's commit to HEAD's, while a fixup keeps HEAD's.
+func squashRebaseStep(repo *models.Repository, step *rebaseStep, opts RebaseOptions) error {
+	branch, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return err
+	}
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return err
+	}
+	if headID == "" {
+		return fmt.Errorf("cannot %s %s without a previous commit", step.Command, step.Commit.ID[:7])
+	}
+	head, err := vcs_operations.GetCommitByHash(repo, headID)
+	if err != nil {
+		return err
+	}
+	tree, err := vcs_operations.CreateTreeFromIndex(repo)
+	if err != nil {
+		return fmt.Errorf("error creating tree from INDEX: %w", err)
+	}
+
+	message := head.Message
+	if step.Command == "squash" {
+		message += "\n\n" + step.Commit.Message
+		if opts.Edit != nil {
+			template := "# This is a combination of commits.\n" + message + "\n" + commitMessageHelp
+			edited, err := opts.Edit(commitEditMsgFile, []byte(template))
+			if err != nil {
+				return err
+			}
+			if message = cleanupMessage(string(edited)); message == "" {
+				return errors.New("aborting commit due to empty commit message")
+			}
+		}
+	} else if maps.Equal(treeFiles(tree), treeFiles(head.Tree)) {
+		return nil
+	}
+
+	squashed, err := newCommit(repo, tree, head.Parent, message)
+	if err != nil {
+		return err
+	}
+	squashed.Author, squashed.Timestamp = head.Author, head.Timestamp
+	return writeCommit(repo, branch, headID, squashed, "rebase ("+step.Command+")", false)
+}
+
+// runExec runs the command of an exec instruction with the shell, at the root of the working tree.
+func runExec(repo *models.Repository, command string, opts RebaseOptions) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = repo.Directory
+	cmd.Env = append(os.Environ(), "GITX_DIR="+repo.GitxDir, "GITX_WORK_TREE="+repo.Directory)
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	return cmd.Run()
+}
+
+// ContinueRebase resumes the rebase once the conflicts it stopped on are resolved and staged,
+// or once the commit it stopped at for editing is amended. A commit that conflicted is
+// committed from the INDEX first.
+func ContinueRebase(repo *models.Repository, opts RebaseOptions) (*RebaseResult, error) {
+	state, err := loadRebase(repo)
+	if err != nil {
+		return nil, err
+	}
+	if state.Current == "" {
+		headFiles, err := vcs_operations.HeadTreeFiles(repo)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkNothingStaged(repo, headFiles, "continue the rebase"); err != nil {
+			return nil, err
+		}
+		return runRebase(repo, state, opts)
+	}
+
+	unresolved, err := unresolvedConflicts(repo, state.Conflicts)
+	if err != nil {
+		return nil, err
+	}
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf("%w: resolve the conflicts and stage them with \"gitx add\" before continuing:\n\t%s",
+			models.ErrConflict, strings.Join(unresolved, "\n\t"))
+	}
+	steps, err := parseRebaseTodo(repo, state.Current)
+	if err != nil {
+		return nil, err
+	}
+	step := steps[0]
+	if err := commitRebaseStep(repo, step, opts); err != nil {
+		return nil, err
+	}
+	state.Current, state.Conflicts = "", nil
+	if err := saveRebase(repo, state); err != nil {
+		return nil, err
+	}
+	if step.Command == "edit" {
+		headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+		if err != nil {
+			return nil, err
+		}
+		return &RebaseResult{Head: headID, Stopped: step.String()}, nil
+	}
+	return runRebase(repo, state, opts)
+}
+
+// SkipRebase drops the commit the rebase stopped at, resetting the INDEX and the working tree
+// to HEAD, and resumes the rebase.
+func SkipRebase(repo *models.Repository, opts RebaseOptions) (*RebaseResult, error) {
+	state, err := loadRebase(repo)
+	if err != nil {
+		return nil, err
+	}
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := discardPick(repo, headFiles, state.Conflicts); err != nil {
+		return nil, err
+	}
+	state.Current, state.Conflicts = "", nil
+	if err := saveRebase(repo, state); err != nil {
+		return nil, err
+	}
+	return runRebase(repo, state, opts)
+}
+
+// AbortRebase cancels the rebase, moving the branch back to the commit it pointed to before
+// and resetting the INDEX and the working tree to it.
+func AbortRebase(repo *models.Repository) error {
+	state, err := loadRebase(repo)
+	if err != nil {
+		return err
+	}
+	if branch, err := vcs_operations.CurrentBranch(repo); err != nil {
+		return err
+	} else if branch != state.Branch {
+		return fmt.Errorf("the rebase was started on branch '%s'; switch back to it to abort", state.Branch)
+	}
+	origFiles, err := revisionFiles(repo, state.OrigHead)
+	if err != nil {
+		return err
+	}
+	if err := discardPick(repo, origFiles, state.Conflicts); err != nil {
+		return err
+	}
+
+	headID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return err
+	}
+	if err := vcs_operations.CreateBranchRef(repo, state.Branch, state.OrigHead); err != nil {
+		return fmt.Errorf("error updating branch ref file: %w", err)
+	}
+	if err := vcs_operations.AppendReflog(repo, "refs/heads/"+state.Branch, headID, state.OrigHead, "rebase (abort): returning to refs/heads/"+state.Branch); err != nil {
+		return err
+	}
+	if err := repo.Store.RemoveAll(rebaseDir); err != nil {
+		return fmt.Errorf("error removing rebase state: %w", err)
+	}
+	return nil
+}
+
+// EditRebaseTodo lets the user edit the instructions the rebase has left to execute.
+func EditRebaseTodo(repo *models.Repository, opts RebaseOptions) error {
+	if _, err := loadRebase(repo); err != nil {
+		return err
+	}
+	if opts.Edit == nil {
+		return errors.New("no editor to edit the todo list with")
+	}
+	steps, err := readRebaseTodo(repo)
+	if err != nil {
+		return err
+	}
+	edited, err := opts.Edit("gitx-rebase-todo", []byte(formatRebaseTodo(steps)+"\n"+rebaseTodoHelp))
+	if err != nil {
+		return err
+	}
+	if steps, err = parseRebaseTodo(repo, string(edited)); err != nil {
+		return err
+	}
+	return writeRebaseTodo(repo, steps)
+}
@@ -0,0 +1,327 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// branchMessages returns the messages of the first n commits of HEAD's first-parent history,
+// newest first.
+func branchMessages(t *testing.T, repo *models.Repository, n int) []string {
+	t.Helper()
+	var messages []string
+	id := headID(t, repo)
+	for len(messages) < n {
+		commit, err := vcs_operations.GetCommitByHash(repo, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, commit.Message)
+		if len(commit.Parent) == 0 {
+			break
+		}
+		id = commit.Parent[0].ID
+	}
+	return messages
+}
+
+// todoEditor returns an Edit function that replaces the todo list with todo and keeps commit
+// messages as they are.
+func todoEditor(todo string) func(string, []byte) ([]byte, error) {
+	return func(name string, content []byte) ([]byte, error) {
+		if name == "gitx-rebase-todo" {
+			return []byte(todo), nil
+		}
+		return content, nil
+	}
+}
+
+func TestRebase(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	switchBranch(t, repo, "topic", true)
+	commitFiles(t, repo, "topic one", map[string]string{"b.txt": "b\n"})
+	commitFiles(t, repo, "topic two", map[string]string{"c.txt": "c\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "main", map[string]string{"d.txt": "d\n"})
+	switchBranch(t, repo, "topic", false)
+
+	result, err := Rebase(repo, "main", RebaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stopped != "" || result.Head != headID(t, repo) {
+		t.Fatalf("rebase result %+v, want a completed rebase", result)
+	}
+	if got := branchMessages(t, repo, 3); !reflect.DeepEqual(got, []string{"topic two", "topic one", "main"}) {
+		t.Errorf("history %q, want the topic commits on top of main", got)
+	}
+	for name, want := range map[string]string{"a.txt": "a\n", "b.txt": "b\n", "c.txt": "c\n", "d.txt": "d\n"} {
+		if got := readFile(t, repo, name); got != want {
+			t.Errorf("%s is %q, want %q", name, got, want)
+		}
+	}
+	if fsys.Exists(repo.Store, rebaseDir) {
+		t.Error("rebase state is left behind")
+	}
+
+	if result, err = Rebase(repo, "main", RebaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !result.UpToDate {
+		t.Errorf("rebasing again gives %+v, want up to date", result)
+	}
+}
+
+func TestRebaseResume(t *testing.T) {
+	tests := []struct {
+		name   string
+		resume func(t *testing.T, repo *models.Repository)
+		// onMain is the number of commits replayed on main, or -1 if the branch is restored
+		onMain int
+		files  map[string]string
+	}{
+		{
+			name: "continue",
+			resume: func(t *testing.T, repo *models.Repository) {
+				if _, err := ContinueRebase(repo, RebaseOptions{}); !errors.Is(err, models.ErrConflict) {
+					t.Errorf("continuing with unresolved conflicts gives %v, want a conflict", err)
+				}
+				writeFile(t, repo, "a.txt", "1\nresolved\n3\n")
+				addFile(t, repo, "a.txt")
+				result, err := ContinueRebase(repo, RebaseOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Stopped != "" {
+					t.Errorf("rebase stopped again at %q", result.Stopped)
+				}
+			},
+			onMain: 2,
+			files:  map[string]string{"a.txt": "1\nresolved\n3\n", "b.txt": "b\n"},
+		},
+		{
+			name: "skip",
+			resume: func(t *testing.T, repo *models.Repository) {
+				result, err := SkipRebase(repo, RebaseOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Stopped != "" {
+					t.Errorf("rebase stopped again at %q", result.Stopped)
+				}
+			},
+			onMain: 1,
+			files:  map[string]string{"a.txt": "1\nmain\n3\n", "b.txt": "b\n"},
+		},
+		{
+			name: "abort",
+			resume: func(t *testing.T, repo *models.Repository) {
+				if err := AbortRebase(repo); err != nil {
+					t.Fatal(err)
+				}
+			},
+			onMain: -1,
+			files:  map[string]string{"a.txt": "1\ntopic\n3\n", "b.txt": "b\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+			switchBranch(t, repo, "topic", true)
+			commitFiles(t, repo, "topic change", map[string]string{"a.txt": "1\ntopic\n3\n"})
+			topicB := commitFiles(t, repo, "add b", map[string]string{"b.txt": "b\n"})
+			switchBranch(t, repo, "main", false)
+			main := commitFiles(t, repo, "main change", map[string]string{"a.txt": "1\nmain\n3\n"})
+			switchBranch(t, repo, "topic", false)
+
+			result, err := Rebase(repo, "main", RebaseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stopped == "" || !reflect.DeepEqual(result.Conflicts, []string{"a.txt"}) {
+				t.Fatalf("rebase stopped at %q with conflicts %q, want a conflict in a.txt", result.Stopped, result.Conflicts)
+			}
+			if _, err := Rebase(repo, "main", RebaseOptions{}); err == nil {
+				t.Error("starting a rebase while one is in progress succeeded")
+			}
+			test.resume(t, repo)
+
+			tip := headID(t, repo)
+			if test.onMain < 0 {
+				if tip != topicB.ID {
+					t.Errorf("branch is at %s, want its original tip %s", tip, topicB.ID)
+				}
+			} else {
+				messages := branchMessages(t, repo, test.onMain+1)
+				if messages[len(messages)-1] != "main change" {
+					t.Errorf("history %q, want %d commits on top of main at %s", messages, test.onMain, main.ID)
+				}
+			}
+			for name, want := range test.files {
+				if got := readFile(t, repo, name); got != want {
+					t.Errorf("%s is %q, want %q", name, got, want)
+				}
+			}
+			if branch, err := vcs_operations.CurrentBranch(repo); err != nil || branch != "topic" {
+				t.Errorf("current branch is %q, %v, want topic", branch, err)
+			}
+			if fsys.Exists(repo.Store, rebaseDir) {
+				t.Error("rebase state is left behind")
+			}
+			if status, err := GetStatus(repo); err != nil {
+				t.Fatal(err)
+			} else if len(status.Files) > 0 {
+				t.Errorf("changes are left behind: %+v", status.Files)
+			}
+		})
+	}
+}
+
+func TestRebaseInteractive(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	one := commitFiles(t, repo, "one", map[string]string{"b.txt": "b\n"})
+	two := commitFiles(t, repo, "two", map[string]string{"c.txt": "c\n"})
+	three := commitFiles(t, repo, "three", map[string]string{"d.txt": "d\n"})
+	four := commitFiles(t, repo, "four", map[string]string{"e.txt": "e\n"})
+
+	todo := fmt.Sprintf("pick %s one\nsquash %s two\nfixup %s three\ndrop %s four\n", one.ID, two.ID, three.ID, four.ID)
+	result, err := Rebase(repo, "HEAD~4", RebaseOptions{Interactive: true, Edit: todoEditor(todo)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stopped != "" {
+		t.Fatalf("rebase stopped at %q", result.Stopped)
+	}
+	if got := branchMessages(t, repo, 2); !reflect.DeepEqual(got, []string{"one\n\ntwo", "base"}) {
+		t.Errorf("history %q, want one squashed commit on top of base", got)
+	}
+	head, err := vcs_operations.GetCommitByHash(repo, headID(t, repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Author != one.Author || !head.Timestamp.Equal(one.Timestamp) {
+		t.Errorf("squashed commit is by %q at %v, want the author of the first commit", head.Author, head.Timestamp)
+	}
+	if got := readFile(t, repo, "e.txt"); got != "" {
+		t.Errorf("e.txt is %q, want the dropped commit's file gone", got)
+	}
+	if got := readFile(t, repo, "d.txt"); got != "d\n" {
+		t.Errorf("d.txt is %q, want the fixed up commit's file", got)
+	}
+}
+
+func TestRebaseAutosquash(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	commitFiles(t, repo, "Add b", map[string]string{"b.txt": "b\n"})
+	commitFiles(t, repo, "Add c", map[string]string{"c.txt": "c\n"})
+	commitFiles(t, repo, "fixup! Add b", map[string]string{"b.txt": "fixed\n"})
+
+	if _, err := Rebase(repo, "HEAD~3", RebaseOptions{Interactive: true, Autosquash: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := branchMessages(t, repo, 3); !reflect.DeepEqual(got, []string{"Add c", "Add b", "base"}) {
+		t.Errorf("history %q, want the fixup melded into Add b", got)
+	}
+	if got := readFile(t, repo, "b.txt"); got != "fixed\n" {
+		t.Errorf("b.txt is %q, want the fixed content", got)
+	}
+}
+
+func TestRebaseTodoErrors(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	one := commitFiles(t, repo, "one", map[string]string{"b.txt": "b\n"})
+	two := commitFiles(t, repo, "two", map[string]string{"c.txt": "c\n"})
+
+	tests := map[string]string{
+		"squash first":    "squash <one> one\npick <two> two\n",
+		"fixup first":     "fixup <one> one\npick <two> two\n",
+		"unknown command": "take <one> one\n",
+		"missing commit":  "pick\n",
+	}
+	for name, todo := range tests {
+		t.Run(name, func(t *testing.T) {
+			todo = strings.NewReplacer("<one>", one.ID, "<two>", two.ID).Replace(todo)
+			_, err := Rebase(repo, "HEAD~2", RebaseOptions{Interactive: true, Edit: todoEditor(todo)})
+			if err == nil {
+				t.Fatal("rebase with an invalid todo list succeeded")
+			}
+			if strings.HasSuffix(name, "first") && !strings.Contains(err.Error(), "without a previous commit") {
+				t.Errorf("error %q, want the instruction rejected for lack of a previous commit", err)
+			}
+			if fsys.Exists(repo.Store, rebaseDir) {
+				t.Error("rebase state is left behind")
+			}
+			if tip := headID(t, repo); tip != two.ID {
+				t.Errorf("branch is at %s, want it unchanged at %s", tip, two.ID)
+			}
+		})
+	}
+}
+
+func TestRebaseRefusedCheckout(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "ignore logs", map[string]string{".gitxignore": "*.log\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "add log", map[string]string{"out.log": "main\n"})
+	switchBranch(t, repo, "topic", false)
+
+	// An ignored file in the way of main's version stops the rebase before it starts
+	writeFile(t, repo, "out.log", "local\n")
+	if _, err := Rebase(repo, "main", RebaseOptions{}); err == nil {
+		t.Fatal("rebase over an untracked file succeeded")
+	}
+	if fsys.Exists(repo.Store, rebaseDir) {
+		t.Error("rebase state is left behind")
+	}
+	if tip := headID(t, repo); tip != topic.ID {
+		t.Errorf("branch is at %s, want it unchanged at %s", tip, topic.ID)
+	}
+	if got := readFile(t, repo, "out.log"); got != "local\n" {
+		t.Errorf("out.log is %q, want it untouched", got)
+	}
+}
+
+func TestRebaseExec(t *testing.T) {
+	repo := newDiskTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n"})
+	one := commitFiles(t, repo, "one", map[string]string{"b.txt": "b\n"})
+	two := commitFiles(t, repo, "two", map[string]string{"c.txt": "c\n"})
+
+	var stdout bytes.Buffer
+	todo := fmt.Sprintf("pick %s one\nexec echo ran\nexec false\npick %s two\n", one.ID, two.ID)
+	opts := RebaseOptions{Interactive: true, Edit: todoEditor(todo), Stdout: &stdout}
+	result, err := Rebase(repo, "HEAD~2", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "ran\n" {
+		t.Errorf("exec output %q, want %q", stdout.String(), "ran\n")
+	}
+	if result.Stopped != "exec false" {
+		t.Fatalf("rebase stopped at %q, want the failed exec", result.Stopped)
+	}
+
+	if result, err = ContinueRebase(repo, opts); err != nil {
+		t.Fatal(err)
+	}
+	if result.Stopped != "" {
+		t.Errorf("rebase stopped again at %q", result.Stopped)
+	}
+	if got := branchMessages(t, repo, 3); !reflect.DeepEqual(got, []string{"two", "one", "base"}) {
+		t.Errorf("history %q, want both commits replayed", got)
+	}
+}
@@ -0,0 +1,350 @@
+package file_operations
+
+import (
+	"GitX/internal/diff"
+	"GitX/internal/hash"
+	"GitX/internal/patch"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ResetMode selects what Reset updates besides the current branch.
+type ResetMode int
+
+const (
+	// ResetMixed resets the INDEX but not the working tree. This is the default.
+	ResetMixed ResetMode = iota
+	// ResetSoft only moves the current branch.
+	ResetSoft
+	// ResetHard resets the INDEX and the working tree, discarding every change to tracked files.
+	ResetHard
+)
+
+// String returns the command line flag selecting the mode.
+func (m ResetMode) String() string {
+	switch m {
+	case ResetSoft:
+		return "--soft"
+	case ResetHard:
+		return "--hard"
+	default:
+		return "--mixed"
+	}
+}
+
+// Reset moves the current branch to the commit named by rev and records the move in the reflog.
+// Depending on mode it also rewrites the INDEX from the commit's tree and checks the tree out
+// into the working tree. It returns the commit the branch now points to.
+func Reset(repo *models.Repository, rev string, mode ResetMode) (*models.Commit, error) {
+	targetID, err := vcs_operations.ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	target, err := vcs_operations.GetCommitByHash(repo, targetID)
+	if err != nil {
+		return nil, err
+	}
+	targetFiles := treeFiles(target.Tree)
+
+	branchName, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return nil, err
+	}
+	oldID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ResetHard {
+		if err := checkoutTree(repo, targetFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode != ResetSoft {
+		if err := writeIndexFiles(repo, targetFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := vcs_operations.CreateBranchRef(repo, branchName, targetID); err != nil {
+		return nil, fmt.Errorf("error updating branch ref file: %w", err)
+	}
+	if err := vcs_operations.AppendReflog(repo, "refs/heads/"+branchName, oldID, targetID, "reset: moving to "+rev); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// checkoutTree makes the tracked files of the working tree match files, which maps paths to
+// blob IDs. Files tracked by the INDEX or HEAD that are not in files are deleted; untracked
+// files are left alone unless they are in the way.
+func checkoutTree(repo *models.Repository, files map[string]string) error {
+	tracked, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	for _, entry := range entries {
+		tracked[entry.Path] = entry.Hash
+	}
+
+	for _, filePath := range sortedKeys(tracked) {
+		if _, ok := files[filePath]; !ok {
+			if err := checkoutFile(repo, filePath, ""); err != nil {
+				return err
+			}
+		}
+	}
+	for _, filePath := range sortedKeys(files) {
+		if err := checkoutFile(repo, filePath, files[filePath]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeIndexFiles replaces the INDEX with the files, which maps paths to blob IDs.
+func writeIndexFiles(repo *models.Repository, files map[string]string) error {
+	entries := make([]*models.IndexEntry, 0, len(files))
+	for _, filePath := range sortedKeys(files) {
+		entries = append(entries, &models.IndexEntry{Mode: "100644", Type: "blob", Hash: files[filePath], Path: filePath})
+	}
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
+
+// checkoutFile writes the blob with the given ID to the slash-separated path in the working
+// tree, or deletes the file if id is empty. Files that already have the content are not touched.
+func checkoutFile(repo *models.Repository, filePath, id string) error {
+	if id == "" {
+		if err := repo.WorkTree.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error removing %s: %w", filePath, err)
+		}
+		removeEmptyParents(repo.WorkTree, filePath)
+		return nil
+	}
+
+	if workID, exists, err := workTreeBlobID(repo, filePath); err == nil && exists && workID == id {
+		return nil
+	}
+	content, err := vcs_operations.ReadBlob(repo, id)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", filePath, err)
+	}
+	if err := repo.WorkTree.MkdirAll(path.Dir(filePath), fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory for %s: %w", filePath, err)
+	}
+	if err := repo.WorkTree.WriteFile(filePath, content, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// ResetPaths unstages the files selected by the pathspecs by setting their INDEX entries back
+// to their version in the commit named by rev, or removing them if the commit does not have
+// them. The working tree and the current branch are left alone.
+func ResetPaths(repo *models.Repository, rev string, paths []string) error {
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := revisionFiles(repo, rev)
+	if err != nil {
+		return err
+	}
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+
+	index := make(map[string]*models.IndexEntry, len(entries))
+	for _, entry := range entries {
+		index[entry.Path] = entry
+	}
+
+	for _, spec := range specs {
+		matched := false
+		for _, filePath := range sortedKeys(index) {
+			if spec.match(filePath) {
+				matched = true
+				if _, ok := targetFiles[filePath]; !ok {
+					delete(index, filePath)
+				}
+			}
+		}
+		for filePath, id := range targetFiles {
+			if spec.match(filePath) {
+				matched = true
+				index[filePath] = &models.IndexEntry{Mode: "100644", Type: "blob", Hash: id, Path: filePath}
+			}
+		}
+		if !matched {
+			return fmt.Errorf("pathspec '%s' did not match any files", spec.spec)
+		}
+	}
+
+	entries = entries[:0]
+	for _, filePath := range sortedKeys(index) {
+		entries = append(entries, index[filePath])
+	}
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
+
+// ResetPatch lets the user pick which hunks of the differences between the commit named by rev
+// and the INDEX to unstage, for the files selected by the pathspecs, or all of them if none are
+// given. It uses the same hunk selector as AddPatch.
+func ResetPatch(repo *models.Repository, rev string, paths []string, selector *patch.Selector) error {
+	if len(paths) == 0 {
+		paths = []string{repo.Directory}
+	}
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := revisionFiles(repo, rev)
+	if err != nil {
+		return err
+	}
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+
+	offered, unstaged := false, false
+	kept := make([]*models.IndexEntry, 0, len(entries))
+	for i, entry := range entries {
+		selected := false
+		for _, spec := range specs {
+			selected = selected || spec.match(entry.Path)
+		}
+		targetID, inTarget := targetFiles[entry.Path]
+		if !selected || targetID == entry.Hash {
+			kept = append(kept, entry)
+			continue
+		}
+
+		var target []byte
+		if inTarget {
+			if target, err = vcs_operations.ReadBlob(repo, targetID); err != nil {
+				return fmt.Errorf("error reading %s: %w", entry.Path, err)
+			}
+		}
+		staged, err := vcs_operations.ReadBlob(repo, entry.Hash)
+		if err != nil {
+			return fmt.Errorf("error reading staged version of %s: %w", entry.Path, err)
+		}
+
+		targetLines, stagedLines := diff.SplitLines(target), diff.SplitLines(staged)
+		hunks := diff.Hunks(targetLines, stagedLines, 3)
+		offered = true
+		picked, quit, err := selector.Select(entry.Path, targetLines, hunks)
+		if err != nil {
+			return err
+		}
+
+		if len(picked) > 0 {
+			// Unstaging applies the picked hunks in reverse to the staged version
+			reversed := make([]diff.Hunk, len(picked))
+			for j, hunk := range picked {
+				reversed[j] = reverseHunk(hunk)
+			}
+			sort.Slice(reversed, func(a, b int) bool {
+				return reversed[a].OldStart < reversed[b].OldStart
+			})
+			lines, err := diff.Apply(stagedLines, reversed)
+			if err != nil {
+				return fmt.Errorf("error applying hunks to %s: %w", entry.Path, err)
+			}
+			unstaged = true
+
+			// A new file whose every hunk is unstaged leaves the INDEX entirely
+			if !inTarget && len(picked) == len(hunks) {
+				if quit {
+					kept = append(kept, entries[i+1:]...)
+					break
+				}
+				continue
+			}
+			hashValue, err := hash.StoreBlob(repo.Hash(), repo.Store, []byte(strings.Join(lines, "")))
+			if err != nil {
+				return fmt.Errorf("error storing blob for %s: %w", entry.Path, err)
+			}
+			entry.Hash = hashValue
+		}
+		kept = append(kept, entry)
+		if quit {
+			kept = append(kept, entries[i+1:]...)
+			break
+		}
+	}
+
+	if !offered {
+		fmt.Fprintln(selector.Out, "No changes.")
+	}
+	if !unstaged {
+		return nil
+	}
+	if err := vcs_operations.WriteIndexFile(repo, kept); err != nil {
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
+
+// reverseHunk turns a hunk from old to new into one from new to old.
+func reverseHunk(hunk diff.Hunk) diff.Hunk {
+	lines := make([]diff.Line, len(hunk.Lines))
+	for i, line := range hunk.Lines {
+		switch line.Kind {
+		case '+':
+			line.Kind = '-'
+		case '-':
+			line.Kind = '+'
+		}
+		lines[i] = line
+	}
+	return diff.NewHunk(hunk.NewStart, hunk.OldStart, lines)
+}
+
+// revisionFiles returns the blob IDs of the files in the commit named by rev, keyed by path.
+// A branch without commits yet has no files.
+func revisionFiles(repo *models.Repository, rev string) (map[string]string, error) {
+	if rev == "HEAD" {
+		return vcs_operations.HeadTreeFiles(repo)
+	}
+	id, err := vcs_operations.ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := vcs_operations.GetCommitByHash(repo, id)
+	if err != nil {
+		return nil, err
+	}
+	return treeFiles(commit.Tree), nil
+}
+
+// treeFiles returns the blob IDs of the files in the tree, keyed by path.
+func treeFiles(tree *models.Tree) map[string]string {
+	files := make(map[string]string)
+	if tree != nil {
+		for _, entry := range tree.Entries {
+			files[entry.Name] = entry.ID
+		}
+	}
+	return files
+}
@@ -0,0 +1,84 @@
+package file_operations
+
+import (
+	"GitX/utils/vcs_operations"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	tests := []struct {
+		mode     ResetMode
+		index    string            // Commit whose tree the INDEX holds afterwards
+		worktree map[string]string // Expected content of the files afterwards, "" for none
+	}{
+		{ResetSoft, "second", map[string]string{"a.txt": "local\n", "b.txt": "b\n"}},
+		{ResetMixed, "first", map[string]string{"a.txt": "local\n", "b.txt": "b\n"}},
+		{ResetHard, "first", map[string]string{"a.txt": "a\n", "b.txt": ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			repo := newTestRepo(t)
+			first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+			second := commitFiles(t, repo, "second", map[string]string{"a.txt": "a2\n", "b.txt": "b\n"})
+			writeFile(t, repo, "a.txt", "local\n")
+
+			commit, err := Reset(repo, "HEAD~1", test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit.ID != first.ID {
+				t.Errorf("reset to %s, want %s", commit.ID, first.ID)
+			}
+			if head, _ := vcs_operations.GetCurrentHeadCommit(repo); head != first.ID {
+				t.Errorf("HEAD is %s, want %s", head, first.ID)
+			}
+
+			want := treeFiles(first.Tree)
+			if test.index == "second" {
+				want = treeFiles(second.Tree)
+			}
+			if got := indexHashes(t, repo); !reflect.DeepEqual(got, want) {
+				t.Errorf("INDEX = %v, want the tree of %s %v", got, test.index, want)
+			}
+			for name, content := range test.worktree {
+				if got := readFile(t, repo, name); got != content {
+					t.Errorf("%s = %q, want %q", name, got, content)
+				}
+			}
+
+			entries, err := vcs_operations.ReadReflog(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := entries[len(entries)-1]
+			if last.OldID != second.ID || last.ID != first.ID || !strings.HasPrefix(last.Message, "reset: moving to HEAD~1") {
+				t.Errorf("last reflog entry = %+v", last)
+			}
+		})
+	}
+}
+
+func TestResetPaths(t *testing.T) {
+	repo := newTestRepo(t)
+	commit := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	writeFile(t, repo, "a.txt", "changed\n")
+	writeFile(t, repo, "c.txt", "new\n")
+	addFile(t, repo, "a.txt")
+	addFile(t, repo, "b.txt")
+	addFile(t, repo, "c.txt")
+
+	if err := ResetPaths(repo, "HEAD", []string{"/a.txt", "/c.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := indexHashes(t, repo), treeFiles(commit.Tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("INDEX = %v, want %v", got, want)
+	}
+	if got := readFile(t, repo, "a.txt"); got != "changed\n" {
+		t.Errorf("a.txt = %q, want the working tree to be kept", got)
+	}
+	if head, _ := vcs_operations.GetCurrentHeadCommit(repo); head != commit.ID {
+		t.Errorf("HEAD moved to %s", head)
+	}
+}
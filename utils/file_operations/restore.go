@@ -0,0 +1,97 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+)
+
+// RestoreOptions selects what RestoreHandler restores and from where.
+type RestoreOptions struct {
+	Staged   bool   // Restore the INDEX (--staged)
+	Worktree bool   // Restore the working tree; the default when Staged is not set (--worktree)
+	Source   string // Revision to restore from; defaults to the INDEX for the working tree and HEAD otherwise
+}
+
+// RestoreHandler restores the files selected by the pathspecs in the working tree, the INDEX or
+// both. Files the source does not have are removed. Unlike a reset, the current branch never
+// moves, and unlike a branch switch, only the selected paths are touched.
+func RestoreHandler(repo *models.Repository, paths []string, opts RestoreOptions) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("you must specify path(s) to restore")
+	}
+	if !opts.Staged {
+		opts.Worktree = true
+	}
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return err
+	}
+
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	index := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		index[entry.Path] = entry.Hash
+	}
+
+	// The working tree alone is restored from the INDEX unless a source is given
+	var source map[string]string
+	switch {
+	case opts.Source != "":
+		source, err = revisionFiles(repo, opts.Source)
+	case opts.Staged:
+		source, err = revisionFiles(repo, "HEAD")
+	default:
+		source = index
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only paths known to the source or the INDEX can be restored
+	selected := make(map[string]bool)
+	for _, spec := range specs {
+		matched := false
+		for _, files := range []map[string]string{source, index} {
+			for filePath := range files {
+				if spec.match(filePath) {
+					selected[filePath] = true
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to gitx", spec.spec)
+		}
+	}
+
+	if opts.Staged {
+		for filePath := range selected {
+			if id, ok := source[filePath]; ok {
+				index[filePath] = id
+			} else {
+				delete(index, filePath)
+			}
+		}
+		entries = entries[:0]
+		for _, filePath := range sortedKeys(index) {
+			entries = append(entries, &models.IndexEntry{Mode: "100644", Type: "blob", Hash: index[filePath], Path: filePath})
+		}
+		if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+			return fmt.Errorf("error writing to INDEX file: %w", err)
+		}
+	}
+
+	if opts.Worktree {
+		for _, filePath := range sortedKeys(selected) {
+			if err := checkoutFile(repo, filePath, source[filePath]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package file_operations
+
+import (
+	"testing"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RestoreOptions
+		index    string // Version of a.txt staged afterwards
+		worktree string // Content of a.txt afterwards
+	}{
+		{"worktree from the INDEX", RestoreOptions{}, "staged", "staged\n"},
+		{"staged from HEAD", RestoreOptions{Staged: true}, "first", "local\n"},
+		{"both from HEAD", RestoreOptions{Staged: true, Worktree: true}, "first", "a\n"},
+		{"worktree from a source", RestoreOptions{Source: "HEAD~1"}, "staged", "old\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			commitFiles(t, repo, "old", map[string]string{"a.txt": "old\n"})
+			first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+			writeFile(t, repo, "a.txt", "staged\n")
+			addFile(t, repo, "a.txt")
+			staged := indexHashes(t, repo)["a.txt"]
+			writeFile(t, repo, "a.txt", "local\n")
+
+			if err := RestoreHandler(repo, []string{"/a.txt"}, test.opts); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"staged": staged, "first": treeFiles(first.Tree)["a.txt"]}[test.index]
+			if got := indexHashes(t, repo)["a.txt"]; got != want {
+				t.Errorf("a.txt is staged as %s, want the %s version %s", got, test.index, want)
+			}
+			if got := readFile(t, repo, "a.txt"); got != test.worktree {
+				t.Errorf("a.txt = %q, want %q", got, test.worktree)
+			}
+			if got := readFile(t, repo, "b.txt"); got != "b\n" {
+				t.Errorf("b.txt = %q, want it untouched", got)
+			}
+		})
+	}
+}
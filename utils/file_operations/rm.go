@@ -0,0 +1,141 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// RemoveOptions controls how RemoveHandler removes files.
+type RemoveOptions struct {
+	Cached    bool // Only remove the files from the INDEX, keeping them in the working tree (--cached)
+	Recursive bool // Allow removing every tracked file below a directory (-r)
+	Force     bool // Remove files even if they have changes that would be lost (-f)
+	DryRun    bool // Report the files without removing them (-n)
+}
+
+// RemoveHandler removes the tracked files selected by the pathspecs from the INDEX and, unless
+// opts.Cached is set, from the working tree. Files whose content would be lost, because it
+// differs from HEAD or from the INDEX, are refused unless opts.Force is set. The removed paths
+// are returned sorted.
+func RemoveHandler(repo *models.Repository, paths []string, opts RemoveOptions) ([]string, error) {
+	specs, err := compilePathspecs(repo, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading INDEX file: %w", err)
+	}
+
+	// Every pathspec must name tracked files, and directories need -r
+	selected := make(map[string]*models.IndexEntry)
+	for _, spec := range specs {
+		matched := false
+		for _, entry := range entries {
+			if !spec.match(entry.Path) {
+				continue
+			}
+			if spec.literal() && entry.Path != spec.spec && !opts.Recursive {
+				return nil, fmt.Errorf("not removing '%s' recursively without -r", spec.spec)
+			}
+			selected[entry.Path] = entry
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("pathspec '%s' did not match any files", spec.spec)
+		}
+	}
+
+	removed := make([]string, 0, len(selected))
+	for filePath := range selected {
+		removed = append(removed, filePath)
+	}
+	sort.Strings(removed)
+
+	if !opts.Force {
+		if err := checkRemovable(repo, removed, selected, opts.Cached); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DryRun {
+		return removed, nil
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if selected[entry.Path] == nil {
+			kept = append(kept, entry)
+		}
+	}
+	if err := vcs_operations.WriteIndexFile(repo, kept); err != nil {
+		return nil, fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+
+	if !opts.Cached {
+		for _, filePath := range removed {
+			if err := repo.WorkTree.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("error removing %s: %w", filePath, err)
+			}
+			removeEmptyParents(repo.WorkTree, filePath)
+		}
+	}
+
+	return removed, nil
+}
+
+// checkRemovable refuses to remove files whose staged or working tree content is not recorded
+// anywhere else, like Git does. With cached, only files whose INDEX content matches neither
+// HEAD nor the working tree are refused, since the working tree copy is kept.
+func checkRemovable(repo *models.Repository, paths []string, entries map[string]*models.IndexEntry, cached bool) error {
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+
+	var bothChanged, stagedChanged, locallyModified []string
+	for _, filePath := range paths {
+		entry := entries[filePath]
+		headID, inHead := headFiles[filePath]
+		workID, exists, err := workTreeBlobID(repo, filePath)
+		if err != nil {
+			return fmt.Errorf("error hashing %s: %w", filePath, err)
+		}
+
+		stagedDiffers := !inHead || headID != entry.Hash
+		workDiffers := exists && workID != entry.Hash
+		switch {
+		case stagedDiffers && workDiffers:
+			bothChanged = append(bothChanged, filePath)
+		case cached:
+		case stagedDiffers:
+			stagedChanged = append(stagedChanged, filePath)
+		case workDiffers:
+			locallyModified = append(locallyModified, filePath)
+		}
+	}
+
+	var message strings.Builder
+	report := func(files []string, problem, hint string) {
+		if len(files) == 0 {
+			return
+		}
+		fmt.Fprintf(&message, "the following files have %s:\n", problem)
+		for _, filePath := range files {
+			fmt.Fprintf(&message, "    %s\n", filePath)
+		}
+		fmt.Fprintf(&message, "(%s)\n", hint)
+	}
+	report(bothChanged, "staged content different from both the file and the HEAD", "use -f to force removal")
+	report(stagedChanged, "changes staged in the index", "use --cached to keep the file, or -f to force removal")
+	report(locallyModified, "local modifications", "use --cached to keep the file, or -f to force removal")
+	if message.Len() > 0 {
+		return errors.New(strings.TrimSuffix(message.String(), "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package file_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		opts     RemoveOptions
+		removed  []string
+		err      bool
+		worktree map[string]string // Expected content of the files afterwards, "" for none
+	}{
+		{
+			name:     "file",
+			paths:    []string{"/a.txt"},
+			removed:  []string{"a.txt"},
+			worktree: map[string]string{"a.txt": "", "dir/b.txt": "b\n"},
+		},
+		{
+			name:     "cached",
+			paths:    []string{"/a.txt"},
+			opts:     RemoveOptions{Cached: true},
+			removed:  []string{"a.txt"},
+			worktree: map[string]string{"a.txt": "a\n"},
+		},
+		{
+			name:  "directory without -r",
+			paths: []string{"/sub"},
+			err:   true,
+		},
+		{
+			name:     "recursive",
+			paths:    []string{"/sub"},
+			opts:     RemoveOptions{Recursive: true},
+			removed:  []string{"sub/d.txt", "sub/e.txt"},
+			worktree: map[string]string{"sub/d.txt": "", "sub/e.txt": "", "a.txt": "a\n"},
+		},
+		{
+			name:  "local changes",
+			paths: []string{"/dir/c.txt"},
+			err:   true,
+		},
+		{
+			name:     "forced",
+			paths:    []string{"/dir/c.txt"},
+			opts:     RemoveOptions{Force: true},
+			removed:  []string{"dir/c.txt"},
+			worktree: map[string]string{"dir/c.txt": ""},
+		},
+		{
+			name:     "dry run",
+			paths:    []string{"/a.txt"},
+			opts:     RemoveOptions{DryRun: true},
+			removed:  []string{"a.txt"},
+			worktree: map[string]string{"a.txt": "a\n"},
+		},
+		{
+			name:  "untracked",
+			paths: []string{"/untracked.txt"},
+			err:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			commitFiles(t, repo, "base", map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n", "dir/c.txt": "c\n", "sub/d.txt": "d\n", "sub/e.txt": "e\n"})
+			writeFile(t, repo, "dir/c.txt", "changed\n")
+			writeFile(t, repo, "untracked.txt", "u\n")
+			before := indexHashes(t, repo)
+
+			removed, err := RemoveHandler(repo, test.paths, test.opts)
+			if test.err {
+				if err == nil {
+					t.Fatal("RemoveHandler succeeds")
+				}
+				if !reflect.DeepEqual(indexHashes(t, repo), before) {
+					t.Error("a refused removal changes the INDEX")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(removed, test.removed) {
+				t.Errorf("removed %q, want %q", removed, test.removed)
+			}
+			index := indexHashes(t, repo)
+			for _, name := range test.removed {
+				if _, staged := index[name]; staged != test.opts.DryRun {
+					t.Errorf("%s staged = %v after the removal", name, staged)
+				}
+			}
+			for name, want := range test.worktree {
+				if got := readFile(t, repo, name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// sequencerDir holds the state of a cherry-pick or revert that stopped on a conflict.
+const sequencerDir = "sequencer"
+
+// sequencerState is the state of a cherry-pick or revert of several commits, saved when it
+// stops so that it can be continued, skipped or aborted.
+type sequencerState struct {
+	Action       string   `json:"action"`
+	Branch       string   `json:"branch"`
+	Head         string   `json:"head"` // Commit the branch pointed to before the sequence started
+	Todo         []string `json:"todo"` // Commits still to apply, starting with the one that stopped
+	Conflicts    []string `json:"conflicts,omitempty"`
+	Mainline     int      `json:"mainline,omitempty"`
+	RecordOrigin bool     `json:"recordOrigin,omitempty"`
+}
+
+// options returns the options the sequence was started with.
+func (s *sequencerState) options() PickOptions {
+	return PickOptions{Mainline: s.Mainline, RecordOrigin: s.RecordOrigin}
+}
+
+// loadSequencer reads the state of the sequence in progress, which must be of the given action
+// unless action is empty.
+func loadSequencer(repo *models.Repository, action string) (*sequencerState, error) {
+	data, err := repo.Store.ReadFile(path.Join(sequencerDir, "state.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no %s in progress", action)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading sequencer state: %w", err)
+	}
+	var state sequencerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing sequencer state: %w", err)
+	}
+	if action != "" && state.Action != action {
+		return nil, fmt.Errorf("a %s is in progress, not a %s", state.Action, action)
+	}
+	return &state, nil
+}
+
+// saveSequencer writes the state of the sequence, so that it can be resumed.
+func saveSequencer(repo *models.Repository, state *sequencerState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing sequencer state: %w", err)
+	}
+	if err := repo.Store.MkdirAll(sequencerDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating sequencer directory: %w", err)
+	}
+	if err := repo.Store.WriteFile(path.Join(sequencerDir, "state.json"), data, 0644); err != nil {
+		return fmt.Errorf("error writing sequencer state: %w", err)
+	}
+	return nil
+}
+
+// startSequence applies the commits named by revs to the current branch, which must have
+// nothing staged.
+func startSequence(repo *models.Repository, action string, revs []string, opts PickOptions) (*PickResult, error) {
+	if fsys.Exists(repo.Store, sequencerDir) {
+		return nil, errors.New("a cherry-pick or revert is already in progress; use --continue, --skip or --abort")
+	}
+	if fsys.Exists(repo.Store, rebaseDir) {
+		return nil, errors.New("a rebase is in progress; finish or abort it first")
+	}
+	if len(revs) == 0 {
+		return nil, errors.New("no commits given")
+	}
+
+	state := &sequencerState{Action: action, Mainline: opts.Mainline, RecordOrigin: opts.RecordOrigin}
+	for _, rev := range revs {
+		id, err := vcs_operations.ResolveRevision(repo, rev)
+		if err != nil {
+			return nil, err
+		}
+		commit, err := vcs_operations.GetCommitByHash(repo, id)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pickParent(commit, opts.Mainline); err != nil {
+			return nil, err
+		}
+		state.Todo = append(state.Todo, id)
+	}
+
+	var err error
+	if state.Branch, err = vcs_operations.CurrentBranch(repo); err != nil {
+		return nil, err
+	}
+	if state.Head, err = vcs_operations.GetCurrentHeadCommit(repo); err != nil {
+		return nil, err
+	}
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNothingStaged(repo, headFiles, action); err != nil {
+		return nil, err
+	}
+	return runSequence(repo, state, &PickResult{})
+}
+
+// runSequence applies the commits left to do in order. It stops at the first conflict and
+// saves the state, and removes it once every commit is applied.
+func runSequence(repo *models.Repository, state *sequencerState, result *PickResult) (*PickResult, error) {
+	for len(state.Todo) > 0 {
+		commit, err := vcs_operations.GetCommitByHash(repo, state.Todo[0])
+		if err != nil {
+			return nil, err
+		}
+		conflicts, err := applyPick(repo, state.Action, commit, state.Mainline)
+		if err != nil {
+			// Commits already applied stay, and the rest can be resumed or aborted
+			if fsys.Exists(repo.Store, sequencerDir) || len(result.Commits)+len(result.Skipped) > 0 {
+				if saveErr := saveSequencer(repo, state); saveErr != nil {
+					return nil, saveErr
+				}
+			}
+			return nil, fmt.Errorf("could not apply %s: %w", commit.ID[:7], err)
+		}
+		if len(conflicts) > 0 {
+			state.Conflicts = conflicts
+			if err := saveSequencer(repo, state); err != nil {
+				return nil, err
+			}
+			result.Stopped, result.Conflicts = commit, conflicts
+			return result, nil
+		}
+		if err := recordPick(repo, state, result, commit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repo.Store.RemoveAll(sequencerDir); err != nil {
+		return nil, fmt.Errorf("error removing sequencer state: %w", err)
+	}
+	return result, nil
+}
+
+// recordPick commits the applied commit, which is the first one to do, and moves on to the next.
+func recordPick(repo *models.Repository, state *sequencerState, result *PickResult, commit *models.Commit) error {
+	author := commit
+	if state.Action == actionRevert {
+		author = nil
+	}
+	picked, err := commitPick(repo, state.Action, author, pickMessage(state.Action, commit, state.options()))
+	if err != nil {
+		return err
+	}
+	if picked != nil {
+		result.Commits = append(result.Commits, picked)
+	} else {
+		result.Skipped = append(result.Skipped, commit.ID)
+	}
+	state.Todo, state.Conflicts = state.Todo[1:], nil
+	return nil
+}
+
+// ContinueSequence resumes the cherry-pick or revert named by action once its conflicts are
+// resolved and staged: the stopped commit is committed from the INDEX, and the remaining
+// commits are applied.
+func ContinueSequence(repo *models.Repository, action string) (*PickResult, error) {
+	state, err := loadSequencer(repo, action)
+	if err != nil {
+		return nil, err
+	}
+	result := &PickResult{}
+	if len(state.Conflicts) > 0 {
+		unresolved, err := unresolvedConflicts(repo, state.Conflicts)
+		if err != nil {
+			return nil, err
+		}
+		if len(unresolved) > 0 {
+			return nil, fmt.Errorf("%w: resolve the conflicts and stage them with \"gitx add\" before continuing:\n\t%s",
+				models.ErrConflict, strings.Join(unresolved, "\n\t"))
+		}
+		commit, err := vcs_operations.GetCommitByHash(repo, state.Todo[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := recordPick(repo, state, result, commit); err != nil {
+			return nil, err
+		}
+	}
+	return runSequence(repo, state, result)
+}
+
+// SkipSequence drops the commit the cherry-pick or revert named by action stopped at, resetting
+// the INDEX and the working tree to HEAD, and applies the remaining commits.
+func SkipSequence(repo *models.Repository, action string) (*PickResult, error) {
+	state, err := loadSequencer(repo, action)
+	if err != nil {
+		return nil, err
+	}
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := discardPick(repo, headFiles, state.Conflicts); err != nil {
+		return nil, err
+	}
+	result := &PickResult{Skipped: []string{state.Todo[0]}}
+	state.Todo, state.Conflicts = state.Todo[1:], nil
+	return runSequence(repo, state, result)
+}
+
+// AbortSequence cancels the cherry-pick or revert named by action, moving the branch back to
+// the commit it pointed to before the sequence and resetting the INDEX and working tree to it.
+func AbortSequence(repo *models.Repository, action string) error {
+	state, err := loadSequencer(repo, action)
+	if err != nil {
+		return err
+	}
+	if branch, err := vcs_operations.CurrentBranch(repo); err != nil {
+		return err
+	} else if branch != state.Branch {
+		return fmt.Errorf("the %s was started on branch '%s'; switch back to it to abort", action, state.Branch)
+	}
+	headFiles := make(map[string]string)
+	if state.Head != "" {
+		if headFiles, err = revisionFiles(repo, state.Head); err != nil {
+			return err
+		}
+	}
+	if err := discardPick(repo, headFiles, state.Conflicts); err != nil {
+		return err
+	}
+
+	oldID, err := vcs_operations.ReadBranchRef(repo, state.Branch)
+	if err != nil {
+		return err
+	}
+	if err := vcs_operations.CreateBranchRef(repo, state.Branch, state.Head); err != nil {
+		return fmt.Errorf("error updating branch ref file: %w", err)
+	}
+	if err := vcs_operations.AppendReflog(repo, "refs/heads/"+state.Branch, oldID, state.Head, action+": abort"); err != nil {
+		return err
+	}
+	if err := repo.Store.RemoveAll(sequencerDir); err != nil {
+		return fmt.Errorf("error removing sequencer state: %w", err)
+	}
+	return nil
+}
+
+// discardPick makes the INDEX and the working tree match files, which maps paths to blob IDs.
+// Conflicted files that are not tracked are removed as well.
+func discardPick(repo *models.Repository, files map[string]string, conflicts []string) error {
+	for _, filePath := range conflicts {
+		if _, ok := files[filePath]; !ok {
+			if err := checkoutFile(repo, filePath, ""); err != nil {
+				return err
+			}
+		}
+	}
+	if err := checkoutTree(repo, files); err != nil {
+		return err
+	}
+	return writeIndexFiles(repo, files)
+}
@@ -0,0 +1,175 @@
+package file_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCherryPick(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "topic change", map[string]string{"a.txt": "1\n2\ntopic\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "main change", map[string]string{"a.txt": "main\n2\n3\n"})
+
+	result, err := CherryPick(repo, []string{topic.ID}, PickOptions{RecordOrigin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stopped != nil || len(result.Commits) != 1 {
+		t.Fatalf("cherry-pick result %+v, want one commit", result)
+	}
+	picked := result.Commits[0]
+	if want := "topic change\n\n(cherry picked from commit " + topic.ID + ")"; picked.Message != want {
+		t.Errorf("message %q, want %q", picked.Message, want)
+	}
+	if picked.Author != topic.Author || !picked.Timestamp.Equal(topic.Timestamp) {
+		t.Errorf("picked commit is by %q at %v, want the original author", picked.Author, picked.Timestamp)
+	}
+	if got := readFile(t, repo, "a.txt"); got != "main\n2\ntopic\n" {
+		t.Errorf("a.txt is %q, want both changes", got)
+	}
+
+	result, err = Revert(repo, []string{"HEAD"}, PickOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Commits) != 1 || result.Commits[0].Message != "Revert \"topic change\"\n\nThis reverts commit "+picked.ID+"." {
+		t.Errorf("revert result %+v, want a revert of %s", result, picked.ID)
+	}
+	if got := readFile(t, repo, "a.txt"); got != "main\n2\n3\n" {
+		t.Errorf("a.txt is %q after the revert, want the main change only", got)
+	}
+}
+
+func TestCherryPickStopsOnConflict(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+	switchBranch(t, repo, "topic", true)
+	topic := commitFiles(t, repo, "topic change", map[string]string{"a.txt": "1\ntopic\n3\n"})
+	topicB := commitFiles(t, repo, "add b", map[string]string{"b.txt": "b\n"})
+	switchBranch(t, repo, "main", false)
+	commitFiles(t, repo, "main change", map[string]string{"a.txt": "1\nmain\n3\n"})
+
+	result, err := CherryPick(repo, []string{topic.ID, topicB.ID}, PickOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stopped == nil || result.Stopped.ID != topic.ID {
+		t.Fatalf("stopped at %v, want the topic change", result.Stopped)
+	}
+	if !reflect.DeepEqual(result.Conflicts, []string{"a.txt"}) {
+		t.Errorf("conflicts %q, want a.txt", result.Conflicts)
+	}
+	if content := readFile(t, repo, "a.txt"); !hasConflictMarkers([]byte(content)) {
+		t.Errorf("a.txt has no conflict markers: %q", content)
+	}
+	if _, err := CherryPick(repo, []string{topicB.ID}, PickOptions{}); err == nil {
+		t.Error("starting a cherry-pick while one is in progress succeeded")
+	}
+	if _, err := ContinueSequence(repo, actionCherryPick); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("continuing with unresolved conflicts gives %v, want a conflict", err)
+	}
+}
+
+func TestSequencerResume(t *testing.T) {
+	tests := []struct {
+		name string
+		// resume ends the stopped cherry-pick and returns the commits it created
+		resume  func(t *testing.T, repo *models.Repository) *PickResult
+		commits int // Commits made on top of main, or -1 if the branch is back where it was
+		files   map[string]string
+	}{
+		{
+			name: "continue",
+			resume: func(t *testing.T, repo *models.Repository) *PickResult {
+				writeFile(t, repo, "a.txt", "1\nresolved\n3\n")
+				addFile(t, repo, "a.txt")
+				result, err := ContinueSequence(repo, actionCherryPick)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result
+			},
+			commits: 2,
+			files:   map[string]string{"a.txt": "1\nresolved\n3\n", "b.txt": "b\n"},
+		},
+		{
+			name: "skip",
+			resume: func(t *testing.T, repo *models.Repository) *PickResult {
+				result, err := SkipSequence(repo, actionCherryPick)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(result.Skipped) != 1 {
+					t.Errorf("skipped %q, want the topic change", result.Skipped)
+				}
+				return result
+			},
+			commits: 1,
+			files:   map[string]string{"a.txt": "1\nmain\n3\n", "b.txt": "b\n"},
+		},
+		{
+			name: "abort",
+			resume: func(t *testing.T, repo *models.Repository) *PickResult {
+				if err := AbortSequence(repo, actionCherryPick); err != nil {
+					t.Fatal(err)
+				}
+				return &PickResult{}
+			},
+			commits: -1,
+			files:   map[string]string{"a.txt": "1\nmain\n3\n", "b.txt": ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			commitFiles(t, repo, "base", map[string]string{"a.txt": "1\n2\n3\n"})
+			switchBranch(t, repo, "topic", true)
+			topic := commitFiles(t, repo, "topic change", map[string]string{"a.txt": "1\ntopic\n3\n"})
+			topicB := commitFiles(t, repo, "add b", map[string]string{"b.txt": "b\n"})
+			switchBranch(t, repo, "main", false)
+			main := commitFiles(t, repo, "main change", map[string]string{"a.txt": "1\nmain\n3\n"})
+			if _, err := CherryPick(repo, []string{topic.ID, topicB.ID}, PickOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			commits := test.resume(t, repo).Commits
+			tip := headID(t, repo)
+			if test.commits < 0 {
+				if tip != main.ID {
+					t.Errorf("branch is at %s, want %s", tip, main.ID)
+				}
+			} else {
+				if len(commits) != test.commits {
+					t.Errorf("created %d commits, want %d", len(commits), test.commits)
+				}
+				if len(commits) > 0 && commits[len(commits)-1].ID != tip {
+					t.Errorf("branch is at %s, not at the last commit created", tip)
+				}
+				for _, commit := range commits {
+					if commit.Author != topic.Author {
+						t.Errorf("commit %s has author %q, want the original author", commit.ID, commit.Author)
+					}
+				}
+			}
+			for name, want := range test.files {
+				if got := readFile(t, repo, name); got != want {
+					t.Errorf("%s is %q, want %q", name, got, want)
+				}
+			}
+			if fsys.Exists(repo.Store, sequencerDir) {
+				t.Error("sequencer state is left behind")
+			}
+			if status, err := GetStatus(repo); err != nil {
+				t.Fatal(err)
+			} else if len(status.Files) > 0 {
+				t.Errorf("changes are left behind: %+v", status.Files)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package file_operations
+
+import (
+	"GitX/internal/diff"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// showDateFormat is how Show prints dates, like Git does.
+const showDateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// Show writes the object named by name to w, resolved like vcs_operations.ReadObject. A
+// commit is shown with its header and message, followed by the patch of its changes against
+// its parent; merge commits have no patch. An annotated tag is shown with its message,
+// followed by the commit it tags. A tree lists the names directly in it, with a trailing
+// slash for directories, and a blob is written as it is.
+func Show(repo *models.Repository, w io.Writer, name string) error {
+	object, err := vcs_operations.ReadObject(repo, name)
+	if err != nil {
+		return err
+	}
+	switch object.Type {
+	case vcs_operations.ObjectBlob:
+		_, err := w.Write(object.Blob)
+		return err
+	case vcs_operations.ObjectTree:
+		fmt.Fprintf(w, "tree %s\n\n", name)
+		for _, entry := range vcs_operations.TreeChildren(repo.Hash(), object.Tree) {
+			if entry.Type == vcs_operations.ObjectTree {
+				entry.Name += "/"
+			}
+			fmt.Fprintln(w, entry.Name)
+		}
+		return nil
+	case vcs_operations.ObjectTag:
+		tag := object.Tag
+		fmt.Fprintf(w, "tag %s\nTagger: %s\nDate:   %s\n\n%s\n", tag.Name, tag.Tagger, tag.Timestamp.Format(showDateFormat), tag.Message)
+		if tag.Signature != "" {
+			fmt.Fprint(w, strings.TrimRight(tag.Signature, "\n")+"\n")
+		}
+		fmt.Fprintln(w)
+		commit, err := vcs_operations.GetCommitByHash(repo, tag.Object)
+		if err != nil {
+			return err
+		}
+		return showCommit(repo, w, commit)
+	}
+	return showCommit(repo, w, object.Commit)
+}
+
+// showCommit writes the header and message of the commit, followed by its patch.
+func showCommit(repo *models.Repository, w io.Writer, commit *models.Commit) error {
+	fmt.Fprintf(w, "commit %s\n", commit.ID)
+	if len(commit.Parent) > 1 {
+		var parents []string
+		for _, parent := range commit.Parent {
+			parents = append(parents, parent.ID[:7])
+		}
+		fmt.Fprintf(w, "Merge: %s\n", strings.Join(parents, " "))
+	}
+	fmt.Fprintf(w, "Author: %s\nDate:   %s\n\n", commit.Author, commit.Timestamp.Format(showDateFormat))
+	for _, line := range strings.Split(commit.Message, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+	if len(commit.Parent) > 1 {
+		return nil
+	}
+
+	oldFiles := make(map[string]string)
+	if len(commit.Parent) == 1 {
+		var err error
+		if oldFiles, err = revisionFiles(repo, commit.Parent[0].ID); err != nil {
+			return err
+		}
+	}
+	newFiles := treeFiles(commit.Tree)
+	changed := make(map[string]bool)
+	for filePath, id := range oldFiles {
+		if newFiles[filePath] != id {
+			changed[filePath] = true
+		}
+	}
+	for filePath, id := range newFiles {
+		if oldFiles[filePath] != id {
+			changed[filePath] = true
+		}
+	}
+	if len(changed) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, filePath := range sortedKeys(changed) {
+		if err := writeFilePatch(repo, w, filePath, oldFiles[filePath], newFiles[filePath]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFilePatch writes the changes to the file between the blobs oldID and newID in unified
+// diff format. An empty ID stands for a file that does not exist on that side.
+func writeFilePatch(repo *models.Repository, w io.Writer, filePath, oldID, newID string) error {
+	var oldContent, newContent []byte
+	var err error
+	if oldID != "" {
+		if oldContent, err = vcs_operations.ReadBlob(repo, oldID); err != nil {
+			return err
+		}
+	}
+	if newID != "" {
+		if newContent, err = vcs_operations.ReadBlob(repo, newID); err != nil {
+			return err
+		}
+	}
+
+	oldName, newName := "a/"+filePath, "b/"+filePath
+	fmt.Fprintf(w, "diff --gitx %s %s\n", oldName, newName)
+	switch {
+	case oldID == "":
+		fmt.Fprintf(w, "new file mode 100644\nindex %s..%s\n", strings.Repeat("0", 7), newID[:7])
+		oldName = "/dev/null"
+	case newID == "":
+		fmt.Fprintf(w, "deleted file mode 100644\nindex %s..%s\n", oldID[:7], strings.Repeat("0", 7))
+		newName = "/dev/null"
+	default:
+		fmt.Fprintf(w, "index %s..%s 100644\n", oldID[:7], newID[:7])
+	}
+
+	if bytes.IndexByte(oldContent, 0) >= 0 || bytes.IndexByte(newContent, 0) >= 0 {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return nil
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range diff.Hunks(diff.SplitLines(oldContent), diff.SplitLines(newContent), 3) {
+		hunk.Write(w)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package file_operations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShowCommit(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "1\n2\n3\n", "old.txt": "old\n"})
+	if _, err := RemoveHandler(repo, []string{workPath(repo, "old.txt")}, RemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	commit := commitFiles(t, repo, "Change a\n\nWith a body", map[string]string{"a.txt": "1\ntwo\n3\n", "new.txt": "new\n"})
+
+	var out strings.Builder
+	if err := Show(repo, &out, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	shown := out.String()
+	for _, want := range []string{
+		"commit " + commit.ID + "\nAuthor: Test User <test@example.com>\n",
+		"\n    Change a\n    \n    With a body\n",
+		"diff --gitx a/a.txt b/a.txt\n",
+		"--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n",
+		"new file mode 100644\n",
+		"--- /dev/null\n+++ b/new.txt\n",
+		"deleted file mode 100644\n",
+		"--- a/old.txt\n+++ /dev/null\n",
+	} {
+		if !strings.Contains(shown, want) {
+			t.Errorf("show output lacks %q:\n%s", want, shown)
+		}
+	}
+	if strings.Index(shown, "a/a.txt") > strings.Index(shown, "b/new.txt") {
+		t.Errorf("files are not shown in order:\n%s", shown)
+	}
+}
+
+func TestShowObjects(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"})
+	if _, err := TagHandler(repo, "v1", "", TagOptions{Message: "Release 1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string // Prefix of the output
+	}{
+		{"HEAD:a.txt", "a\n"},
+		{"HEAD:", "tree HEAD:\n\na.txt\ndir/\n"},
+		{"v1", "tag v1\nTagger: Test User <test@example.com>\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Show(repo, &out, test.name); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), test.want) {
+				t.Errorf("show output %q, want it to start with %q", out.String(), test.want)
+			}
+		})
+	}
+
+	var out strings.Builder
+	if err := Show(repo, &out, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\n\ncommit "+headID(t, repo)+"\n") {
+		t.Errorf("annotated tag is not followed by the commit it tags:\n%s", out.String())
+	}
+}
@@ -0,0 +1,197 @@
+package file_operations
+
+import (
+	"GitX/internal/signing"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allowedSignersFile is the allowed signers file in the .gitx directory, used when
+// gpg.ssh.allowedSignersFile is not set.
+const allowedSignersFile = "allowed_signers"
+
+// SignatureStatus summarizes a signature check with the letters of Git's %G? format.
+type SignatureStatus byte
+
+const (
+	// SignatureGood is a valid signature by a key of the allowed signers file.
+	SignatureGood SignatureStatus = 'G'
+	// SignatureUntrusted is a valid signature by a key missing from the allowed signers file.
+	SignatureUntrusted SignatureStatus = 'U'
+	// SignatureBad is a signature that does not match the signed object.
+	SignatureBad SignatureStatus = 'B'
+	// SignatureNone is an unsigned object.
+	SignatureNone SignatureStatus = 'N'
+)
+
+// SignatureCheck is the result of verifying the signature of a commit or tag.
+type SignatureCheck struct {
+	Status      SignatureStatus
+	Principals  []string // Identities the allowed signers file gives the key, only the signer's if good
+	Fingerprint string   // Fingerprint of the signing key, if the signature could be read
+	Reason      string   // Why a signature is bad
+}
+
+// String describes the check in the words of "ssh-keygen -Y verify".
+func (c *SignatureCheck) String() string {
+	switch c.Status {
+	case SignatureGood:
+		return fmt.Sprintf("Good \"%s\" signature for %s with ED25519 key %s",
+			signing.Namespace, strings.Join(c.Principals, ","), c.Fingerprint)
+	case SignatureUntrusted:
+		reason := "the key is not an allowed signer"
+		if len(c.Principals) > 0 {
+			reason = "the key is only allowed for " + strings.Join(c.Principals, ",")
+		}
+		return fmt.Sprintf("Good \"%s\" signature with ED25519 key %s\nNo principal matched: %s",
+			signing.Namespace, c.Fingerprint, reason)
+	case SignatureBad:
+		return "Bad signature: " + c.Reason
+	default:
+		return "No signature"
+	}
+}
+
+// signPayload signs payload with the key of user.signingkey.
+func signPayload(repo *models.Repository, payload []byte) (string, error) {
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	if config.SigningKey == "" {
+		return "", errors.New("no signing key configured; set one with \"gitx config user.signingkey <key file>\"")
+	}
+	key, err := signing.LoadPrivateKey(config.SigningKey)
+	if err != nil {
+		return "", err
+	}
+	return signing.Sign(key, signing.Namespace, payload), nil
+}
+
+// verify checks signature against payload and against the allowed signers file. The signature
+// is only good if the key is allowed for one of the identities that made the object, such as its
+// author or committer.
+func verify(repo *models.Repository, payload []byte, signature string, identities ...string) (*SignatureCheck, error) {
+	if signature == "" {
+		return &SignatureCheck{Status: SignatureNone}, nil
+	}
+	key, err := signing.Verify(signature, signing.Namespace, payload)
+	if err != nil {
+		return &SignatureCheck{Status: SignatureBad, Reason: err.Error()}, nil
+	}
+
+	signers, err := allowedSigners(repo)
+	if err != nil {
+		return nil, err
+	}
+	check := &SignatureCheck{Status: SignatureUntrusted, Fingerprint: signing.Fingerprint(key)}
+	check.Principals = signing.Principals(signers, key)
+	var matched []string
+	for _, principal := range check.Principals {
+		for _, identity := range identities {
+			if principal == identityPrincipal(identity) {
+				matched = append(matched, principal)
+				break
+			}
+		}
+	}
+	if len(matched) > 0 {
+		check.Status, check.Principals = SignatureGood, matched
+	}
+	return check, nil
+}
+
+// identityPrincipal returns the principal that an identity signs as in the allowed signers file:
+// the email of "Name <email>", or the whole identity if it has none.
+func identityPrincipal(identity string) string {
+	if start := strings.LastIndex(identity, "<"); start >= 0 {
+		if email, _, found := strings.Cut(identity[start+1:], ">"); found {
+			return email
+		}
+	}
+	return identity
+}
+
+// allowedSigners reads the file of gpg.ssh.allowedSignersFile with repo.ReadHostFile, or the
+// allowed_signers file of the .gitx directory. A missing file trusts no key, and so does a file
+// outside of a repository that cannot read one.
+func allowedSigners(repo *models.Repository) ([]signing.AllowedSigner, error) {
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	var data []byte
+	if signersPath := config.AllowedSignersFile; signersPath != "" {
+		if repo.ReadHostFile == nil {
+			return nil, nil
+		}
+		if rest, ok := strings.CutPrefix(signersPath, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			signersPath = filepath.Join(home, rest)
+		} else if !filepath.IsAbs(signersPath) {
+			signersPath = filepath.Join(repo.Directory, signersPath)
+		}
+		data, err = repo.ReadHostFile(signersPath)
+	} else {
+		data, err = repo.Store.ReadFile(allowedSignersFile)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading allowed signers file: %w", err)
+	}
+	return signing.ParseAllowedSigners(data)
+}
+
+// CheckCommitSignature verifies the signature of the commit.
+func CheckCommitSignature(repo *models.Repository, commit *models.Commit) (*SignatureCheck, error) {
+	return verify(repo, vcs_operations.CommitPayload(commit), commit.GPGSignature, commit.Author, commit.Committer)
+}
+
+// VerifyCommit verifies the signature of the commit named by rev.
+func VerifyCommit(repo *models.Repository, rev string) (*models.Commit, *SignatureCheck, error) {
+	id, err := vcs_operations.ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	commit, err := vcs_operations.GetCommitByHash(repo, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := CheckCommitSignature(repo, commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commit, check, nil
+}
+
+// VerifyTag verifies the signature of the annotated tag called name.
+func VerifyTag(repo *models.Repository, name string) (*models.Tag, *SignatureCheck, error) {
+	id, err := vcs_operations.ReadTagRef(repo, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !vcs_operations.IsTagObject(repo, id) {
+		return nil, nil, fmt.Errorf("%s is a lightweight tag, which cannot be signed", name)
+	}
+	tag, err := vcs_operations.GetTagByHash(repo, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := verify(repo, vcs_operations.TagPayload(tag), tag.Signature, tag.Tagger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tag, check, nil
+}
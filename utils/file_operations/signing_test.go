@@ -0,0 +1,257 @@
+package file_operations
+
+import (
+	"GitX/internal/signing"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// signer is the identity of the commits and tags signed in the tests.
+const signer = "Test User <test@example.com>"
+
+// newSigningKey writes a new Ed25519 key to a PKCS#8 PEM file and returns its path and public key.
+func newSigningKey(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "signing_key")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, public
+}
+
+// newSigningRepo returns a repository with a commit, signing with a new key that the allowed
+// signers file allows for principal, or for nobody if principal is empty.
+func newSigningRepo(t *testing.T, principal string) *models.Repository {
+	t.Helper()
+	repo := newTestRepo(t)
+	keyPath, public := newSigningKey(t)
+	setConfig(t, repo, "user.signingkey", keyPath)
+	if principal != "" {
+		signers := principal + " " + signing.AuthorizedKey(public) + "\n"
+		if err := repo.Store.WriteFile(allowedSignersFile, []byte(signers), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitFiles(t, repo, "initial", map[string]string{"a.txt": "a\n"})
+	return repo
+}
+
+// signedCommit returns a commit of the HEAD tree made by identity and signed with the key of
+// the repository.
+func signedCommit(t *testing.T, repo *models.Repository, identity string) *models.Commit {
+	t.Helper()
+	head, err := vcs_operations.GetCommitByHash(repo, headID(t, repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := &models.Commit{
+		Tree:      head.Tree,
+		Parent:    []*models.Commit{{ID: head.ID}},
+		Message:   "signed",
+		Author:    identity,
+		Committer: identity,
+		Timestamp: time.Unix(1700000000, 0),
+	}
+	if commit.GPGSignature, err = signPayload(repo, vcs_operations.CommitPayload(commit)); err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+// headID returns the commit HEAD points to.
+func headID(t *testing.T, repo *models.Repository) string {
+	t.Helper()
+	id, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCommitSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal string
+		tamper    func(commit *models.Commit)
+		want      SignatureStatus
+	}{
+		{name: "signed by an allowed signer", principal: "test@example.com", want: SignatureGood},
+		{name: "signed by an unknown key", want: SignatureUntrusted},
+		{name: "key allowed for someone else", principal: "other@example.com", want: SignatureUntrusted},
+		{name: "changed message", principal: "test@example.com", tamper: func(c *models.Commit) { c.Message = "changed" }, want: SignatureBad},
+		{name: "changed author", principal: "test@example.com", tamper: func(c *models.Commit) { c.Author = "Someone <x@example.com>" }, want: SignatureBad},
+		{name: "changed committer", principal: "test@example.com", tamper: func(c *models.Commit) { c.Committer = "Someone <x@example.com>" }, want: SignatureBad},
+		{name: "garbled signature", principal: "test@example.com", tamper: func(c *models.Commit) { c.GPGSignature = "garbage" }, want: SignatureBad},
+		{name: "unsigned", principal: "test@example.com", tamper: func(c *models.Commit) { c.GPGSignature = "" }, want: SignatureNone},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newSigningRepo(t, test.principal)
+			commit := signedCommit(t, repo, signer)
+			if test.tamper != nil {
+				test.tamper(commit)
+			}
+			check, err := CheckCommitSignature(repo, commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if check.Status != test.want {
+				t.Errorf("status %c (%s), want %c", check.Status, check, test.want)
+			}
+			if check.Status == SignatureGood && (len(check.Principals) != 1 || check.Principals[0] != "test@example.com") {
+				t.Errorf("principals %q, want the allowed signer", check.Principals)
+			}
+		})
+	}
+}
+
+func TestCommitHandlerSigns(t *testing.T) {
+	for name, opts := range map[string]CommitOptions{"-S": {Sign: true}, "commit.gpgSign": {}} {
+		t.Run(name, func(t *testing.T) {
+			repo := newSigningRepo(t, "test@example.com")
+			if name == "commit.gpgSign" {
+				setConfig(t, repo, "commit.gpgSign", "true")
+			}
+			writeFile(t, repo, "b.txt", "b\n")
+			addFile(t, repo, "b.txt")
+			if _, err := CommitHandler(repo, "second", opts); err != nil {
+				t.Fatal(err)
+			}
+			_, check, err := VerifyCommit(repo, "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if check.Status != SignatureGood {
+				t.Errorf("status %c (%s), want %c", check.Status, check, SignatureGood)
+			}
+		})
+	}
+}
+
+func TestSignWithoutKey(t *testing.T) {
+	repo := newTestRepo(t)
+	writeFile(t, repo, "a.txt", "a\n")
+	addFile(t, repo, "a.txt")
+	if _, err := CommitHandler(repo, "initial", CommitOptions{Sign: true}); err == nil {
+		t.Error("signing without a key succeeded")
+	}
+}
+
+func TestAllowedSignersFile(t *testing.T) {
+	repo := newSigningRepo(t, "")
+	commit := signedCommit(t, repo, signer)
+
+	// The configured file is read from the host, never from the repository's file systems
+	setConfig(t, repo, "gpg.ssh.allowedSignersFile", "/etc/gitx/allowed_signers")
+	check, err := CheckCommitSignature(repo, commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Status != SignatureUntrusted {
+		t.Errorf("status without a host to read the file from %c, want %c", check.Status, SignatureUntrusted)
+	}
+
+	signature, err := signing.Verify(commit.GPGSignature, signing.Namespace, vcs_operations.CommitPayload(commit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read []string
+	repo.ReadHostFile = func(name string) ([]byte, error) {
+		read = append(read, name)
+		if name != "/etc/gitx/allowed_signers" {
+			return nil, fs.ErrNotExist
+		}
+		return []byte("test@example.com " + signing.AuthorizedKey(signature) + "\n"), nil
+	}
+	if check, err = CheckCommitSignature(repo, commit); err != nil {
+		t.Fatal(err)
+	}
+	if check.Status != SignatureGood {
+		t.Errorf("status %c (%s), want %c", check.Status, check, SignatureGood)
+	}
+	if len(read) != 1 {
+		t.Errorf("read host files %q, want the allowed signers file", read)
+	}
+}
+
+func TestTagSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal string
+		tagger    string
+		want      SignatureStatus
+	}{
+		{"signed by an allowed signer", "test@example.com", signer, SignatureGood},
+		{"signed by an unknown key", "", signer, SignatureUntrusted},
+		{"key allowed for someone else", "test@example.com", "Someone <x@example.com>", SignatureUntrusted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newSigningRepo(t, test.principal)
+			tag := &models.Tag{Object: headID(t, repo), Name: "v1", Tagger: test.tagger, Timestamp: time.Unix(1700000000, 0), Message: "v1"}
+			var err error
+			if tag.Signature, err = signPayload(repo, vcs_operations.TagPayload(tag)); err != nil {
+				t.Fatal(err)
+			}
+			check, err := verify(repo, vcs_operations.TagPayload(tag), tag.Signature, tag.Tagger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if check.Status != test.want {
+				t.Errorf("status %c (%s), want %c", check.Status, check, test.want)
+			}
+
+			tag.Message = "v2"
+			if check, err = verify(repo, vcs_operations.TagPayload(tag), tag.Signature, tag.Tagger); err != nil {
+				t.Fatal(err)
+			}
+			if check.Status != SignatureBad {
+				t.Errorf("changed tag has status %c, want a bad signature", check.Status)
+			}
+		})
+	}
+}
+
+func TestVerifyTag(t *testing.T) {
+	repo := newSigningRepo(t, "test@example.com")
+	if _, err := TagHandler(repo, "signed", "", TagOptions{Sign: true, Message: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, check, err := VerifyTag(repo, "signed"); err != nil {
+		t.Fatal(err)
+	} else if check.Status != SignatureGood {
+		t.Errorf("signed tag has status %c (%s), want %c", check.Status, check, SignatureGood)
+	}
+
+	if _, err := TagHandler(repo, "annotated", "", TagOptions{Message: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, check, err := VerifyTag(repo, "annotated"); err != nil {
+		t.Fatal(err)
+	} else if check.Status != SignatureNone {
+		t.Errorf("unsigned tag has status %c, want %c", check.Status, SignatureNone)
+	}
+
+	if _, err := TagHandler(repo, "light", "", TagOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := VerifyTag(repo, "light"); err == nil {
+		t.Error("verifying a lightweight tag succeeded")
+	}
+}
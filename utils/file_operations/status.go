@@ -0,0 +1,409 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileStatus describes how a tracked file differs between HEAD, the INDEX and the working tree.
+// Staged and Unstaged use the letters of Git's short format: 'M' for modified, 'A' for added,
+// 'D' for deleted, 'R' for renamed and '.' for unchanged.
+type FileStatus struct {
+	Path     string
+	OrigPath string // Path the file was renamed from, for a staged rename
+	Staged   byte   // Change from HEAD to the INDEX
+	Unstaged byte   // Change from the INDEX to the working tree
+	HeadID   string // Blob ID in HEAD, empty if HEAD does not have the file
+	IndexID  string // Blob ID in the INDEX, empty if the INDEX does not have the file
+	InWork   bool   // The file exists in the working tree
+}
+
+// Status is the state of the working tree compared with the INDEX and HEAD.
+type Status struct {
+	Branch       string
+	Head         string // Commit HEAD points to, empty on a branch without commits
+	Upstream     string // Branch the current branch tracks, if configured
+	UpstreamGone bool   // The upstream branch is configured but does not exist
+	Ahead        int    // Commits on the current branch that the upstream does not have
+	Behind       int    // Commits on the upstream that the current branch does not have
+	Files        []FileStatus
+	Untracked    []string // Untracked files, and directories without tracked files ending in "/"
+}
+
+// Clean reports whether there is nothing to commit and nothing untracked.
+func (s *Status) Clean() bool {
+	return len(s.Files) == 0 && len(s.Untracked) == 0
+}
+
+// StatusFormat selects how StatusHandler prints the status.
+type StatusFormat int
+
+const (
+	// StatusLong is the human readable format with one section per kind of change.
+	StatusLong StatusFormat = iota
+	// StatusShort prints one line per file with its staged and unstaged change (-s).
+	StatusShort
+	// StatusPorcelain is the short format with paths relative to the repository root (--porcelain).
+	StatusPorcelain
+	// StatusPorcelainV2 is the detailed format for scripts, listing modes and IDs (--porcelain=v2).
+	StatusPorcelainV2
+)
+
+// StatusOptions controls the output of StatusHandler.
+type StatusOptions struct {
+	Format StatusFormat
+	Branch bool // Include the branch and upstream in the short and porcelain formats (-b)
+}
+
+// GetStatus compares HEAD, the INDEX and the working tree. Working tree files are hashed
+// without being stored, so the object store is never written to. Staged deletions and
+// additions with the same content are reported as renames.
+func GetStatus(repo *models.Repository) (*Status, error) {
+	status := &Status{}
+	var err error
+	if status.Branch, err = vcs_operations.CurrentBranch(repo); err != nil {
+		return nil, err
+	}
+	if status.Head, err = vcs_operations.GetCurrentHeadCommit(repo); err != nil {
+		return nil, err
+	}
+	if err := branchUpstream(repo, status); err != nil {
+		return nil, err
+	}
+
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading HEAD tree: %w", err)
+	}
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	index := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		index[entry.Path] = entry.Hash
+	}
+
+	// Pair deleted and added files with the same content as renames
+	added := make(map[string]string)
+	deleted := make(map[string]string)
+	for filePath, id := range index {
+		if _, ok := headFiles[filePath]; !ok {
+			added[filePath] = id
+		}
+	}
+	for filePath, id := range headFiles {
+		if _, ok := index[filePath]; !ok {
+			deleted[filePath] = id
+		}
+	}
+	renames := vcs_operations.DetectRenames(deleted, added)
+	for _, oldPath := range renames {
+		delete(deleted, oldPath)
+	}
+
+	paths := make(map[string]bool)
+	for _, files := range []map[string]string{index, deleted} {
+		for filePath := range files {
+			paths[filePath] = true
+		}
+	}
+	for _, filePath := range sortedKeys(paths) {
+		file := FileStatus{Path: filePath, Staged: '.', Unstaged: '.', HeadID: headFiles[filePath], IndexID: index[filePath]}
+		switch {
+		case renames[filePath] != "":
+			file.Staged, file.OrigPath = 'R', renames[filePath]
+			file.HeadID = headFiles[file.OrigPath]
+		case file.HeadID == "":
+			file.Staged = 'A'
+		case file.IndexID == "":
+			file.Staged = 'D'
+		case file.HeadID != file.IndexID:
+			file.Staged = 'M'
+		}
+
+		if file.IndexID != "" {
+			workID, exists, err := workTreeBlobID(repo, filePath)
+			if err != nil {
+				return nil, fmt.Errorf("error hashing %s: %w", filePath, err)
+			}
+			file.InWork = exists
+			if !exists {
+				file.Unstaged = 'D'
+			} else if workID != file.IndexID {
+				file.Unstaged = 'M'
+			}
+		}
+
+		if file.Staged != '.' || file.Unstaged != '.' {
+			status.Files = append(status.Files, file)
+		}
+	}
+
+	status.Untracked, err = untrackedFiles(repo, index)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// branchUpstream fills in the upstream of the current branch and how far the two have diverged.
+func branchUpstream(repo *models.Repository, status *Status) error {
+	config, err := vcs_operations.LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	status.Upstream = strings.TrimPrefix(config.Branches[status.Branch].Merge, "refs/heads/")
+	if status.Upstream == "" {
+		return nil
+	}
+
+	upstreamID, err := vcs_operations.ReadBranchRef(repo, status.Upstream)
+	if err != nil {
+		status.UpstreamGone = true
+		return nil
+	}
+	if status.Head == "" || upstreamID == "" {
+		return nil
+	}
+	status.Ahead, status.Behind, err = vcs_operations.AheadBehind(repo, status.Head, upstreamID)
+	return err
+}
+
+// untrackedFiles lists the files of the working tree that are neither in the INDEX nor ignored.
+// A directory without any tracked file is listed once, with a trailing slash, instead of its
+// files.
+func untrackedFiles(repo *models.Repository, index map[string]string) ([]string, error) {
+	matcher, err := LoadIgnoreMatcher(repo)
+	if err != nil {
+		return nil, err
+	}
+	files, err := getAllFilesInDir(repo.WorkTree, matcher)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving files from working directory: %w", err)
+	}
+
+	trackedDirs := make(map[string]bool)
+	for filePath := range index {
+		for dir := path.Dir(filePath); dir != "."; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+
+	untracked := make(map[string]bool)
+	for _, filePath := range files {
+		if _, ok := index[filePath]; ok {
+			continue
+		}
+		// Collapse to the outermost directory that holds nothing tracked
+		name := filePath
+		for dir := path.Dir(filePath); dir != "."; dir = path.Dir(dir) {
+			if !trackedDirs[dir] {
+				name = dir + "/"
+			}
+		}
+		untracked[name] = true
+	}
+	return sortedKeys(untracked), nil
+}
+
+// StatusHandler writes the status of the working tree to w in the given format. The long and
+// short formats show paths relative to baseDir, usually the current directory, or to the
+// repository root if baseDir is empty; the porcelain formats always use the repository root.
+func StatusHandler(repo *models.Repository, w io.Writer, baseDir string, opts StatusOptions) error {
+	status, err := GetStatus(repo)
+	if err != nil {
+		return err
+	}
+
+	displayPath := func(relPath string) string { return relPath }
+	if baseDir != "" && (opts.Format == StatusLong || opts.Format == StatusShort) {
+		displayPath = func(relPath string) string {
+			displayed, err := filepath.Rel(baseDir, repo.WorkPath(relPath))
+			if err != nil {
+				return relPath
+			}
+			displayed = filepath.ToSlash(displayed)
+			if strings.HasSuffix(relPath, "/") {
+				displayed += "/"
+			}
+			return displayed
+		}
+	}
+
+	switch opts.Format {
+	case StatusShort, StatusPorcelain:
+		writeShortStatus(w, status, opts.Branch, displayPath)
+	case StatusPorcelainV2:
+		writePorcelainV2Status(w, status, repo.Hash().ZeroID(), opts.Branch)
+	default:
+		writeLongStatus(w, status, displayPath)
+	}
+	return nil
+}
+
+// writeLongStatus prints the status in sections like "git status" does.
+func writeLongStatus(w io.Writer, status *Status, displayPath func(string) string) {
+	fmt.Fprintf(w, "On branch %s\n", status.Branch)
+	switch {
+	case status.Upstream == "":
+	case status.UpstreamGone:
+		fmt.Fprintf(w, "Your branch is based on '%s', but the upstream is gone.\n", status.Upstream)
+	case status.Ahead > 0 && status.Behind > 0:
+		fmt.Fprintf(w, "Your branch and '%s' have diverged,\nand have %d and %d different commits each, respectively.\n", status.Upstream, status.Ahead, status.Behind)
+	case status.Ahead > 0:
+		fmt.Fprintf(w, "Your branch is ahead of '%s' by %s.\n", status.Upstream, plural(status.Ahead, "commit"))
+	case status.Behind > 0:
+		fmt.Fprintf(w, "Your branch is behind '%s' by %s, and can be fast-forwarded.\n", status.Upstream, plural(status.Behind, "commit"))
+	default:
+		fmt.Fprintf(w, "Your branch is up to date with '%s'.\n", status.Upstream)
+	}
+	if status.Head == "" {
+		fmt.Fprintln(w, "\nNo commits yet")
+	}
+
+	labels := map[byte]string{'M': "modified:", 'A': "new file:", 'D': "deleted:", 'R': "renamed:"}
+	var staged, unstaged []string
+	for _, file := range status.Files {
+		if file.Staged != '.' {
+			name := displayPath(file.Path)
+			if file.Staged == 'R' {
+				name = displayPath(file.OrigPath) + " -> " + name
+			}
+			staged = append(staged, fmt.Sprintf("\t%-12s%s", labels[file.Staged], name))
+		}
+		if file.Unstaged != '.' {
+			unstaged = append(unstaged, fmt.Sprintf("\t%-12s%s", labels[file.Unstaged], displayPath(file.Path)))
+		}
+	}
+	var untracked []string
+	for _, name := range status.Untracked {
+		untracked = append(untracked, "\t"+displayPath(name))
+	}
+
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Changes to be committed:", staged},
+		{"Changes not staged for commit:", unstaged},
+		{"Untracked files:", untracked},
+	} {
+		if len(section.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n%s\n", section.title, strings.Join(section.lines, "\n"))
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case len(staged) > 0:
+	case len(unstaged) > 0:
+		fmt.Fprintln(w, `no changes added to commit (use "gitx add")`)
+	case len(untracked) > 0:
+		fmt.Fprintln(w, `nothing added to commit but untracked files present (use "gitx add" to track)`)
+	default:
+		fmt.Fprintln(w, "nothing to commit, working tree clean")
+	}
+}
+
+// writeShortStatus prints one "XY path" line per file, with "??" for untracked files.
+func writeShortStatus(w io.Writer, status *Status, branch bool, displayPath func(string) string) {
+	if branch {
+		line := "## " + status.Branch
+		if status.Head == "" {
+			line = "## No commits yet on " + status.Branch
+		}
+		if status.Upstream != "" {
+			line += "..." + status.Upstream
+			var counts []string
+			if status.UpstreamGone {
+				counts = append(counts, "gone")
+			}
+			if status.Ahead > 0 {
+				counts = append(counts, fmt.Sprintf("ahead %d", status.Ahead))
+			}
+			if status.Behind > 0 {
+				counts = append(counts, fmt.Sprintf("behind %d", status.Behind))
+			}
+			if len(counts) > 0 {
+				line += " [" + strings.Join(counts, ", ") + "]"
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	short := func(change byte) byte {
+		if change == '.' {
+			return ' '
+		}
+		return change
+	}
+	for _, file := range status.Files {
+		name := displayPath(file.Path)
+		if file.Staged == 'R' {
+			name = displayPath(file.OrigPath) + " -> " + name
+		}
+		fmt.Fprintf(w, "%c%c %s\n", short(file.Staged), short(file.Unstaged), name)
+	}
+	for _, name := range status.Untracked {
+		fmt.Fprintf(w, "?? %s\n", displayPath(name))
+	}
+}
+
+// writePorcelainV2Status prints the status in Git's porcelain v2 format: optional "# branch"
+// headers, then "1" lines for changed files, "2" lines for renames and "?" lines for untracked
+// files. zeroID stands for a missing object.
+func writePorcelainV2Status(w io.Writer, status *Status, zeroID string, branch bool) {
+	if branch {
+		oid := status.Head
+		if oid == "" {
+			oid = "(initial)"
+		}
+		fmt.Fprintf(w, "# branch.oid %s\n# branch.head %s\n", oid, status.Branch)
+		if status.Upstream != "" {
+			fmt.Fprintf(w, "# branch.upstream %s\n", status.Upstream)
+			if !status.UpstreamGone {
+				fmt.Fprintf(w, "# branch.ab +%d -%d\n", status.Ahead, status.Behind)
+			}
+		}
+	}
+
+	mode := func(present bool) string {
+		if present {
+			return "100644"
+		}
+		return "000000"
+	}
+	id := func(id string) string {
+		if id == "" {
+			return zeroID
+		}
+		return id
+	}
+	for _, file := range status.Files {
+		fields := fmt.Sprintf("%c%c N... %s %s %s %s %s", file.Staged, file.Unstaged,
+			mode(file.HeadID != ""), mode(file.IndexID != ""), mode(file.InWork), id(file.HeadID), id(file.IndexID))
+		if file.Staged == 'R' {
+			fmt.Fprintf(w, "2 %s R100 %s\t%s\n", fields, file.Path, file.OrigPath)
+		} else {
+			fmt.Fprintf(w, "1 %s %s\n", fields, file.Path)
+		}
+	}
+	for _, name := range status.Untracked {
+		fmt.Fprintf(w, "? %s\n", name)
+	}
+}
+
+// plural formats a count followed by the noun, adding an "s" unless the count is one.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,164 @@
+package file_operations
+
+import (
+	"GitX/utils/vcs_operations"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatusHandler(t *testing.T) {
+	repo := newTestRepo(t)
+	addFile(t, repo, ".gitxignore")
+	commitFiles(t, repo, "first", map[string]string{
+		"a.txt":     "a\n",
+		"b.txt":     "b\n",
+		"dir/c.txt": "c\n",
+		"old.txt":   "old\n",
+	})
+	writeFile(t, repo, "a.txt", "changed\n")
+	if err := repo.WorkTree.Remove("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "d.txt", "d\n")
+	addFile(t, repo, "d.txt")
+	if err := MoveHandler(repo, []string{workPath(repo, "old.txt")}, workPath(repo, "new.txt"), false); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "dir/e.txt", "e\n")
+	writeFile(t, repo, "new/x.txt", "x\n")
+
+	tests := []struct {
+		name    string
+		baseDir string
+		opts    StatusOptions
+		want    string
+	}{
+		{
+			name: "short",
+			opts: StatusOptions{Format: StatusShort},
+			want: " M a.txt\n D b.txt\nA  d.txt\nR  old.txt -> new.txt\n?? dir/e.txt\n?? new/\n",
+		},
+		{
+			name:    "short in a subdirectory",
+			baseDir: workPath(repo, "dir"),
+			opts:    StatusOptions{Format: StatusShort},
+			want:    " M ../a.txt\n D ../b.txt\nA  ../d.txt\nR  ../old.txt -> ../new.txt\n?? e.txt\n?? ../new/\n",
+		},
+		{
+			name:    "porcelain ignores the directory",
+			baseDir: workPath(repo, "dir"),
+			opts:    StatusOptions{Format: StatusPorcelain, Branch: true},
+			want:    "## main\n M a.txt\n D b.txt\nA  d.txt\nR  old.txt -> new.txt\n?? dir/e.txt\n?? new/\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := StatusHandler(repo, &out, test.baseDir, test.opts); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("status is\n%s\nwant\n%s", out.String(), test.want)
+			}
+		})
+	}
+
+	t.Run("long", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := StatusHandler(repo, &out, "", StatusOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"On branch main\n",
+			"Changes to be committed:\n\tnew file:   d.txt\n\trenamed:    old.txt -> new.txt\n",
+			"Changes not staged for commit:\n\tmodified:   a.txt\n\tdeleted:    b.txt\n",
+			"Untracked files:\n\tdir/e.txt\n\tnew/\n",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("long status is missing %q:\n%s", want, out.String())
+			}
+		}
+	})
+
+	t.Run("porcelain v2", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := StatusHandler(repo, &out, "", StatusOptions{Format: StatusPorcelainV2}); err != nil {
+			t.Fatal(err)
+		}
+		index := indexHashes(t, repo)
+		want := "1 A. N... 000000 100644 100644 " + repo.Hash().ZeroID() + " " + index["d.txt"] + " d.txt\n"
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("porcelain v2 status is missing %q:\n%s", want, out.String())
+		}
+		if !strings.Contains(out.String(), " R100 new.txt\told.txt\n") {
+			t.Errorf("porcelain v2 status does not show the rename:\n%s", out.String())
+		}
+	})
+}
+
+func TestStatusClean(t *testing.T) {
+	repo := newTestRepo(t)
+	addFile(t, repo, ".gitxignore")
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+
+	status, err := GetStatus(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Clean() {
+		t.Errorf("status after a commit has changes: %+v", status)
+	}
+	// Status only hashes the working tree, without writing objects
+	before, err := repo.Store.ReadDir("objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repo, "a.txt", "changed\n")
+	if _, err := GetStatus(repo); err != nil {
+		t.Fatal(err)
+	}
+	after, err := repo.Store.ReadDir("objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("status wrote objects: %d object directories, had %d", len(after), len(before))
+	}
+}
+
+func TestStatusUpstream(t *testing.T) {
+	repo := newTestRepo(t)
+	addFile(t, repo, ".gitxignore")
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	if err := vcs_operations.CreateBranch(repo, "base"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUpstream(repo, "main", "base"); err != nil {
+		t.Fatal(err)
+	}
+
+	branchLine := func() string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := StatusHandler(repo, &out, "", StatusOptions{Format: StatusShort, Branch: true}); err != nil {
+			t.Fatal(err)
+		}
+		line, _, _ := strings.Cut(out.String(), "\n")
+		return line
+	}
+	if got := branchLine(); got != "## main...base" {
+		t.Errorf("branch line = %q, want %q", got, "## main...base")
+	}
+	commitFiles(t, repo, "second", map[string]string{"b.txt": "b\n"})
+	commitFiles(t, repo, "third", map[string]string{"c.txt": "c\n"})
+	if got := branchLine(); got != "## main...base [ahead 2]" {
+		t.Errorf("branch line = %q, want %q", got, "## main...base [ahead 2]")
+	}
+	if err := repo.Store.Remove("refs/heads/base"); err != nil {
+		t.Fatal(err)
+	}
+	if got := branchLine(); got != "## main...base [gone]" {
+		t.Errorf("branch line = %q, want %q", got, "## main...base [gone]")
+	}
+}
@@ -0,0 +1,168 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"fmt"
+	"strings"
+)
+
+// SwitchHandler switches to the given branch, updating the INDEX and the working tree to the
+// branch's commit. With create, the branch is first created at startPoint (HEAD if empty).
+// Local changes are carried over when the branches agree on the file; the switch is refused
+// with models.ErrConflict when it would overwrite local changes or untracked files, in which
+// case no branch is created either.
+func SwitchHandler(repo *models.Repository, branchName string, create bool, startPoint string) error {
+	var targetID string
+	if create {
+		if startPoint == "" {
+			startPoint = "HEAD"
+		}
+		startID, err := vcs_operations.ResolveRevision(repo, startPoint)
+		if err != nil {
+			return err
+		}
+		if _, err := vcs_operations.ReadBranchRef(repo, branchName); err == nil {
+			return fmt.Errorf("%w: %s", models.ErrBranchExists, branchName)
+		}
+		targetID = startID
+	} else {
+		id, err := vcs_operations.ReadBranchRef(repo, branchName)
+		if err != nil {
+			return fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, branchName)
+		}
+		targetID = id
+	}
+
+	currentBranch, err := vcs_operations.CurrentBranch(repo)
+	if err != nil {
+		return err
+	}
+	if currentBranch == branchName {
+		return nil
+	}
+
+	headFiles, err := vcs_operations.HeadTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	targetFiles := make(map[string]string)
+	if targetID != "" {
+		target, err := vcs_operations.GetCommitByHash(repo, targetID)
+		if err != nil {
+			return err
+		}
+		targetFiles = treeFiles(target.Tree)
+	}
+
+	if err := updateWorkTree(repo, headFiles, targetFiles, "switch"); err != nil {
+		return err
+	}
+
+	// A new branch is only created once the working tree could be switched to it
+	if create {
+		if err := vcs_operations.CreateBranchRef(repo, branchName, targetID); err != nil {
+			return fmt.Errorf("error creating branch ref file: %w", err)
+		}
+		if err := vcs_operations.AppendReflog(repo, "refs/heads/"+branchName, "", targetID, "branch: Created from "+startPoint); err != nil {
+			return err
+		}
+	}
+
+	oldID, err := vcs_operations.GetCurrentHeadCommit(repo)
+	if err != nil {
+		return err
+	}
+	if err := vcs_operations.UpdateHEAD(repo, "refs/heads/"+branchName); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return vcs_operations.AppendReflog(repo, "HEAD", oldID, targetID, fmt.Sprintf("checkout: moving from %s to %s", currentBranch, branchName))
+}
+
+// updateWorkTree moves the INDEX and the working tree from headFiles to targetFiles, both mapping
+// paths to blob IDs. Only the files that differ between the two are touched. The update is
+// refused with models.ErrConflict, naming the operation, when it would overwrite local changes
+// or untracked files.
+func updateWorkTree(repo *models.Repository, headFiles, targetFiles map[string]string, operation string) error {
+	entries, err := vcs_operations.ReadIndexFile(repo)
+	if err != nil {
+		return fmt.Errorf("error reading INDEX file: %w", err)
+	}
+	index := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		index[entry.Path] = entry.Hash
+	}
+
+	// Only the files that differ between the two commits are touched, and only if the INDEX and
+	// the working tree still hold the HEAD version
+	changed := make(map[string]bool)
+	for _, files := range []map[string]string{headFiles, targetFiles} {
+		for filePath := range files {
+			if headFiles[filePath] != targetFiles[filePath] {
+				changed[filePath] = true
+			}
+		}
+	}
+
+	var localChanges, untracked []string
+	for _, filePath := range sortedKeys(changed) {
+		workID, exists, err := workTreeBlobID(repo, filePath)
+		if err != nil {
+			return fmt.Errorf("error hashing %s: %w", filePath, err)
+		}
+		indexID, inIndex := index[filePath]
+		headID, inHead := headFiles[filePath]
+
+		switch {
+		case !inIndex && !inHead:
+			if exists && workID != targetFiles[filePath] {
+				untracked = append(untracked, filePath)
+			}
+		case indexID != headID:
+			// Staged changes, including staged additions and deletions
+			localChanges = append(localChanges, filePath)
+		case exists && workID != indexID, !exists && targetFiles[filePath] != "":
+			localChanges = append(localChanges, filePath)
+		}
+	}
+	if len(localChanges) > 0 || len(untracked) > 0 {
+		var message strings.Builder
+		if len(localChanges) > 0 {
+			fmt.Fprintf(&message, "your local changes to the following files would be overwritten by %s:\n", operation)
+			for _, filePath := range localChanges {
+				fmt.Fprintf(&message, "\t%s\n", filePath)
+			}
+			fmt.Fprintf(&message, "commit your changes or stash them before you %s", operation)
+		}
+		if len(untracked) > 0 {
+			if message.Len() > 0 {
+				message.WriteString("\n")
+			}
+			fmt.Fprintf(&message, "the following untracked working tree files would be overwritten by %s:\n", operation)
+			for _, filePath := range untracked {
+				fmt.Fprintf(&message, "\t%s\n", filePath)
+			}
+			fmt.Fprintf(&message, "move or remove them before you %s", operation)
+		}
+		return fmt.Errorf("%w: %s", models.ErrConflict, message.String())
+	}
+
+	for _, filePath := range sortedKeys(changed) {
+		if err := checkoutFile(repo, filePath, targetFiles[filePath]); err != nil {
+			return err
+		}
+		if id, ok := targetFiles[filePath]; ok {
+			index[filePath] = id
+		} else {
+			delete(index, filePath)
+		}
+	}
+	entries = entries[:0]
+	for _, filePath := range sortedKeys(index) {
+		entries = append(entries, &models.IndexEntry{Mode: "100644", Type: "blob", Hash: index[filePath], Path: filePath})
+	}
+	if err := vcs_operations.WriteIndexFile(repo, entries); err != nil {
+		return fmt.Errorf("error writing to INDEX file: %w", err)
+	}
+	return nil
+}
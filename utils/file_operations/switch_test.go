@@ -0,0 +1,67 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"testing"
+)
+
+func TestSwitchHandler(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	if err := SwitchHandler(repo, "topic", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, "topic", map[string]string{"a.txt": "topic\n", "c.txt": "c\n"})
+
+	// Local changes to files both branches agree on are carried over
+	writeFile(t, repo, "b.txt", "local\n")
+	if err := SwitchHandler(repo, "main", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := vcs_operations.CurrentBranch(repo); branch != "main" {
+		t.Errorf("current branch is %s, want main", branch)
+	}
+	for name, want := range map[string]string{"a.txt": "a\n", "b.txt": "local\n", "c.txt": ""} {
+		if got := readFile(t, repo, name); got != want {
+			t.Errorf("%s = %q after switching to main, want %q", name, got, want)
+		}
+	}
+	if head, _ := vcs_operations.GetCurrentHeadCommit(repo); head != first.ID {
+		t.Errorf("HEAD is %s, want %s", head, first.ID)
+	}
+
+	if err := SwitchHandler(repo, "missing", false, ""); !errors.Is(err, models.ErrRefNotFound) {
+		t.Errorf("switching to a missing branch returns %v, want ErrRefNotFound", err)
+	}
+	if err := SwitchHandler(repo, "topic", true, ""); !errors.Is(err, models.ErrBranchExists) {
+		t.Errorf("creating an existing branch returns %v, want ErrBranchExists", err)
+	}
+}
+
+func TestSwitchRefused(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFiles(t, repo, "first", map[string]string{"a.txt": "a\n"})
+	if err := SwitchHandler(repo, "topic", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, "topic", map[string]string{"a.txt": "topic\n"})
+	writeFile(t, repo, "a.txt", "local\n")
+
+	if err := SwitchHandler(repo, "main", false, ""); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("switching over local changes returns %v, want ErrConflict", err)
+	}
+	if err := SwitchHandler(repo, "other", true, "main"); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("creating a branch over local changes returns %v, want ErrConflict", err)
+	}
+	if _, err := vcs_operations.ReadBranchRef(repo, "other"); err == nil {
+		t.Error("a refused switch -c leaves the new branch behind")
+	}
+	if branch, _ := vcs_operations.CurrentBranch(repo); branch != "topic" {
+		t.Errorf("current branch is %s, want topic", branch)
+	}
+	if got := readFile(t, repo, "a.txt"); got != "local\n" {
+		t.Errorf("a.txt = %q, want the local changes kept", got)
+	}
+}
@@ -0,0 +1,76 @@
+package file_operations
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TagOptions configures TagHandler.
+type TagOptions struct {
+	// Annotate creates an annotated tag object holding Message, instead of a lightweight tag
+	// pointing directly at the commit. Giving a message or signing also annotates.
+	Annotate bool
+	Message  string
+	// Sign signs the tag with the key of user.signingkey.
+	Sign bool
+	// Force replaces an existing tag of the same name.
+	Force bool
+}
+
+// TagHandler creates the tag called name for the commit named by rev, HEAD if rev is empty. It
+// returns the ID the tag points to: the commit for a lightweight tag, or the tag object.
+func TagHandler(repo *models.Repository, name, rev string, opts TagOptions) (string, error) {
+	if err := vcs_operations.CheckTagName(name); err != nil {
+		return "", err
+	}
+	if !opts.Force {
+		if _, err := vcs_operations.ReadTagRef(repo, name); err == nil {
+			return "", fmt.Errorf("%w: %s", models.ErrTagExists, name)
+		} else if !errors.Is(err, models.ErrRefNotFound) {
+			return "", err
+		}
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	commitID, err := vcs_operations.ResolveRevision(repo, rev)
+	if err != nil {
+		return "", err
+	}
+
+	id := commitID
+	if opts.Annotate || opts.Sign || opts.Message != "" {
+		if strings.TrimSpace(opts.Message) == "" {
+			return "", errors.New("an annotated tag needs a message")
+		}
+		tagger, err := vcs_operations.GetCurrentUser(repo, vcs_operations.RoleCommitter)
+		if err != nil {
+			return "", err
+		}
+		tag := &models.Tag{
+			Object:    commitID,
+			Name:      name,
+			Tagger:    tagger,
+			Timestamp: time.Now(),
+			Message:   opts.Message,
+		}
+		if opts.Sign {
+			if tag.Signature, err = signPayload(repo, vcs_operations.TagPayload(tag)); err != nil {
+				return "", fmt.Errorf("error signing tag: %w", err)
+			}
+		}
+		if err := vcs_operations.WriteTag(repo, tag); err != nil {
+			return "", err
+		}
+		id = tag.ID
+	}
+
+	if err := vcs_operations.CreateTagRef(repo, name, id); err != nil {
+		return "", fmt.Errorf("error writing tag ref: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,276 @@
+package vcs_operations
+
+import (
+	"GitX/internal/commitgraph"
+	"GitX/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// commitGraphFile is the location of the commit-graph file in the store.
+const commitGraphFile = "commit-graph"
+
+// ReadCommitGraph reads the commit-graph file. A repository without one has an empty graph.
+func ReadCommitGraph(repo *models.Repository) (*commitgraph.Graph, error) {
+	content, err := repo.Store.ReadFile(commitGraphFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return commitgraph.New(repo.Hash()), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit-graph: %w", err)
+	}
+	return commitgraph.Parse(repo.Hash(), content)
+}
+
+// AddToCommitGraph records a new commit in the commit-graph file. Parents missing from the
+// graph, such as commits made before the repository had one, are added first. Only the new
+// lines are appended to the file.
+func AddToCommitGraph(repo *models.Repository, commit *models.Commit) error {
+	graph, err := ReadCommitGraph(repo)
+	if err != nil {
+		return err
+	}
+	size := len(graph.Commits)
+	if err := addCommit(repo, graph, commit); err != nil {
+		return err
+	}
+	if err := repo.Store.AppendFile(commitGraphFile, graph.BytesSince(size), 0644); err != nil {
+		return fmt.Errorf("error writing commit-graph: %w", err)
+	}
+	return nil
+}
+
+// WriteCommitGraph rebuilds the commit-graph file from the history reachable from every branch
+// and returns the number of commits it holds.
+func WriteCommitGraph(repo *models.Repository) (int, error) {
+	branches, err := GetBranches(repo)
+	if err != nil {
+		return 0, err
+	}
+	graph := commitgraph.New(repo.Hash())
+	for _, branch := range branches {
+		commitID, err := ReadBranchRef(repo, branch)
+		if err != nil {
+			return 0, fmt.Errorf("error reading branch %s: %w", branch, err)
+		}
+		if commitID == "" {
+			continue
+		}
+		if _, err := commitPosition(repo, graph, commitID); err != nil {
+			return 0, err
+		}
+	}
+	if err := repo.Store.WriteFile(commitGraphFile, graph.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("error writing commit-graph: %w", err)
+	}
+	return len(graph.Commits), nil
+}
+
+// loadCommitGraph reads the commit-graph file and adds the given commits to it in memory, along
+// with the ancestors it is missing. The file itself is left unchanged: only commit and
+// "commit-graph write" update it.
+func loadCommitGraph(repo *models.Repository, ids ...string) (*commitgraph.Graph, error) {
+	graph, err := ReadCommitGraph(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := commitPosition(repo, graph, id); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// commitPosition returns the position of the commit in the graph, reading it and the ancestors
+// the graph is missing from the commit files if needed.
+func commitPosition(repo *models.Repository, graph *commitgraph.Graph, id string) (int, error) {
+	if position, ok := graph.Lookup(id); ok {
+		return position, nil
+	}
+	commit, err := GetCommitByHash(repo, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := addCommit(repo, graph, commit); err != nil {
+		return 0, err
+	}
+	position, _ := graph.Lookup(id)
+	return position, nil
+}
+
+// addCommit adds the commit to the graph after its parents. The commit files are only read for
+// parents that are not in the graph yet, walking iteratively so long histories cannot overflow
+// the stack.
+func addCommit(repo *models.Repository, graph *commitgraph.Graph, commit *models.Commit) error {
+	pending := []*models.Commit{commit}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		if _, ok := graph.Lookup(current.ID); ok {
+			pending = pending[:len(pending)-1]
+			continue
+		}
+
+		var parentIDs []string
+		missing := false
+		for _, parent := range current.Parent {
+			if parent == nil {
+				continue
+			}
+			parentIDs = append(parentIDs, parent.ID)
+			if _, ok := graph.Lookup(parent.ID); !ok {
+				parentCommit, err := GetCommitByHash(repo, parent.ID)
+				if err != nil {
+					return err
+				}
+				pending = append(pending, parentCommit)
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+
+		if _, err := graph.Add(current.ID, commitDate(current), parentIDs); err != nil {
+			return err
+		}
+		pending = pending[:len(pending)-1]
+	}
+	return nil
+}
+
+// commitDate returns when the commit was made, which orders history like in Git: the committer
+// date, as amended commits keep their author date.
+func commitDate(commit *models.Commit) time.Time {
+	if commit.CommitTime.IsZero() {
+		return commit.Timestamp
+	}
+	return commit.CommitTime
+}
+
+// MergeBase returns the best common ancestor of the two commits, or an empty ID if their
+// histories are unrelated. When there are several, the most recent one is returned.
+func MergeBase(repo *models.Repository, a, b string) (string, error) {
+	graph, err := loadCommitGraph(repo, a, b)
+	if err != nil {
+		return "", err
+	}
+	positionA, _ := graph.Lookup(a)
+	positionB, _ := graph.Lookup(b)
+
+	bases := graph.MergeBases(positionA, positionB)
+	if len(bases) == 0 {
+		return "", nil
+	}
+	sort.Slice(bases, func(i, j int) bool {
+		return graph.Commits[bases[i]].Date > graph.Commits[bases[j]].Date
+	})
+	return graph.Commits[bases[0]].ID, nil
+}
+
+// IsAncestor reports whether commit a is reachable from commit b, counting b itself.
+func IsAncestor(repo *models.Repository, a, b string) (bool, error) {
+	graph, err := loadCommitGraph(repo, a, b)
+	if err != nil {
+		return false, err
+	}
+	positionA, _ := graph.Lookup(a)
+	positionB, _ := graph.Lookup(b)
+	return graph.IsAncestor(positionA, positionB), nil
+}
+
+// AheadBehind counts the commits reachable from commit a but not from commit b, and the
+// commits reachable from b but not from a.
+func AheadBehind(repo *models.Repository, a, b string) (ahead, behind int, err error) {
+	graph, err := loadCommitGraph(repo, a, b)
+	if err != nil {
+		return 0, 0, err
+	}
+	positionA, _ := graph.Lookup(a)
+	positionB, _ := graph.Lookup(b)
+	ahead, behind = graph.AheadBehind(positionA, positionB)
+	return ahead, behind, nil
+}
+
+// historyIDs returns the IDs of the commits reachable from the given commit, newest first.
+// Commits with the same date are ordered children first using their generation numbers.
+func historyIDs(repo *models.Repository, id string) ([]string, error) {
+	graph, err := loadCommitGraph(repo, id)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := graph.Lookup(id)
+	positions := newAncestors(graph, start, make(map[int]bool))
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := graph.Commits[positions[i]], graph.Commits[positions[j]]
+		if a.Date != b.Date {
+			return a.Date > b.Date
+		}
+		return a.Generation > b.Generation
+	})
+	ids := make([]string, len(positions))
+	for i, position := range positions {
+		ids[i] = graph.Commits[position].ID
+	}
+	return ids, nil
+}
+
+// CommitsBetween returns the IDs of the commits reachable from head but not from base, parents
+// before their children and older commits first. An empty base selects the whole history.
+func CommitsBetween(repo *models.Repository, base, head string) ([]string, error) {
+	ids := []string{head}
+	if base != "" {
+		ids = append(ids, base)
+	}
+	graph, err := loadCommitGraph(repo, ids...)
+	if err != nil {
+		return nil, err
+	}
+	visited := make(map[int]bool)
+	if base != "" {
+		position, _ := graph.Lookup(base)
+		newAncestors(graph, position, visited)
+	}
+	start, _ := graph.Lookup(head)
+	positions := newAncestors(graph, start, visited)
+
+	// A commit's generation is above its parents', so sorting by generation puts them first
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := graph.Commits[positions[i]], graph.Commits[positions[j]]
+		if a.Generation != b.Generation {
+			return a.Generation < b.Generation
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.ID < b.ID
+	})
+	result := make([]string, len(positions))
+	for i, position := range positions {
+		result[i] = graph.Commits[position].ID
+	}
+	return result, nil
+}
+
+// newAncestors returns the positions of the commit at start and of its ancestors that are not
+// visited yet, and marks them visited.
+func newAncestors(graph *commitgraph.Graph, start int, visited map[int]bool) []int {
+	if visited[start] {
+		return nil
+	}
+	visited[start] = true
+	positions := []int{start}
+	for i := 0; i < len(positions); i++ {
+		for _, parent := range graph.Commits[positions[i]].Parents {
+			if !visited[parent] {
+				visited[parent] = true
+				positions = append(positions, parent)
+			}
+		}
+	}
+	return positions
+}
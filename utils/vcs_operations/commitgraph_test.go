@@ -0,0 +1,94 @@
+package vcs_operations_test
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"testing"
+)
+
+// newBranch points a new branch at the commit and makes it the current branch.
+func newBranch(t *testing.T, repo *models.Repository, name string, commit *models.Commit) {
+	t.Helper()
+	if err := vcs_operations.CreateBranchRef(repo, name, commit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := vcs_operations.UpdateHEAD(repo, "refs/heads/"+name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommitGraph(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFile(t, repo, "a.txt", "a\n")
+	second := commitFile(t, repo, "b.txt", "b\n")
+	newBranch(t, repo, "topic", first)
+	third := commitFile(t, repo, "c.txt", "c\n")
+
+	// Every commit, including the initial one, is appended to the file as it is made
+	graph, err := vcs_operations.ReadCommitGraph(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Commits) != 4 {
+		t.Errorf("commit-graph holds %d commits, want 4", len(graph.Commits))
+	}
+	count, err := vcs_operations.WriteCommitGraph(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("WriteCommitGraph wrote %d commits, want 4", count)
+	}
+
+	base, err := vcs_operations.MergeBase(repo, second.ID, third.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base != first.ID {
+		t.Errorf("MergeBase = %s, want %s", base, first.ID)
+	}
+	for _, test := range []struct {
+		a, b *models.Commit
+		want bool
+	}{
+		{first, second, true},
+		{first, third, true},
+		{second, second, true},
+		{second, third, false},
+		{third, first, false},
+	} {
+		got, err := vcs_operations.IsAncestor(repo, test.a.ID, test.b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("IsAncestor(%q, %q) = %v, want %v", test.a.Message, test.b.Message, got, test.want)
+		}
+	}
+}
+
+func TestCommitGraphReadsDoNotWrite(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFile(t, repo, "a.txt", "a\n")
+	second := commitFile(t, repo, "b.txt", "b\n")
+	removeStore(t, repo, "commit-graph")
+
+	// Queries fill the graph in from the commit files without saving it
+	if ok, err := vcs_operations.IsAncestor(repo, first.ID, second.ID); err != nil || !ok {
+		t.Errorf("IsAncestor = %v, %v, want true", ok, err)
+	}
+	if fsys.Exists(repo.Store, "commit-graph") {
+		t.Error("IsAncestor wrote the commit-graph")
+	}
+
+	// The next commit brings the file back with its missing ancestors
+	commitFile(t, repo, "c.txt", "c\n")
+	graph, err := vcs_operations.ReadCommitGraph(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Commits) != 4 {
+		t.Errorf("commit-graph holds %d commits, want 4", len(graph.Commits))
+	}
+}
@@ -0,0 +1,58 @@
+package vcs_operations
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io/fs"
+	"os"
+)
+
+// Roles of the identities returned by GetCurrentUser. Each names the environment variables
+// that override the configured identity, such as GITX_AUTHOR_NAME and GITX_AUTHOR_EMAIL.
+const (
+	RoleAuthor    = "AUTHOR"
+	RoleCommitter = "COMMITTER"
+)
+
+// LoadConfig reads the configuration from a file.
+func LoadConfig(store fsys.FS, filePath string) (*models.GitXConfig, error) {
+	config := &models.GitXConfig{}
+	data, err := store.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// If the config file does not exist, return an empty config with no error
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := toml.Decode(string(data), config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// GetCurrentUser returns the identity of the current user in the given role, as
+// "Name <email>". It is taken from user.name and user.email in the repository config, which
+// GITX_<role>_NAME and GITX_<role>_EMAIL override. An error wrapping models.ErrIdentityUnknown
+// is returned if the name or the email is not set.
+func GetCurrentUser(repo *models.Repository, role string) (string, error) {
+	config, err := LoadConfig(repo.Store, "config.toml")
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	name, email := config.UserName, config.UserEmail
+	if value := os.Getenv("GITX_" + role + "_NAME"); value != "" {
+		name = value
+	}
+	if value := os.Getenv("GITX_" + role + "_EMAIL"); value != "" {
+		email = value
+	}
+	if name == "" || email == "" {
+		return "", fmt.Errorf("%w: set user.name and user.email with gitx config", models.ErrIdentityUnknown)
+	}
+	return fmt.Sprintf("%s <%s>", name, email), nil
+}
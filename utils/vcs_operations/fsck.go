@@ -0,0 +1,449 @@
+package vcs_operations
+
+import (
+	"GitX/internal/hash"
+	"GitX/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FsckProblem describes a single integrity issue found while checking the repository.
+type FsckProblem struct {
+	Kind   string // "corrupt", "missing", "broken" or "dangling"
+	Type   string // "blob", "tree", "commit", "tag", "ref", "reflog", "index" or "commit-graph"
+	ID     string // Object ID, ref name or index file name
+	Detail string // Optional human readable explanation
+}
+
+// String formats the problem as a single machine-readable line: "<kind> <type> <id>[\t<detail>]".
+func (p FsckProblem) String() string {
+	line := fmt.Sprintf("%s %s %s", p.Kind, p.Type, p.ID)
+	if p.Detail != "" {
+		line += "\t" + p.Detail
+	}
+	return line
+}
+
+// Fatal reports whether the problem means the repository is damaged.
+// Dangling objects are only unreachable, so they are reported but not fatal.
+func (p FsckProblem) Fatal() bool {
+	return p.Kind != "dangling"
+}
+
+// FsckReport collects all the problems found by Fsck.
+type FsckReport struct {
+	Problems []FsckProblem
+}
+
+// HasErrors reports whether any fatal problem was found.
+func (r *FsckReport) HasErrors() bool {
+	for _, problem := range r.Problems {
+		if problem.Fatal() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *FsckReport) add(kind, objectType, id, detail string) {
+	r.Problems = append(r.Problems, FsckProblem{Kind: kind, Type: objectType, ID: id, Detail: detail})
+}
+
+// Fsck verifies the integrity of the repository. It rehashes every stored object,
+// checks that commits and trees only reference existing objects, validates the refs,
+// the INDEX checksum and the commit-graph, and reports objects that are not reachable
+// from any ref or reflog entry.
+func Fsck(repo *models.Repository) (*FsckReport, error) {
+	report := &FsckReport{}
+
+	blobs, err := fsckObjects(repo, report)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := fsckCommits(repo, blobs, report)
+	if err != nil {
+		return nil, err
+	}
+
+	tips, err := fsckRefs(repo, commits, report)
+	if err != nil {
+		return nil, err
+	}
+	tagTips, err := fsckTags(repo, commits, report)
+	if err != nil {
+		return nil, err
+	}
+	tips = append(tips, tagTips...)
+	tips = append(tips, fsckReflog(repo, commits, report)...)
+
+	// Blobs staged in the index are referenced even if they are not committed yet
+	referencedBlobs := make(map[string]bool)
+	indexEntries, err := ReadIndexFile(repo)
+	if err != nil {
+		report.add("corrupt", "index", "INDEX", err.Error())
+	}
+	for _, entry := range indexEntries {
+		referencedBlobs[entry.Hash] = true
+		if !blobs[entry.Hash] {
+			report.add("missing", "blob", entry.Hash, "referenced by index at "+entry.Path)
+		}
+	}
+
+	fsckCommitGraph(repo, commits, report)
+
+	// Walk the history from every ref tip and reflog entry to find the reachable commits
+	reachable := make(map[string]bool)
+	stack := tips
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[id] {
+			continue
+		}
+		reachable[id] = true
+		if commit, ok := commits[id]; ok {
+			for _, parent := range commit.Parent {
+				if parent != nil {
+					stack = append(stack, parent.ID)
+				}
+			}
+		}
+	}
+
+	// Only the tips of unreachable history are reported, like Git does
+	unreachableParents := make(map[string]bool)
+	for id, commit := range commits {
+		if commit.Tree != nil {
+			for _, entry := range commit.Tree.Entries {
+				referencedBlobs[entry.ID] = true
+			}
+		}
+		if reachable[id] {
+			continue
+		}
+		for _, parent := range commit.Parent {
+			if parent != nil {
+				unreachableParents[parent.ID] = true
+			}
+		}
+	}
+	for _, id := range sortedKeys(commits) {
+		if !reachable[id] && !unreachableParents[id] {
+			report.add("dangling", "commit", id, "")
+		}
+	}
+	for _, id := range sortedKeys(blobs) {
+		if !referencedBlobs[id] {
+			report.add("dangling", "blob", id, "")
+		}
+	}
+
+	return report, nil
+}
+
+// fsckObjects rehashes every object in the object store and returns the IDs of the valid blobs.
+func fsckObjects(repo *models.Repository, report *FsckReport) (map[string]bool, error) {
+	blobs := make(map[string]bool)
+	objectsDir := "objects"
+
+	err := fs.WalkDir(repo.Store, objectsDir, func(objectPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && objectPath == objectsDir {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		// Left over by an interrupted write, and never referenced
+		if strings.HasPrefix(entry.Name(), hash.TempObjectPrefix) {
+			return nil
+		}
+
+		id := strings.ReplaceAll(strings.TrimPrefix(objectPath, objectsDir+"/"), "/", "")
+		if !repo.Hash().ValidID(id) {
+			report.add("corrupt", "blob", id, fmt.Sprintf("not a %s object name", repo.Hash().Name))
+			return nil
+		}
+
+		content, err := repo.Store.ReadFile(objectPath)
+		if err != nil {
+			return fmt.Errorf("error reading object %s: %v", id, err)
+		}
+
+		if repo.Hash().Sum(content) != id {
+			report.add("corrupt", "blob", id, "hash mismatch")
+			return nil
+		}
+
+		// Objects are stored as "blob <size>\x00<content>"
+		header, body, found := bytes.Cut(content, []byte{0})
+		objectType, size, _ := strings.Cut(string(header), " ")
+		if !found || objectType != "blob" || size != strconv.Itoa(len(body)) {
+			report.add("corrupt", "blob", id, "invalid object header")
+			return nil
+		}
+
+		blobs[id] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading objects directory: %v", err)
+	}
+
+	return blobs, nil
+}
+
+// fsckCommits verifies every commit and the tree it records, and returns the valid commits by ID.
+func fsckCommits(repo *models.Repository, blobs map[string]bool, report *FsckReport) (map[string]*models.Commit, error) {
+	commits := make(map[string]*models.Commit)
+	commitsDir := "commits"
+
+	files, err := repo.Store.ReadDir(commitsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading commits directory: %v", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		id := file.Name()
+
+		data, err := repo.Store.ReadFile(path.Join(commitsDir, id))
+		if err != nil {
+			return nil, fmt.Errorf("error reading commit %s: %v", id, err)
+		}
+
+		var commit models.Commit
+		if err := json.Unmarshal(data, &commit); err != nil {
+			report.add("corrupt", "commit", id, "invalid commit data")
+			continue
+		}
+		if !repo.Hash().ValidID(id) {
+			report.add("corrupt", "commit", id, fmt.Sprintf("not a %s object name", repo.Hash().Name))
+			continue
+		}
+		if commit.ID != id {
+			report.add("corrupt", "commit", id, "stored under the wrong ID "+commit.ID)
+			continue
+		}
+		if commit.Tree == nil {
+			report.add("corrupt", "commit", id, "missing tree")
+			continue
+		}
+		if slices.Contains(commit.Parent, nil) {
+			report.add("corrupt", "commit", id, "null parent")
+			continue
+		}
+
+		computedID, err := GenerateCommitID(repo.Hash(), &commit)
+		if err != nil {
+			return nil, err
+		}
+		if computedID != id {
+			report.add("corrupt", "commit", id, "hash mismatch")
+			continue
+		}
+
+		if TreeID(repo.Hash(), commit.Tree) != commit.Tree.ID {
+			report.add("corrupt", "tree", commit.Tree.ID, "hash mismatch in commit "+id)
+		}
+		for _, entry := range commit.Tree.Entries {
+			if entry.Type == "blob" && !blobs[entry.ID] {
+				report.add("missing", "blob", entry.ID, fmt.Sprintf("referenced by tree %s at %s", commit.Tree.ID, entry.Name))
+			}
+		}
+
+		commits[id] = &commit
+	}
+
+	// Parents are checked once all the commits are known
+	for _, id := range sortedKeys(commits) {
+		for _, parent := range commits[id].Parent {
+			if parent == nil {
+				continue
+			}
+			if _, ok := commits[parent.ID]; !ok {
+				report.add("missing", "commit", parent.ID, "parent of commit "+id)
+			}
+		}
+	}
+
+	return commits, nil
+}
+
+// fsckRefs validates HEAD and every branch ref and returns the commit IDs they point to.
+func fsckRefs(repo *models.Repository, commits map[string]*models.Commit, report *FsckReport) ([]string, error) {
+	var tips []string
+	refsHeadsDir := path.Join("refs", "heads")
+
+	headContent, err := repo.Store.ReadFile("HEAD")
+	if err != nil {
+		report.add("broken", "ref", "HEAD", "cannot read HEAD")
+	} else {
+		headRef := strings.TrimSpace(strings.TrimPrefix(string(headContent), "ref: "))
+		branchName := strings.TrimPrefix(headRef, "refs/heads/")
+		if branchName == headRef || branchName == "" {
+			report.add("broken", "ref", "HEAD", "does not point to a branch")
+		} else if _, err := repo.Store.Stat(path.Join(refsHeadsDir, branchName)); err != nil {
+			report.add("broken", "ref", "HEAD", "points to missing branch "+branchName)
+		}
+	}
+
+	files, err := repo.Store.ReadDir(refsHeadsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading refs/heads directory: %v", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		refName := "refs/heads/" + file.Name()
+
+		content, err := repo.Store.ReadFile(path.Join(refsHeadsDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", refName, err)
+		}
+
+		commitID := strings.TrimSpace(string(content))
+		if commitID == "" {
+			report.add("broken", "ref", refName, "empty ref")
+			continue
+		}
+		if _, ok := commits[commitID]; !ok {
+			report.add("broken", "ref", refName, "points to missing commit "+commitID)
+			continue
+		}
+		tips = append(tips, commitID)
+	}
+
+	return tips, nil
+}
+
+// fsckReflog checks that the commits recorded in the reflog exist and returns them, since
+// commits a ref used to point to are still reachable through its reflog.
+func fsckReflog(repo *models.Repository, commits map[string]*models.Commit, report *FsckReport) []string {
+	entries, err := ReadReflog(repo)
+	if err != nil {
+		report.add("corrupt", "reflog", reflogDir, err.Error())
+		return nil
+	}
+	var tips []string
+	for _, entry := range entries {
+		for _, id := range []string{entry.OldID, entry.ID} {
+			if id == "" || id == repo.Hash().ZeroID() {
+				continue
+			}
+			if _, ok := commits[id]; !ok {
+				report.add("missing", "commit", id, "referenced by reflog of "+entry.Ref)
+				continue
+			}
+			tips = append(tips, id)
+		}
+	}
+	return tips
+}
+
+// fsckTags validates every annotated tag object and every tag ref, and returns the commit IDs
+// the tags point to.
+func fsckTags(repo *models.Repository, commits map[string]*models.Commit, report *FsckReport) ([]string, error) {
+	files, err := repo.Store.ReadDir(tagsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading tags directory: %v", err)
+	}
+	for _, file := range files {
+		id := file.Name()
+		if !repo.Hash().ValidID(id) {
+			report.add("corrupt", "tag", id, fmt.Sprintf("not a %s object name", repo.Hash().Name))
+			continue
+		}
+		tag, err := GetTagByHash(repo, id)
+		if err != nil {
+			report.add("corrupt", "tag", id, "invalid tag data")
+			continue
+		}
+		if tag.ID != id || TagID(repo, tag) != id {
+			report.add("corrupt", "tag", id, "hash mismatch")
+			continue
+		}
+		if _, ok := commits[tag.Object]; !ok {
+			report.add("missing", "commit", tag.Object, "tagged by "+tag.Name)
+		}
+	}
+
+	names, err := GetTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	var tips []string
+	for _, name := range names {
+		refName := "refs/tags/" + name
+		id, err := ReadTagRef(repo, name)
+		if err != nil {
+			return nil, err
+		}
+		commitID, err := PeelTag(repo, id)
+		if err != nil {
+			report.add("broken", "ref", refName, err.Error())
+			continue
+		}
+		if _, ok := commits[commitID]; !ok {
+			report.add("broken", "ref", refName, "points to missing commit "+commitID)
+			continue
+		}
+		tips = append(tips, commitID)
+	}
+	return tips, nil
+}
+
+// fsckCommitGraph checks that every commit in the commit-graph exists and has the parents
+// the graph records for it.
+func fsckCommitGraph(repo *models.Repository, commits map[string]*models.Commit, report *FsckReport) {
+	graph, err := ReadCommitGraph(repo)
+	if err != nil {
+		report.add("corrupt", "commit-graph", commitGraphFile, err.Error())
+		return
+	}
+	for _, entry := range graph.Commits {
+		commit, ok := commits[entry.ID]
+		if !ok {
+			report.add("missing", "commit", entry.ID, "referenced by commit-graph")
+			continue
+		}
+		var parents []string
+		for _, parent := range commit.Parent {
+			if parent != nil {
+				parents = append(parents, parent.ID)
+			}
+		}
+		matches := len(parents) == len(entry.Parents)
+		for i := 0; matches && i < len(parents); i++ {
+			matches = graph.Commits[entry.Parents[i]].ID == parents[i]
+		}
+		if !matches {
+			report.add("corrupt", "commit-graph", commitGraphFile, "wrong parents for commit "+entry.ID)
+		}
+	}
+}
+
+// sortedKeys returns the keys of the map in sorted order so reports are stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
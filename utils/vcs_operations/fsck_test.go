@@ -0,0 +1,194 @@
+package vcs_operations_test
+
+import (
+	"GitX/internal/hash"
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+)
+
+// objectPath returns the path of the object in the store.
+func objectPath(id string) string {
+	return path.Join("objects", id[:2], id[2:])
+}
+
+func TestFsck(t *testing.T) {
+	tests := []struct {
+		name string
+		// corrupt damages repo, whose main branch has first and then second,
+		// and returns the problem fsck must report
+		corrupt func(t *testing.T, repo *models.Repository, first, second *models.Commit) string
+		fatal   bool
+	}{
+		{
+			name:    "clean",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string { return "" },
+		},
+		{
+			name: "changed blob",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				id := blobID(t, second, "b.txt")
+				writeStore(t, repo, objectPath(id), "blob 2\x00x\n")
+				return "corrupt blob " + id + "\thash mismatch"
+			},
+			fatal: true,
+		},
+		{
+			name: "missing blob",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				id := blobID(t, first, "a.txt")
+				removeStore(t, repo, objectPath(id))
+				return "missing blob " + id
+			},
+			fatal: true,
+		},
+		{
+			name: "garbled commit",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, path.Join("commits", first.ID), "{")
+				return "corrupt commit " + first.ID + "\tinvalid commit data"
+			},
+			fatal: true,
+		},
+		{
+			name: "changed commit",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				commitPath := path.Join("commits", second.ID)
+				writeStore(t, repo, commitPath, strings.Replace(readStore(t, repo, commitPath), "add b.txt", "add c.txt", 1))
+				return "corrupt commit " + second.ID + "\thash mismatch"
+			},
+			fatal: true,
+		},
+		{
+			name: "null parent",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				second.Parent = []*models.Commit{nil}
+				data, err := json.Marshal(second)
+				if err != nil {
+					t.Fatal(err)
+				}
+				writeStore(t, repo, path.Join("commits", second.ID), string(data))
+				return "corrupt commit " + second.ID + "\tnull parent"
+			},
+			fatal: true,
+		},
+		{
+			name: "missing parent",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				removeStore(t, repo, path.Join("commits", first.ID))
+				return "missing commit " + first.ID
+			},
+			fatal: true,
+		},
+		{
+			name: "branch to missing commit",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				sum := sha1.Sum([]byte("nothing"))
+				writeStore(t, repo, "refs/heads/broken", hex.EncodeToString(sum[:]))
+				return "broken ref refs/heads/broken"
+			},
+			fatal: true,
+		},
+		{
+			name: "HEAD to missing branch",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, "HEAD", "refs/heads/gone")
+				return "broken ref HEAD"
+			},
+			fatal: true,
+		},
+		{
+			name: "commit-graph checksum",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				content := readStore(t, repo, "commit-graph")
+				sum := sha1.Sum([]byte("other"))
+				last := strings.LastIndex(content, "checksum ")
+				writeStore(t, repo, "commit-graph", content[:last]+"checksum "+hex.EncodeToString(sum[:])+"\n")
+				return "corrupt commit-graph commit-graph"
+			},
+			fatal: true,
+		},
+		{
+			name: "garbled reflog",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, "reflog/1", "{")
+				return "corrupt reflog reflog"
+			},
+			fatal: true,
+		},
+		{
+			name: "reflog to missing commit",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				sum := sha1.Sum([]byte("nothing"))
+				id := hex.EncodeToString(sum[:])
+				writeStore(t, repo, "reflog/1", `{"ID":"`+id+`","Ref":"refs/heads/gone"}`)
+				return "missing commit " + id + "\treferenced by reflog of refs/heads/gone"
+			},
+			fatal: true,
+		},
+		{
+			name: "temporary object",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, path.Join("objects", hash.TempObjectPrefix+"1"), "partial")
+				return ""
+			},
+		},
+		{
+			name: "reflog from the zero ID",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, "reflog/1", `{"OldID":"`+repo.Hash().ZeroID()+`","ID":"`+second.ID+`","Ref":"refs/heads/main"}`)
+				return ""
+			},
+		},
+		{
+			name: "commit only in the reflog",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, "refs/heads/main", first.ID)
+				return ""
+			},
+		},
+		{
+			name: "unreachable commit",
+			corrupt: func(t *testing.T, repo *models.Repository, first, second *models.Commit) string {
+				writeStore(t, repo, "refs/heads/main", first.ID)
+				if err := repo.Store.RemoveAll("reflog"); err != nil {
+					t.Fatal(err)
+				}
+				return "dangling commit " + second.ID
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			first := commitFile(t, repo, "a.txt", "a\n")
+			second := commitFile(t, repo, "b.txt", "b\n")
+			want := test.corrupt(t, repo, first, second)
+
+			report, err := vcs_operations.Fsck(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var problems []string
+			found := false
+			for _, problem := range report.Problems {
+				problems = append(problems, problem.String())
+				found = found || (want != "" && strings.HasPrefix(problem.String(), want))
+			}
+			if want == "" && len(problems) > 0 {
+				t.Errorf("fsck reports %q, want no problems", problems)
+			}
+			if want != "" && !found {
+				t.Errorf("fsck reports %q, want %q", problems, want)
+			}
+			if report.HasErrors() != test.fatal {
+				t.Errorf("HasErrors() = %v, want %v", report.HasErrors(), test.fatal)
+			}
+		})
+	}
+}
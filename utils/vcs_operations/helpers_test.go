@@ -0,0 +1,88 @@
+package vcs_operations_test
+
+import (
+	"GitX/internal/fsys"
+	"GitX/models"
+	"GitX/utils/file_operations"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRepo initializes a repository on in-memory file systems, with an identity configured.
+func newTestRepo(t *testing.T) *models.Repository {
+	t.Helper()
+	directory := string(filepath.Separator)
+	repo := &models.Repository{
+		Directory: directory,
+		GitxDir:   filepath.Join(directory, ".gitx"),
+		WorkTree:  fsys.NewMemFS(),
+		Store:     fsys.NewMemFS(),
+	}
+	if err := file_operations.InitHandler(repo); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"user.name": "Test User", "user.email": "test@example.com"} {
+		if err := file_operations.ConfigHandler(repo, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+// commitFile writes content to name in the working tree, stages it and commits it.
+func commitFile(t *testing.T, repo *models.Repository, name, content string) *models.Commit {
+	t.Helper()
+	if err := repo.WorkTree.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := file_operations.AddHandler(repo, repo.WorkPath(name)); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := file_operations.CommitHandler(repo, "add "+name, file_operations.CommitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+// blobID returns the ID of the blob the commit records for name.
+func blobID(t *testing.T, commit *models.Commit, name string) string {
+	t.Helper()
+	for _, entry := range commit.Tree.Entries {
+		if path.Base(entry.Name) == name {
+			return entry.ID
+		}
+	}
+	t.Fatalf("commit %s has no %s", commit.ID, name)
+	return ""
+}
+
+// readStore returns the content of the file at name in the repository store.
+func readStore(t *testing.T, repo *models.Repository, name string) string {
+	t.Helper()
+	content, err := repo.Store.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// writeStore writes content to the file at name in the repository store.
+func writeStore(t *testing.T, repo *models.Repository, name, content string) {
+	t.Helper()
+	if err := repo.Store.MkdirAll(path.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Store.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// removeStore removes the file at name from the repository store.
+func removeStore(t *testing.T, repo *models.Repository, name string) {
+	t.Helper()
+	if err := repo.Store.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+}
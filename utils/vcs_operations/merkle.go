@@ -0,0 +1,326 @@
+package vcs_operations
+
+import (
+	"GitX/internal/merkle"
+	"GitX/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// merkleNotesDir holds one MerkleNote per recorded commit, named after the commit.
+var merkleNotesDir = path.Join("notes", "merkle")
+
+// HistoryCheck is the result of checking a history against a published Merkle root.
+type HistoryCheck struct {
+	Root   string
+	Intact bool   // The history starts with the commits the root was computed over
+	Size   int    // Number of commits under the root, when known
+	Commit string // Newest commit under the root, when known
+
+	// When the history was rewritten and the published commits are known, Diverged is the
+	// position of the first commit that differs, Published the commit the root covers there
+	// and Current the commit found instead, empty if the history is shorter.
+	Diverged  int
+	Published string
+	Current   string
+}
+
+// MerkleRoot returns the root of the Merkle tree over the history of the revision, and the
+// number of commits it covers.
+func MerkleRoot(repo *models.Repository, rev string) (string, int, error) {
+	ids, err := merkleHistory(repo, rev)
+	if err != nil {
+		return "", 0, err
+	}
+	root, err := merkle.NewMerkleTree(repo.Hash(), ids)
+	if err != nil {
+		return "", 0, err
+	}
+	return root.Hash, len(ids), nil
+}
+
+// MerkleProof returns the proof that the commit is part of the history of the revision, checked
+// against the root MerkleRoot returns for the same revision.
+func MerkleProof(repo *models.Repository, rev, commit string) (*merkle.Proof, error) {
+	commitID, err := ResolveRevision(repo, commit)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := merkleHistory(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	for index, id := range ids {
+		if id == commitID {
+			return merkle.Prove(repo.Hash(), ids, index)
+		}
+	}
+	return nil, fmt.Errorf("commit %s is not in the history of %s", commitID, rev)
+}
+
+// RecordMerkleRoot computes the root of the history of the branch and records it in a note
+// attached to the branch's newest commit. Notes are never overwritten, so together they form an
+// append-only trail of the roots a branch had; if the commit already has one, it is returned.
+func RecordMerkleRoot(repo *models.Repository, branch string) (*models.MerkleNote, error) {
+	if branch == "" {
+		var err error
+		if branch, err = getCurrentBranch(repo); err != nil {
+			return nil, err
+		}
+	}
+	if !branchExists(repo, branch) {
+		return nil, fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, branch)
+	}
+
+	root, size, err := MerkleRoot(repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	commitID, err := ReadBranchRef(repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	noteFile := path.Join(merkleNotesDir, commitID)
+	if data, err := repo.Store.ReadFile(noteFile); err == nil {
+		var note models.MerkleNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return nil, fmt.Errorf("error parsing note %s: %w", commitID, err)
+		}
+		return &note, nil
+	}
+	author, err := GetCurrentUser(repo, RoleCommitter)
+	if err != nil {
+		return nil, err
+	}
+	note := &models.MerkleNote{
+		Algorithm: repo.Hash().Name,
+		Root:      root,
+		Size:      size,
+		Commit:    commitID,
+		Ref:       "refs/heads/" + branch,
+		Author:    author,
+		Timestamp: time.Now(),
+	}
+	data, err := json.MarshalIndent(note, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error serializing note: %w", err)
+	}
+	if err := repo.Store.MkdirAll(merkleNotesDir, fs.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating notes directory: %w", err)
+	}
+	if err := repo.Store.WriteFile(noteFile, data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing note: %w", err)
+	}
+	return note, nil
+}
+
+// MerkleTagMessage returns the message of an annotated tag recording the root of the note, which
+// MerkleNotes reads back. Signing the tag vouches for the root.
+func MerkleTagMessage(note *models.MerkleNote) string {
+	return fmt.Sprintf("Merkle root of %s\n\n%s%s\n%s%d\n%s%s\n%s%s\n", strings.TrimPrefix(note.Ref, "refs/heads/"),
+		merkleRootTrailer, note.Root, merkleSizeTrailer, note.Size, merkleAlgorithmTrailer, note.Algorithm, merkleRefTrailer, note.Ref)
+}
+
+// Trailers of the tag messages recording Merkle roots.
+const (
+	merkleRootTrailer      = "Merkle-Root: "
+	merkleSizeTrailer      = "Merkle-Size: "
+	merkleAlgorithmTrailer = "Merkle-Algorithm: "
+	merkleRefTrailer       = "Merkle-Ref: "
+)
+
+// MerkleNotes returns the recorded roots, oldest first: the notes, and the annotated tags with a
+// MerkleTagMessage.
+func MerkleNotes(repo *models.Repository) ([]models.MerkleNote, error) {
+	notes, err := merkleNoteFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	tagNotes, err := merkleTagNotes(repo)
+	if err != nil {
+		return nil, err
+	}
+	notes = append(notes, tagNotes...)
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Timestamp.Before(notes[j].Timestamp)
+	})
+	return notes, nil
+}
+
+// merkleTagNotes returns the roots recorded in annotated tags.
+func merkleTagNotes(repo *models.Repository) ([]models.MerkleNote, error) {
+	tags, err := GetTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	var notes []models.MerkleNote
+	for _, name := range tags {
+		id, err := ReadTagRef(repo, name)
+		if err != nil {
+			return nil, err
+		}
+		if !IsTagObject(repo, id) {
+			continue
+		}
+		tag, err := GetTagByHash(repo, id)
+		if err != nil {
+			return nil, err
+		}
+
+		note := models.MerkleNote{Commit: tag.Object, Tag: name, Author: tag.Tagger, Timestamp: tag.Timestamp}
+		for _, line := range strings.Split(tag.Message, "\n") {
+			if value, ok := strings.CutPrefix(line, merkleRootTrailer); ok {
+				note.Root = value
+			} else if value, ok := strings.CutPrefix(line, merkleSizeTrailer); ok {
+				note.Size, _ = strconv.Atoi(value)
+			} else if value, ok := strings.CutPrefix(line, merkleAlgorithmTrailer); ok {
+				note.Algorithm = value
+			} else if value, ok := strings.CutPrefix(line, merkleRefTrailer); ok {
+				note.Ref = value
+			}
+		}
+		if note.Root != "" {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+// merkleNoteFiles returns the roots recorded in notes.
+func merkleNoteFiles(repo *models.Repository) ([]models.MerkleNote, error) {
+	files, err := repo.Store.ReadDir(merkleNotesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading notes directory: %w", err)
+	}
+
+	var notes []models.MerkleNote
+	for _, file := range files {
+		data, err := repo.Store.ReadFile(path.Join(merkleNotesDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading note %s: %w", file.Name(), err)
+		}
+		var note models.MerkleNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			return nil, fmt.Errorf("error parsing note %s: %w", file.Name(), err)
+		}
+		if note.Commit != file.Name() {
+			return nil, fmt.Errorf("note %s is attached to commit %s", file.Name(), note.Commit)
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// VerifyHistory checks the history of the revision against a published root. The history is
+// intact if its oldest commits are exactly the ones the root was computed over, in the same
+// order, so a branch that only grew since still matches. Otherwise, if a recorded note names the
+// commits behind the root, the first commit that differs is reported.
+func VerifyHistory(repo *models.Repository, rev, root string) (*HistoryCheck, error) {
+	if err := repo.Hash().CheckID(root); err != nil {
+		return nil, fmt.Errorf("invalid root: %w", err)
+	}
+	ids, err := merkleHistory(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &HistoryCheck{Root: root}
+	if size, found := merkle.PrefixSize(repo.Hash(), ids, root); found {
+		check.Intact, check.Size, check.Commit = true, size, ids[size-1]
+		return check, nil
+	}
+
+	notes, err := MerkleNotes(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.Root != root {
+			continue
+		}
+		// Commits are never deleted, so the published history can still be read
+		published, err := merkleHistory(repo, note.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the history the root was recorded for: %w", err)
+		}
+		publishedRoot, err := merkle.NewMerkleTree(repo.Hash(), published)
+		if err != nil {
+			return nil, err
+		}
+		if publishedRoot.Hash != root || len(published) != note.Size {
+			return nil, fmt.Errorf("the commits recorded under root %s no longer match it", root)
+		}
+
+		check.Size, check.Commit = note.Size, note.Commit
+		for i, id := range published {
+			if i >= len(ids) || ids[i] != id {
+				check.Diverged, check.Published = i, id
+				if i < len(ids) {
+					check.Current = ids[i]
+				}
+				break
+			}
+		}
+		break
+	}
+	return check, nil
+}
+
+// merkleHistory returns the IDs of the commits reachable from the revision in the order of the
+// leaves of its Merkle tree. Following the first parents from the oldest commit, each commit
+// comes right after the commits it brought into the history, themselves ordered by generation,
+// date and ID. The order only depends on the commits themselves, so every clone computes the same
+// root, and the history of a commit always starts with the history of its first parent, so roots
+// published for a branch stay valid as it grows.
+func merkleHistory(repo *models.Repository, rev string) ([]string, error) {
+	id, err := ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := loadCommitGraph(repo, id)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := graph.Lookup(id)
+
+	var chain []int
+	for position := start; ; {
+		chain = append(chain, position)
+		parents := graph.Commits[position].Parents
+		if len(parents) == 0 {
+			break
+		}
+		position = parents[0]
+	}
+
+	visited := make(map[int]bool)
+	ids := make([]string, 0, len(graph.Commits))
+	for i := len(chain) - 1; i >= 0; i-- {
+		added := newAncestors(graph, chain[i], visited)
+		sort.Slice(added, func(i, j int) bool {
+			a, b := graph.Commits[added[i]], graph.Commits[added[j]]
+			if a.Generation != b.Generation {
+				return a.Generation < b.Generation
+			}
+			if a.Date != b.Date {
+				return a.Date < b.Date
+			}
+			return a.ID < b.ID
+		})
+		for _, position := range added {
+			ids = append(ids, graph.Commits[position].ID)
+		}
+	}
+	return ids, nil
+}
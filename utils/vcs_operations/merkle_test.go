@@ -0,0 +1,108 @@
+package vcs_operations_test
+
+import (
+	"GitX/utils/vcs_operations"
+	"testing"
+)
+
+func TestMerkleProof(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFile(t, repo, "a.txt", "a\n")
+	commitFile(t, repo, "b.txt", "b\n")
+	newBranch(t, repo, "topic", first)
+	other := commitFile(t, repo, "c.txt", "c\n")
+	if err := vcs_operations.UpdateHEAD(repo, "refs/heads/main"); err != nil {
+		t.Fatal(err)
+	}
+	third := commitFile(t, repo, "d.txt", "d\n")
+
+	root, size, err := vcs_operations.MerkleRoot(repo, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The initial commit and the three commits of main
+	if size != 4 {
+		t.Errorf("MerkleRoot covers %d commits, want 4", size)
+	}
+
+	for _, commit := range []string{first.ID, third.ID, "HEAD~1"} {
+		proof, err := vcs_operations.MerkleProof(repo, "main", commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Root != root || proof.Size != size {
+			t.Errorf("proof of %s is for root %s of %d commits, want %s of %d", commit, proof.Root, proof.Size, root, size)
+		}
+		if err := proof.Verify(); err != nil {
+			t.Errorf("proof of %s does not verify: %v", commit, err)
+		}
+	}
+
+	if _, err := vcs_operations.MerkleProof(repo, "main", other.ID); err == nil {
+		t.Error("MerkleProof of a commit of another branch succeeds")
+	}
+
+	// The root only depends on the history, and grows with it
+	again, _, err := vcs_operations.MerkleRoot(repo, third.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != root {
+		t.Errorf("MerkleRoot of the commit ID = %s, want %s", again, root)
+	}
+	commitFile(t, repo, "e.txt", "e\n")
+	if grown, _, err := vcs_operations.MerkleRoot(repo, "main"); err != nil || grown == root {
+		t.Errorf("MerkleRoot after a commit = %s, %v, want a new root", grown, err)
+	}
+}
+
+func TestVerifyHistory(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFile(t, repo, "a.txt", "a\n")
+	second := commitFile(t, repo, "b.txt", "b\n")
+	note, err := vcs_operations.RecordMerkleRoot(repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Commit != second.ID || note.Size != 3 || note.Ref != "refs/heads/main" {
+		t.Errorf("note = %+v, want the 3 commits up to %s on main", note, second.ID)
+	}
+	if again, err := vcs_operations.RecordMerkleRoot(repo, "main"); err != nil || again.Root != note.Root || !again.Timestamp.Equal(note.Timestamp) {
+		t.Errorf("recording the root of the same commit again = %+v, %v, want the existing note", again, err)
+	}
+
+	// A branch that only grew still matches the root
+	commitFile(t, repo, "c.txt", "c\n")
+	check, err := vcs_operations.VerifyHistory(repo, "main", note.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Intact || check.Size != 3 || check.Commit != second.ID {
+		t.Errorf("check of a grown history = %+v, want intact up to %s", check, second.ID)
+	}
+
+	// Rewriting the second commit is caught there
+	if err := vcs_operations.CreateBranchRef(repo, "main", first.ID); err != nil {
+		t.Fatal(err)
+	}
+	rewritten := commitFile(t, repo, "b.txt", "rewritten\n")
+	check, err = vcs_operations.VerifyHistory(repo, "main", note.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Intact || check.Diverged != 2 || check.Published != second.ID || check.Current != rewritten.ID {
+		t.Errorf("check of a rewritten history = %+v, want a divergence at 2 from %s to %s", check, second.ID, rewritten.ID)
+	}
+
+	// Without a note, a root that does not match only says so
+	check, err = vcs_operations.VerifyHistory(repo, "main", repo.Hash().Sum([]byte("unknown")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Intact || check.Published != "" {
+		t.Errorf("check against an unknown root = %+v, want not intact without details", check)
+	}
+	if _, err := vcs_operations.VerifyHistory(repo, "main", "abc"); err == nil {
+		t.Error("VerifyHistory with an invalid root succeeds")
+	}
+}
@@ -0,0 +1,233 @@
+package vcs_operations
+
+import (
+	"GitX/internal/hash"
+	"GitX/models"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Types of the objects of a repository.
+const (
+	ObjectBlob   = "blob"
+	ObjectTree   = "tree"
+	ObjectCommit = "commit"
+	ObjectTag    = "tag"
+)
+
+// treeMode is the mode of a directory in a tree listing.
+const treeMode = "040000"
+
+// Object is an object of the repository. Only the field matching its Type is set.
+type Object struct {
+	Type   string
+	ID     string
+	Blob   []byte
+	Tree   *models.Tree
+	Commit *models.Commit
+	Tag    *models.Tag
+}
+
+// Content returns the raw content of the object: the content of a blob, the entries of a tree
+// as its ID hashes them, and the text of a commit or an annotated tag with its signature.
+func (o *Object) Content() []byte {
+	switch o.Type {
+	case ObjectTree:
+		var content strings.Builder
+		for _, entry := range o.Tree.Entries {
+			fmt.Fprintf(&content, "%s %s %s\t%s", entry.Mode, entry.Type, entry.ID, entry.Name)
+		}
+		return []byte(content.String())
+	case ObjectCommit:
+		return CommitText(o.Commit)
+	case ObjectTag:
+		return append(TagPayload(o.Tag), o.Tag.Signature...)
+	}
+	return o.Blob
+}
+
+// ReadObject returns the object named by name. "<rev>:<path>" names the file or directory at
+// path in the commit named by rev, and "<rev>:" its root tree. The name or ID of an annotated
+// tag names the tag itself, and a full blob or tree ID names that blob or tree. Any other name
+// is resolved as a revision, naming a commit.
+func ReadObject(repo *models.Repository, name string) (*Object, error) {
+	if rev, filePath, ok := strings.Cut(name, ":"); ok {
+		return readTreePath(repo, rev, filePath)
+	}
+
+	id := name
+	if !branchExists(repo, name) && CheckTagName(name) == nil && tagExists(repo, name) {
+		tagID, err := ReadTagRef(repo, name)
+		if err != nil {
+			return nil, err
+		}
+		id = tagID
+	}
+	if IsTagObject(repo, id) {
+		tag, err := GetTagByHash(repo, id)
+		if err != nil {
+			return nil, err
+		}
+		return &Object{Type: ObjectTag, ID: id, Tag: tag}, nil
+	}
+
+	if repo.Hash().ValidID(id) {
+		if _, err := repo.Store.Stat(path.Join("objects", id[:2], id[2:])); err == nil {
+			content, err := ReadBlob(repo, id)
+			if err != nil {
+				return nil, err
+			}
+			return &Object{Type: ObjectBlob, ID: id, Blob: content}, nil
+		}
+		if _, err := repo.Store.Stat(path.Join("commits", id)); errors.Is(err, fs.ErrNotExist) {
+			tree, err := findTree(repo, id)
+			if err != nil {
+				return nil, err
+			}
+			if tree != nil {
+				return &Object{Type: ObjectTree, ID: id, Tree: tree}, nil
+			}
+		}
+	}
+
+	commitID, err := ResolveRevision(repo, name)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := GetCommitByHash(repo, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{Type: ObjectCommit, ID: commitID, Commit: commit}, nil
+}
+
+// readTreePath returns the blob or tree at the slash-separated path in the commit named by rev.
+func readTreePath(repo *models.Repository, rev, filePath string) (*Object, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	commitID, err := ResolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := GetCommitByHash(repo, commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath = strings.Trim(path.Clean("/"+filePath), "/")
+	for _, entry := range commit.Tree.Entries {
+		if entry.Name == filePath {
+			content, err := ReadBlob(repo, entry.ID)
+			if err != nil {
+				return nil, err
+			}
+			return &Object{Type: ObjectBlob, ID: entry.ID, Blob: content}, nil
+		}
+	}
+	if tree := SubTree(repo.Hash(), commit.Tree, filePath); tree != nil {
+		return &Object{Type: ObjectTree, ID: tree.ID, Tree: tree}, nil
+	}
+	return nil, fmt.Errorf("%w: path '%s' does not exist in '%s'", models.ErrObjectNotFound, filePath, rev)
+}
+
+// SubTree returns the tree of the files under the slash-separated directory dir, with their
+// paths relative to it, or nil if there are none. An empty dir names the whole tree.
+func SubTree(alg *hash.Algorithm, tree *models.Tree, dir string) *models.Tree {
+	if dir == "" {
+		return tree
+	}
+	sub := &models.Tree{}
+	for _, entry := range tree.Entries {
+		if name, ok := strings.CutPrefix(entry.Name, dir+"/"); ok {
+			entry.Name = name
+			sub.Entries = append(sub.Entries, entry)
+		}
+	}
+	if len(sub.Entries) == 0 {
+		return nil
+	}
+	sub.ID = TreeID(alg, sub)
+	return sub
+}
+
+// TreeChildren returns the entries directly in the tree, sorted by name. Trees only record
+// files, so each directory is returned as a tree entry whose ID is that of its SubTree.
+func TreeChildren(alg *hash.Algorithm, tree *models.Tree) []models.TreeEntry {
+	var children []models.TreeEntry
+	dirs := make(map[string]bool)
+	for _, entry := range tree.Entries {
+		dir, _, nested := strings.Cut(entry.Name, "/")
+		if !nested {
+			children = append(children, entry)
+		} else if !dirs[dir] {
+			dirs[dir] = true
+			sub := SubTree(alg, tree, dir)
+			children = append(children, models.TreeEntry{Name: dir, Mode: treeMode, ID: sub.ID, Type: ObjectTree})
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// findTree returns the tree with the given ID, or nil if there is none. Trees are not stored
+// on their own, so they are looked for among the trees of the commits and their directories.
+func findTree(repo *models.Repository, id string) (*models.Tree, error) {
+	files, err := repo.Store.ReadDir("commits")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading commits directory: %w", err)
+	}
+	for _, file := range files {
+		commit, err := GetCommitByHash(repo, file.Name())
+		if err != nil || commit.Tree == nil {
+			continue
+		}
+		if commit.Tree.ID == id {
+			return commit.Tree, nil
+		}
+		dirs := make(map[string]bool)
+		for _, entry := range commit.Tree.Entries {
+			for dir := path.Dir(entry.Name); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+				dirs[dir] = true
+				if sub := SubTree(repo.Hash(), commit.Tree, dir); sub.ID == id {
+					return sub, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// CatFile writes the type of the object named by name to w when format is 't', its size in
+// bytes when it is 's', and its content when it is 'p'. A tree is printed as the entries
+// directly in it, one "<mode> <type> <id>\t<name>" line each.
+func CatFile(repo *models.Repository, w io.Writer, name string, format byte) error {
+	object, err := ReadObject(repo, name)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case 't':
+		fmt.Fprintln(w, object.Type)
+	case 's':
+		fmt.Fprintln(w, len(object.Content()))
+	case 'p':
+		if object.Type != ObjectTree {
+			_, err := w.Write(object.Content())
+			return err
+		}
+		for _, entry := range TreeChildren(repo.Hash(), object.Tree) {
+			fmt.Fprintf(w, "%s %s %s\t%s\n", entry.Mode, entry.Type, entry.ID, entry.Name)
+		}
+	default:
+		return fmt.Errorf("unknown cat-file format '%c'", format)
+	}
+	return nil
+}
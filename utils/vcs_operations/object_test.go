@@ -0,0 +1,60 @@
+package vcs_operations_test
+
+import (
+	"GitX/utils/vcs_operations"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCatFile(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := repo.WorkTree.MkdirAll("dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repo, "dir/b.txt", "b\n")
+	commit := commitFile(t, repo, "a.txt", "hello\n")
+	dir := vcs_operations.SubTree(repo.Hash(), commit.Tree, "dir")
+
+	tests := []struct {
+		name       string
+		objectType string
+		content    string
+	}{
+		{name: "HEAD", objectType: vcs_operations.ObjectCommit, content: string(vcs_operations.CommitText(commit))},
+		{name: "HEAD:a.txt", objectType: vcs_operations.ObjectBlob, content: "hello\n"},
+		{name: blobID(t, commit, "a.txt"), objectType: vcs_operations.ObjectBlob, content: "hello\n"},
+		{name: "HEAD:dir", objectType: vcs_operations.ObjectTree, content: "100644 blob " + blobID(t, commit, "b.txt") + "\tb.txt\n"},
+		{name: dir.ID, objectType: vcs_operations.ObjectTree, content: "100644 blob " + blobID(t, commit, "b.txt") + "\tb.txt\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := vcs_operations.CatFile(repo, &out, test.name, 't'); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.objectType+"\n" {
+				t.Errorf("type %q, want %q", out.String(), test.objectType)
+			}
+
+			out.Reset()
+			if err := vcs_operations.CatFile(repo, &out, test.name, 'p'); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.content {
+				t.Errorf("content %q, want %q", out.String(), test.content)
+			}
+		})
+	}
+
+	var out strings.Builder
+	if err := vcs_operations.CatFile(repo, &out, "HEAD:a.txt", 's'); err != nil {
+		t.Fatal(err)
+	}
+	if want := strconv.Itoa(len("hello\n")) + "\n"; out.String() != want {
+		t.Errorf("size %q, want %q", out.String(), want)
+	}
+	if err := vcs_operations.CatFile(repo, &out, "HEAD:missing.txt", 'p'); err == nil {
+		t.Error("cat-file of a missing path succeeded")
+	}
+}
@@ -0,0 +1,128 @@
+package vcs_operations
+
+import (
+	"GitX/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// ResolveRevision returns the ID of the commit named by rev. A revision is HEAD (or @), a branch
+// name, a tag name, or a full or abbreviated commit ID, optionally followed by any number of
+// "~<n>" (the n-th first-parent ancestor) and "^<n>" (the n-th parent) suffixes.
+func ResolveRevision(repo *models.Repository, rev string) (string, error) {
+	base := rev
+	suffix := ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		base, suffix = rev[:i], rev[i:]
+	}
+
+	id, err := resolveBase(repo, base)
+	if err != nil {
+		return "", err
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+
+		if op == '~' {
+			for ; n > 0; n-- {
+				if id, err = nthParent(repo, id, 1, rev); err != nil {
+					return "", err
+				}
+			}
+		} else if n > 0 {
+			if id, err = nthParent(repo, id, n, rev); err != nil {
+				return "", err
+			}
+		}
+	}
+	return id, nil
+}
+
+// resolveBase resolves a revision without its ancestry suffixes.
+func resolveBase(repo *models.Repository, name string) (string, error) {
+	if name == "HEAD" || name == "@" {
+		id, err := GetCurrentHeadCommit(repo)
+		if err != nil {
+			return "", err
+		}
+		if id == "" {
+			return "", fmt.Errorf("%w: HEAD does not point to a commit yet", models.ErrRefNotFound)
+		}
+		return id, nil
+	}
+
+	if name != "" && branchExists(repo, name) {
+		return ReadBranchRef(repo, name)
+	}
+
+	// Tags name commits, through their tag object for an annotated tag
+	if name != "" && CheckTagName(name) == nil && tagExists(repo, name) {
+		id, err := ReadTagRef(repo, name)
+		if err != nil {
+			return "", err
+		}
+		return PeelTag(repo, id)
+	}
+
+	if len(name) > repo.Hash().HexLen() && isHex(name) {
+		return "", fmt.Errorf("%w: '%s' is longer than a %s commit ID", models.ErrRefNotFound, name, repo.Hash().Name)
+	}
+
+	// Abbreviated commit IDs must be unambiguous
+	if len(name) >= 4 && isHex(name) {
+		files, err := repo.Store.ReadDir("commits")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("error reading commits directory: %w", err)
+		}
+		var matches []string
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), name) {
+				matches = append(matches, file.Name())
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		if len(matches) > 1 {
+			return "", fmt.Errorf("short commit ID %s is ambiguous", name)
+		}
+	}
+
+	return "", fmt.Errorf("%w: unknown revision '%s'", models.ErrRefNotFound, name)
+}
+
+// nthParent returns the ID of the n-th parent of the commit, counting from 1.
+func nthParent(repo *models.Repository, id string, n int, rev string) (string, error) {
+	commit, err := GetCommitByHash(repo, id)
+	if err != nil {
+		return "", err
+	}
+	if n > len(commit.Parent) || commit.Parent[n-1] == nil {
+		return "", fmt.Errorf("%w: unknown revision '%s'", models.ErrRefNotFound, rev)
+	}
+	return commit.Parent[n-1].ID, nil
+}
+
+// isHex reports whether s only contains lowercase hexadecimal digits.
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
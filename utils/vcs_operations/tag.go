@@ -0,0 +1,148 @@
+package vcs_operations
+
+import (
+	"GitX/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// tagsDir holds the annotated tag objects, named after their IDs like the commits.
+const tagsDir = "tags"
+
+// TagPayload returns the text an annotated tag is identified by, without its signature. It is
+// also the data a tag signature covers.
+func TagPayload(tag *models.Tag) []byte {
+	return []byte(fmt.Sprintf("object %s\ntype commit\ntag %s\ntagger %s %d +0000\n\n%s\n",
+		tag.Object, tag.Name, tag.Tagger, tag.Timestamp.Unix(), tag.Message))
+}
+
+// TagID returns the ID of an annotated tag. Like in Git, a signature follows the message, so
+// the ID also covers it.
+func TagID(repo *models.Repository, tag *models.Tag) string {
+	return repo.Hash().Sum(append(TagPayload(tag), tag.Signature...))
+}
+
+// WriteTag stores the annotated tag under its ID, which it sets.
+func WriteTag(repo *models.Repository, tag *models.Tag) error {
+	tag.ID = TagID(repo, tag)
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("error serializing tag: %w", err)
+	}
+	if err := repo.Store.MkdirAll(tagsDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating tags directory: %w", err)
+	}
+	if err := repo.Store.WriteFile(path.Join(tagsDir, tag.ID), data, 0644); err != nil {
+		return fmt.Errorf("error writing tag: %w", err)
+	}
+	return nil
+}
+
+// GetTagByHash reads the annotated tag with the given ID.
+func GetTagByHash(repo *models.Repository, id string) (*models.Tag, error) {
+	if err := repo.Hash().CheckID(id); err != nil {
+		return nil, err
+	}
+	data, err := repo.Store.ReadFile(path.Join(tagsDir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: tag %s", models.ErrObjectNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading tag: %w", err)
+	}
+	var tag models.Tag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, fmt.Errorf("error parsing tag %s: %w", id, err)
+	}
+	return &tag, nil
+}
+
+// IsTagObject reports whether id names an annotated tag rather than a commit.
+func IsTagObject(repo *models.Repository, id string) bool {
+	if !repo.Hash().ValidID(id) {
+		return false
+	}
+	_, err := repo.Store.Stat(path.Join(tagsDir, id))
+	return err == nil
+}
+
+// CheckTagName returns an error if name cannot be used as a tag name.
+func CheckTagName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") ||
+		strings.Contains(name, "..") || strings.ContainsAny(name, "/\\ \t\n~^:?*[@{") {
+		return fmt.Errorf("'%s' is not a valid tag name", name)
+	}
+	return nil
+}
+
+// CreateTagRef points the tag at id, which is a commit for a lightweight tag and a tag object
+// for an annotated one.
+func CreateTagRef(repo *models.Repository, name, id string) error {
+	if err := repo.Store.MkdirAll(path.Join("refs", "tags"), fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating refs/tags directory: %w", err)
+	}
+	return repo.Store.WriteFile(path.Join("refs", "tags", name), []byte(id), 0644)
+}
+
+// ReadTagRef returns the ID the tag points to.
+func ReadTagRef(repo *models.Repository, name string) (string, error) {
+	content, err := repo.Store.ReadFile(path.Join("refs", "tags", name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: tag '%s'", models.ErrRefNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// DeleteTagRef removes the tag. Annotated tag objects are kept, like the commits.
+func DeleteTagRef(repo *models.Repository, name string) error {
+	if !tagExists(repo, name) {
+		return fmt.Errorf("%w: tag '%s'", models.ErrRefNotFound, name)
+	}
+	return repo.Store.Remove(path.Join("refs", "tags", name))
+}
+
+// GetTags returns the names of all the tags, sorted.
+func GetTags(repo *models.Repository) ([]string, error) {
+	files, err := repo.Store.ReadDir(path.Join("refs", "tags"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading refs/tags directory: %w", err)
+	}
+	var tags []string
+	for _, file := range files {
+		if !file.IsDir() {
+			tags = append(tags, file.Name())
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// PeelTag returns the commit id points to: id itself for a commit, or the commit of an
+// annotated tag.
+func PeelTag(repo *models.Repository, id string) (string, error) {
+	if !IsTagObject(repo, id) {
+		return id, nil
+	}
+	tag, err := GetTagByHash(repo, id)
+	if err != nil {
+		return "", err
+	}
+	return tag.Object, nil
+}
+
+// tagExists reports whether there is a tag with the given name.
+func tagExists(repo *models.Repository, name string) bool {
+	_, err := repo.Store.Stat(path.Join("refs", "tags", name))
+	return err == nil
+}
@@ -4,24 +4,29 @@ import (
 	"GitX/internal/hash"
 	"GitX/models"
 	"bufio"
-	"crypto/sha1"
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"io"
+	"io/fs"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// reflogDir is the directory of the store holding the reflog entries.
+const reflogDir = "reflog"
+
 // UpdateHEAD updates the HEAD file with the reference to the latest commit on the current branch.
-func UpdateHEAD(commitHash string) error {
-	headFile := ".gitx/HEAD"
+func UpdateHEAD(repo *models.Repository, commitHash string) error {
+	headFile := path.Join("HEAD")
 
 	// Write the commit hash to the HEAD file
-	if err := os.WriteFile(headFile, []byte(commitHash), 0644); err != nil {
+	if err := repo.Store.WriteFile(headFile, []byte(commitHash), 0644); err != nil {
 		return fmt.Errorf("error writing to HEAD file: %w", err)
 	}
 
@@ -29,60 +34,76 @@ func UpdateHEAD(commitHash string) error {
 }
 
 // GetCurrentHeadCommit retrieves the current commit that HEAD is pointing to.
-func GetCurrentHeadCommit() string {
-	headFile := filepath.Join(".gitx", "HEAD")
-	content, err := os.ReadFile(headFile)
+// It returns an empty ID when the current branch has no commits yet.
+func GetCurrentHeadCommit(repo *models.Repository) (string, error) {
+	branchName, err := getCurrentBranch(repo)
 	if err != nil {
-		log.Fatalf("Error reading HEAD file: %v", err)
+		return "", err
 	}
 
-	headContent := string(content)
-	// Extract the branch name
-	branchName := strings.TrimPrefix(headContent, "refs/heads/")
-	fmt.Printf("Current branch: %s\n", branchName) // Debug print
 	// Construct the path to the branch commits file
-	branchCommitsFile := filepath.Join(".gitx", "refs", "heads", branchName)
-
-	// Clean the file path
-	branchCommitsFile = filepath.Clean(branchCommitsFile)
-	fmt.Printf("Reading commit hash from: %s\n", branchCommitsFile) // Debug print
-
-	if _, err := os.Stat(branchCommitsFile); os.IsNotExist(err) {
-		fmt.Printf("Branch commits file does not exist: %s\n", branchCommitsFile) // Debug print
-		return ""
-	} else {
-		// Read the last commit hash from the branch commits file
-		lastCommitHash, err := os.ReadFile(branchCommitsFile)
-		fmt.Printf("Last commit hash: %s\n", lastCommitHash) // Debug print
-		if err != nil {
-			log.Fatalf("Error reading branch commits file: %v", err)
-		}
-		return string(lastCommitHash)
+	branchCommitsFile := path.Join("refs", "heads", branchName)
+
+	// Read the last commit hash from the branch commits file
+	lastCommitHash, err := repo.Store.ReadFile(branchCommitsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading branch commits file: %w", err)
 	}
+	return strings.TrimSpace(string(lastCommitHash)), nil
 }
 
-// readIndexFile reads and parses the index file into a slice of IndexEntry.
-func ReadIndexFile(indexPath string) ([]*models.IndexEntry, error) {
-	file, err := os.Open(indexPath)
+// indexChecksumPrefix marks the trailing line of the index file that holds the checksum of all entries.
+const indexChecksumPrefix = "checksum "
+
+// ReadIndexFile reads and parses the repository's INDEX file into a slice of IndexEntry.
+// The trailing checksum line, when present, is verified against the entries read.
+func ReadIndexFile(repo *models.Repository) ([]*models.IndexEntry, error) {
+	content, err := repo.Store.ReadFile("INDEX")
 	if err != nil {
 		return nil, fmt.Errorf("cannot open index file: %v", err)
 	}
-	defer file.Close()
 
+	return parseIndex(repo.Hash(), content)
+}
+
+// parseIndex parses the raw content of an index file and validates its entries' IDs and its
+// checksum, which both use the repository's hash algorithm.
+func parseIndex(alg *hash.Algorithm, content []byte) ([]*models.IndexEntry, error) {
 	var entries []*models.IndexEntry
-	scanner := bufio.NewScanner(file)
+	var body []byte
+	checksum := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
 	for scanner.Scan() {
 		line := scanner.Text()
-		fields := strings.Fields(line)
-		if len(fields) != 4 {
+		if checksum != "" {
+			return nil, fmt.Errorf("invalid index file format: data after checksum")
+		}
+		if strings.HasPrefix(line, indexChecksumPrefix) {
+			checksum = strings.TrimSpace(strings.TrimPrefix(line, indexChecksumPrefix))
+			continue
+		}
+		body = append(body, line...)
+		body = append(body, '\n')
+
+		// Each entry is "<mode> <type> <hash>\t<path>"
+		meta, path, found := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !found || len(fields) != 3 || path == "" {
 			return nil, fmt.Errorf("invalid index file format")
 		}
+		if err := alg.CheckID(fields[2]); err != nil {
+			return nil, fmt.Errorf("invalid index entry for %s: %v", path, err)
+		}
 
 		entry := &models.IndexEntry{
 			Mode: fields[0],
 			Type: fields[1],
 			Hash: fields[2],
-			Path: fields[3],
+			Path: path,
 		}
 		entries = append(entries, entry)
 	}
@@ -91,17 +112,49 @@ func ReadIndexFile(indexPath string) ([]*models.IndexEntry, error) {
 		return nil, fmt.Errorf("error reading index file: %v", err)
 	}
 
+	// An empty index has nothing to checksum
+	if len(entries) == 0 && checksum == "" {
+		return entries, nil
+	}
+	if checksum == "" {
+		return nil, fmt.Errorf("index file checksum is missing")
+	}
+	if alg.Sum(body) != checksum {
+		return nil, fmt.Errorf("index file checksum mismatch")
+	}
+
 	return entries, nil
 }
 
-// CreateTreeFromIndex creates a tree object from the index file.
-func CreateTreeFromIndex(indexPath string) (*models.Tree, error) {
+// WriteIndexFile writes the entries to the INDEX file sorted by path, followed by their checksum.
+func WriteIndexFile(repo *models.Repository, entries []*models.IndexEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%s %s %s\t%s\n", entry.Mode, entry.Type, entry.Hash, entry.Path)
+	}
+	if len(entries) > 0 {
+		fmt.Fprintf(&buf, "%s%s\n", indexChecksumPrefix, repo.Hash().Sum(buf.Bytes()))
+	}
+
+	if err := repo.Store.WriteFile("INDEX", buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing index file: %v", err)
+	}
+
+	return nil
+}
+
+// CreateTreeFromIndex creates a tree object from the INDEX file.
+func CreateTreeFromIndex(repo *models.Repository) (*models.Tree, error) {
 	tree := &models.Tree{
 		Entries: []models.TreeEntry{},
 	}
 
 	// Read the index file
-	indexEntries, err := ReadIndexFile(indexPath)
+	indexEntries, err := ReadIndexFile(repo)
 	if err != nil {
 		return nil, fmt.Errorf("error reading index file: %v", err)
 	}
@@ -122,27 +175,33 @@ func CreateTreeFromIndex(indexPath string) (*models.Tree, error) {
 		tree.Entries = append(tree.Entries, treeEntry)
 	}
 
-	// Generate the SHA-1 hash for the tree
-	hash := sha1.New()
+	tree.ID = TreeID(repo.Hash(), tree)
+
+	return tree, nil
+}
+
+// TreeID computes the hash identifying the given tree from its entries.
+func TreeID(alg *hash.Algorithm, tree *models.Tree) string {
+	h := alg.New()
 	for _, entry := range tree.Entries {
 		entryStr := fmt.Sprintf("%s %s %s\t%s", entry.Mode, entry.Type, entry.ID, entry.Name)
-		hash.Write([]byte(entryStr))
+		h.Write([]byte(entryStr))
 	}
-	tree.ID = hex.EncodeToString(hash.Sum(nil))
-
-	return tree, nil
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // GetCommitByHash retrieves a commit object by its hash.
-func GetCommitByHash(commitHash string) (*models.Commit, error) {
-	commitsDir := ".gitx/commits"
-	commitFilePath := filepath.Join(commitsDir, commitHash)
+func GetCommitByHash(repo *models.Repository, commitHash string) (*models.Commit, error) {
+	if err := repo.Hash().CheckID(commitHash); err != nil {
+		return nil, err
+	}
+	commitFilePath := path.Join("commits", commitHash)
 
-	// Read the commit file using os.ReadFile
-	commitData, err := os.ReadFile(commitFilePath)
+	// Read the commit file from the store
+	commitData, err := repo.Store.ReadFile(commitFilePath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("commit %s does not exist", commitHash)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: commit %s", models.ErrRefNotFound, commitHash)
 		}
 		return nil, fmt.Errorf("error reading commit file: %w", err)
 	}
@@ -156,189 +215,238 @@ func GetCommitByHash(commitHash string) (*models.Commit, error) {
 	return &commit, nil
 }
 
-// GetCurrentUser retrieves the current user from the system.
-func GetCurrentUser() string {
-	// Placeholder for getting the current user
-	return "John Doe"
+// HeadTreeFiles returns the blob IDs of the files recorded in the HEAD commit, keyed by path.
+// The map is empty when the current branch has no commits yet.
+func HeadTreeFiles(repo *models.Repository) (map[string]string, error) {
+	files := make(map[string]string)
+	headCommitID, err := GetCurrentHeadCommit(repo)
+	if err != nil || headCommitID == "" {
+		return files, err
+	}
+
+	headCommit, err := GetCommitByHash(repo, headCommitID)
+	if err != nil {
+		return nil, err
+	}
+	if headCommit.Tree != nil {
+		for _, entry := range headCommit.Tree.Entries {
+			files[entry.Name] = entry.ID
+		}
+	}
+	return files, nil
+}
+
+// DetectRenames pairs deleted and added files that have the same content, which is how
+// a move shows up when comparing two snapshots. Both maps hold blob IDs keyed by path; the
+// result maps each new path to the path it was renamed from.
+func DetectRenames(deleted, added map[string]string) map[string]string {
+	sources := make(map[string][]string)
+	for _, oldPath := range sortedKeys(deleted) {
+		sources[deleted[oldPath]] = append(sources[deleted[oldPath]], oldPath)
+	}
+
+	renames := make(map[string]string)
+	for _, newPath := range sortedKeys(added) {
+		candidates := sources[added[newPath]]
+		if len(candidates) == 0 {
+			continue
+		}
+		renames[newPath] = candidates[0]
+		sources[added[newPath]] = candidates[1:]
+	}
+	return renames
 }
 
-func CreateEmptyTree() *models.Tree {
-	return &models.Tree{
+func CreateEmptyTree(alg *hash.Algorithm) *models.Tree {
+	tree := &models.Tree{
 		Entries: []models.TreeEntry{},
 	}
+	tree.ID = TreeID(alg, tree)
+	return tree
 }
 
 // Function to check if a branch exists by looking for its reference file
-func branchExists(branchName string) bool {
-	refsHeadsDir := filepath.Join(".gitx", "refs", "heads")
-	branchRefPath := filepath.Join(refsHeadsDir, branchName)
-	if _, err := os.Stat(branchRefPath); err == nil {
+func branchExists(repo *models.Repository, branchName string) bool {
+	branchRefPath := path.Join("refs", "heads", branchName)
+	if _, err := repo.Store.Stat(branchRefPath); err == nil {
 		return true
 	}
 	return false
 }
 
+// CurrentBranch returns the name of the branch HEAD points to.
+func CurrentBranch(repo *models.Repository) (string, error) {
+	return getCurrentBranch(repo)
+}
+
 // getCurrentBranch reads the current branch from the HEAD file.
-func getCurrentBranch() (string, error) {
-	headFile := filepath.Join(".gitx", "HEAD")
-	content, err := os.ReadFile(headFile)
+func getCurrentBranch(repo *models.Repository) (string, error) {
+	headFile := path.Join("HEAD")
+	content, err := repo.Store.ReadFile(headFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", models.ErrNotARepository, repo.GitxDir)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read HEAD file: %v", err)
+		return "", fmt.Errorf("failed to read HEAD file: %w", err)
 	}
 
-	// Parse the content to extract the branch name
-	refPrefix := "ref: refs/heads/"
-	if strings.HasPrefix(string(content), refPrefix) {
+	// Parse the content to extract the branch name, accepting both
+	// "refs/heads/<name>" and Git's "ref: refs/heads/<name>" forms
+	headRef := strings.TrimPrefix(strings.TrimSpace(string(content)), "ref: ")
+	refPrefix := "refs/heads/"
+	if strings.HasPrefix(headRef, refPrefix) {
 		// The branch name is the part after the prefix
-		return strings.TrimSpace(strings.TrimPrefix(string(content), refPrefix)), nil
+		return strings.TrimPrefix(headRef, refPrefix), nil
 	}
 
 	return "", fmt.Errorf("HEAD file does not contain a valid branch reference")
 }
 
-// GenerateCommitID generates a commit ID based on the tree hash, parent commit IDs, and other commit information.
-func GenerateCommitID(tree *models.Tree, parents []*models.Commit, message, author string, timestamp time.Time) (string, error) {
-	// Create a new SHA-1 hash instance
-	h := sha1.New()
+// CommitPayload returns the text a commit is identified by, without its signature. It is also
+// the data a commit signature covers.
+func CommitPayload(commit *models.Commit) []byte {
+	var payload strings.Builder
 
 	// Serialize the tree hash
-	treeHash := tree.ID // Use the ID field of the tree object
-	h.Write([]byte(fmt.Sprintf("tree %s\n", treeHash)))
+	fmt.Fprintf(&payload, "tree %s\n", commit.Tree.ID)
 
 	// Serialize parent commits
-	for _, parent := range parents {
-		h.Write([]byte(fmt.Sprintf("parent %s\n", parent.ID)))
+	for _, parent := range commit.Parent {
+		if parent == nil {
+			continue
+		}
+		fmt.Fprintf(&payload, "parent %s\n", parent.ID)
 	}
 
-	// Serialize author information
-	authorInfo := fmt.Sprintf("author %s %d +0000\n", author, timestamp.Unix())
-	h.Write([]byte(authorInfo))
-
-	// Serialize committer information (same as author for simplicity)
-	committerInfo := fmt.Sprintf("committer %s %d +0000\n", author, timestamp.Unix())
-	h.Write([]byte(committerInfo))
+	// Serialize author and committer information; the committer is the author unless the
+	// commit was rewritten by someone else or later
+	committer, commitTime := commit.Committer, commit.CommitTime
+	if committer == "" {
+		committer = commit.Author
+	}
+	if commitTime.IsZero() {
+		commitTime = commit.Timestamp
+	}
+	fmt.Fprintf(&payload, "author %s %d +0000\n", commit.Author, commit.Timestamp.Unix())
+	fmt.Fprintf(&payload, "committer %s %d +0000\n", committer, commitTime.Unix())
 
 	// Serialize commit message
-	h.Write([]byte(fmt.Sprintf("\n%s\n", message)))
-
-	// Calculate the hash
-	hashed := h.Sum(nil)
+	fmt.Fprintf(&payload, "\n%s\n", commit.Message)
+	return []byte(payload.String())
+}
 
-	// Encode the hashed result to hexadecimal string
-	hashedString := hex.EncodeToString(hashed)
+// CommitText returns the text of a commit with its signature, which its ID is the hash of. A
+// signature is a "gpgsig" header after the committer, like in Git.
+func CommitText(commit *models.Commit) []byte {
+	payload := CommitPayload(commit)
+	if commit.GPGSignature == "" {
+		return payload
+	}
+	headers, body, _ := strings.Cut(string(payload), "\n\n")
+	return []byte(headers + "\ngpgsig " + strings.ReplaceAll(commit.GPGSignature, "\n", "\n ") + "\n\n" + body)
+}
 
-	return hashedString, nil
+// GenerateCommitID generates a commit ID based on the tree hash, parent commit IDs, and other commit information.
+// A signature, empty for an unsigned commit, is hashed too, so the ID also covers it.
+func GenerateCommitID(alg *hash.Algorithm, commit *models.Commit) (string, error) {
+	return alg.Sum(CommitText(commit)), nil
 }
 
 // CreateBranch creates a new Git branch.
-func CreateBranch(branchName string) error {
-	if branchExists(branchName) {
-		return fmt.Errorf("branch '%s' already exists", branchName)
-	}
-	gitxDir := ".gitx"
-	refsHeadsDir := filepath.Join(gitxDir, "refs", "heads")
-	branchRefPath := filepath.Join(refsHeadsDir, branchName)
-
-	// Check if the branch already exists
-	if _, err := os.Stat(branchRefPath); err == nil {
-		return fmt.Errorf("branch '%s' already exists", branchName)
+func CreateBranch(repo *models.Repository, branchName string) error {
+	if branchExists(repo, branchName) {
+		return fmt.Errorf("%w: %s", models.ErrBranchExists, branchName)
 	}
+	branchRefPath := path.Join("refs", "heads", branchName)
 
 	// Get the current HEAD commit
-	currentCommitID := GetCurrentHeadCommit()
+	currentCommitID, err := GetCurrentHeadCommit(repo)
+	if err != nil {
+		return err
+	}
 	if currentCommitID == "" {
 		return fmt.Errorf("no current commit found to point the branch to")
 	}
 
 	// Write the current commit ID to the branch ref file
-	if err := os.WriteFile(branchRefPath, []byte(currentCommitID), 0644); err != nil {
-		return fmt.Errorf("error initializing branch ref file: %v", err)
+	if err := repo.Store.WriteFile(branchRefPath, []byte(currentCommitID), 0644); err != nil {
+		return fmt.Errorf("error initializing branch ref file: %w", err)
 	}
 
-	fmt.Printf("Branch '%s' created successfully.\n", branchName)
 	return nil
 }
 
-// ListBranches lists all the Git branches in the repository.
-func ListBranches() {
-	gitxDir := ".gitx"
-	refsHeadsDir := filepath.Join(gitxDir, "refs", "heads")
-
-	// Read the current branch reference from the HEAD file
-	headFile := filepath.Join(gitxDir, "HEAD")
-	currentBranchRefBytes, err := os.ReadFile(headFile)
+// GetBranches returns the names of all the branches in the repository.
+func GetBranches(repo *models.Repository) ([]string, error) {
+	files, err := repo.Store.ReadDir(path.Join("refs", "heads"))
 	if err != nil {
-		log.Fatalf("Error reading HEAD file: %v", err)
+		return nil, fmt.Errorf("error reading refs/heads directory: %v", err)
 	}
-	currentBranchRef := strings.TrimSpace(string(currentBranchRefBytes))
 
-	// Extract the branch name from the reference
-	// Assuming the reference is in the format "refs/heads/branch-name"
-	parts := strings.Split(currentBranchRef, "/")
-	if len(parts) < 3 {
-		log.Fatalf("Invalid branch reference in HEAD file")
+	var branches []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		branches = append(branches, file.Name())
 	}
-	currentBranch := parts[2] // The branch name is the third part of the reference
+	return branches, nil
+}
 
-	files, err := os.ReadDir(refsHeadsDir)
+// ListBranches writes the branches of the repository to w, one per line, marking the current
+// branch with an asterisk.
+func ListBranches(repo *models.Repository, w io.Writer) error {
+	// Read the current branch reference from the HEAD file
+	currentBranch, err := getCurrentBranch(repo)
 	if err != nil {
-		log.Fatalf("Error reading refs/heads directory: %v", err)
+		return err
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		branchName := file.Name()
+	branches, err := GetBranches(repo)
+	if err != nil {
+		return err
+	}
+
+	for _, branchName := range branches {
 		if branchName == currentBranch {
 			// Print the current branch in green with an asterisk
-			fmt.Printf("\033[32m* %s\033[0m\n", branchName)
+			fmt.Fprintf(w, "\033[32m* %s\033[0m\n", branchName)
 		} else {
-			fmt.Println(branchName)
+			fmt.Fprintln(w, branchName)
 		}
 	}
+
+	return nil
 }
 
 // SwitchBranch switches to the specified Git branch.
-func SwitchBranch(branchName string) error {
-	gitxDir := ".gitx"
-	refsHeadsDir := filepath.Join(gitxDir, "refs", "heads")
-	branchRefPath := filepath.Join(refsHeadsDir, branchName)
+func SwitchBranch(repo *models.Repository, branchName string) error {
+	branchRefPath := path.Join("refs", "heads", branchName)
 
 	// Check if the branch exists
-	if _, err := os.Stat(branchRefPath); os.IsNotExist(err) {
-		return fmt.Errorf("branch '%s' does not exist", branchName)
-	}
-
-	// Read the commit ID from the branch file
-	branchCommitID, err := os.ReadFile(branchRefPath)
-	if err != nil {
-		return fmt.Errorf("error reading branch file: %v", err)
+	if _, err := repo.Store.Stat(branchRefPath); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, branchName)
 	}
 
 	// Update HEAD to point to the new branch
-	headPath := filepath.Join(gitxDir, "HEAD")
-	newHeadContent := "refs/heads/" + branchName + "\n"
-
-	if err := os.WriteFile(headPath, []byte(newHeadContent), 0644); err != nil {
-		return fmt.Errorf("failed to update HEAD: %v", err)
+	if err := UpdateHEAD(repo, "refs/heads/"+branchName); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
 	}
 
-	fmt.Printf("Switched to branch '%s'. Current commit: %s\n", branchName, string(branchCommitID))
 	return nil
 }
 
 // DeleteBranch deletes the specified Git branch.
-func DeleteBranch(branchName string) error {
+func DeleteBranch(repo *models.Repository, branchName string) error {
 	if branchName == "" {
 		return fmt.Errorf("branch name cannot be empty")
 	}
-	if !branchExists(branchName) {
-		return fmt.Errorf("branch '%s' does not exist", branchName)
+	if !branchExists(repo, branchName) {
+		return fmt.Errorf("%w: branch '%s'", models.ErrRefNotFound, branchName)
 	}
 
 	// Prevent deletion of the current branch
-	currentBranch, err := getCurrentBranch()
+	currentBranch, err := getCurrentBranch(repo)
 	if err != nil {
 		return err
 	}
@@ -347,301 +455,159 @@ func DeleteBranch(branchName string) error {
 	}
 
 	// Path to the branch reference file
-	refsHeadsDir := filepath.Join(".gitx", "refs", "heads")
-	branchRefPath := filepath.Join(refsHeadsDir, branchName)
+	branchRefPath := path.Join("refs", "heads", branchName)
 
 	// Delete the branch reference file
-	if err := os.Remove(branchRefPath); err != nil {
-		return fmt.Errorf("failed to delete branch: %v", err)
+	if err := repo.Store.Remove(branchRefPath); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
 	}
 
-	fmt.Printf("Branch '%s' deleted successfully.\n", branchName)
 	return nil
 }
 
-// MergeBranch merges the specified branch into the current branch.
-func MergeBranch(branchName string) error {
-	// Read the current branch from HEAD
-	currentBranch, err := getCurrentBranch()
-	if err != nil {
-		return fmt.Errorf("failed to get current branch: %v", err)
+// ReadBlob reads the content of the blob with the given ID from the object store.
+func ReadBlob(repo *models.Repository, id string) ([]byte, error) {
+	if err := repo.Hash().CheckID(id); err != nil {
+		return nil, err
 	}
-
-	// Read the commit IDs of the current branch and the branch to merge
-	currentCommitID, err := getCommitID(currentBranch)
-	if err != nil {
-		return fmt.Errorf("failed to get commit ID of %s: %v", currentBranch, err)
+	object, err := repo.Store.ReadFile(path.Join("objects", id[:2], id[2:]))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", models.ErrObjectNotFound, id)
 	}
-
-	mergeCommitID, err := getCommitID(branchName)
 	if err != nil {
-		return fmt.Errorf("failed to get commit ID of %s: %v", branchName, err)
+		return nil, fmt.Errorf("error reading object %s: %v", id, err)
 	}
 
-	// Perform the merge operation using commit IDs
-	// Perform the merge operation using commit IDs
-	err = mergeCommits(currentCommitID, mergeCommitID)
-	if err != nil {
-		return fmt.Errorf("failed to merge branch %s into %s: %v", branchName, currentBranch, err)
+	// Objects are stored as "blob <size>\x00<content>"
+	header, content, found := bytes.Cut(object, []byte{0})
+	objectType, size, _ := strings.Cut(string(header), " ")
+	if !found || objectType != "blob" || size != strconv.Itoa(len(content)) {
+		return nil, fmt.Errorf("object %s is corrupt", id)
 	}
+	return content, nil
+}
 
-	fmt.Printf("Merged branch %s into %s\n", branchName, currentBranch)
+// SquashCommits manually squashes the specified range of commits into a single commit.
+func SquashCommits(baseCommit, targetCommit string) error {
+	// Implement the squash logic here
+	// For example, I could use a diffing algorithm to generate a single commit that represents the changes between baseCommit and targetCommit.
+
+	fmt.Printf("Manually squashed commits from %s to %s\n", baseCommit, targetCommit)
 	return nil
 }
 
-// getCommitID returns the commit ID of the given branch
-func getCommitID(branchName string) (string, error) {
-	branchPath := filepath.Join("refs", "heads", branchName)
-	commitID, err := readFileContent(branchPath)
-	if err != nil {
-		return "", err
+// Stash saves the changes in the working directory to a new directory under .gitx/stash and returns it.
+func Stash(repo *models.Repository) (string, error) {
+	// Create a directory to store the stashed changes
+	stashDir := path.Join("stash", strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := repo.Store.MkdirAll(stashDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create stash directory: %w", err)
 	}
-	return strings.TrimSpace(string(commitID)), nil
-}
 
-// readCommit reads the commit data from the commit file.
-func readCommit(commitID string) (*models.Commit, error) {
-	commitFile := filepath.Join(".gitx", "commits", commitID+".json")
-	file, err := os.Open(commitFile)
+	// Walk through the working directory and copy all files to the stash directory
+	err := copyDir(repo, stashDir)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to stash changes: %w", err)
 	}
-	defer file.Close()
-
-	var commit models.Commit
-	if err := json.NewDecoder(file).Decode(&commit); err != nil {
-		return nil, err
-	}
-	return &commit, nil
-}
 
-// findCommonAncestor finds the common ancestor of two commits.
-func findCommonAncestor(currentCommit *models.Commit, mergeCommit *models.Commit) (*models.Commit, error) {
-	// Placeholder for common ancestor logic
-	// You can implement a proper logic to find common ancestors.
-	// For now, we assume the initial commit is the common ancestor.
-	initialCommitID := "initial_commit_id" // Replace this with actual logic to find the common ancestor
-	return readCommit(initialCommitID)
+	return repo.Path(stashDir), nil
 }
 
-// mergeFiles performs a three-way merge of the contents of files.
-func mergeFiles(baseContent, currentContent, otherContent string) (string, bool) {
-	if currentContent == otherContent {
-		return currentContent, false
-	}
-	if baseContent == currentContent {
-		return otherContent, false
-	}
-	if baseContent == otherContent {
-		return currentContent, false
-	}
+// CommitLog returns the history reachable from HEAD, newest commit first. The commit-graph
+// orders the history, so only the commits returned are read.
+func CommitLog(repo *models.Repository) ([]*models.Commit, error) {
+	var history []*models.Commit
 
-	// Conflict detected
-	conflictContent := fmt.Sprintf("<<<<<<< current\n%s\n=======\n%s\n>>>>>>> other\n", currentContent, otherContent)
-	return conflictContent, true
-}
-
-// mergeCommits merges changes from two commits and creates a new merge commit.
-func mergeCommits(currentCommitID, mergeCommitID string) error {
-	// Read the current commit
-	currentCommit, err := readCommit(currentCommitID)
+	headCommitID, err := GetCurrentHeadCommit(repo)
 	if err != nil {
-		return fmt.Errorf("error reading current commit: %v", err)
+		return nil, err
 	}
-
-	// Read the commit to merge
-	mergeCommit, err := readCommit(mergeCommitID)
-	if err != nil {
-		return fmt.Errorf("error reading merge commit: %v", err)
+	if headCommitID == "" {
+		return history, nil
 	}
 
-	// Find common ancestor
-	baseCommit, err := findCommonAncestor(currentCommit, mergeCommit)
+	ids, err := historyIDs(repo, headCommitID)
 	if err != nil {
-		return fmt.Errorf("error finding common ancestor: %v", err)
-	}
-
-	// Merge changes
-	mergedFiles := make(map[string]string)
-	conflictDetected := false
-
-	// Collect all unique file paths
-	allFilePaths := make(map[string]bool)
-	for filePath := range baseCommit.Files {
-		allFilePaths[filePath] = true
-	}
-	for filePath := range currentCommit.Files {
-		allFilePaths[filePath] = true
-	}
-	for filePath := range mergeCommit.Files {
-		allFilePaths[filePath] = true
+		return nil, err
 	}
-
-	// Merge each file
-	for filePath := range allFilePaths {
-		baseContent := baseCommit.Files[filePath]
-		currentContent := currentCommit.Files[filePath]
-		mergeContent := mergeCommit.Files[filePath]
-
-		mergedContent, conflict := mergeFiles(baseContent, currentContent, mergeContent)
-		if conflict {
-			conflictDetected = true
+	for _, id := range ids {
+		commit, err := GetCommitByHash(repo, id)
+		if err != nil {
+			return nil, err
 		}
-
-		mergedFiles[filePath] = mergedContent
-	}
-
-	// If conflicts were detected, notify the user
-	if conflictDetected {
-		fmt.Println("Merge conflicts detected. Please resolve them manually.")
+		history = append(history, commit)
 	}
 
-	// Create the merge commit
-	mergeCommitHash, err := hash.SHA1Hash(fmt.Sprintf("%s+%s", currentCommitID, mergeCommitID))
-	if err != nil {
-		return fmt.Errorf("error computing merge commit hash: %v", err)
-	}
-
-	newCommit := &models.Commit{
-		ID:           mergeCommitHash,
-		Parent:       []*models.Commit{currentCommit, mergeCommit},
-		Message:      fmt.Sprintf("Merge commit %s into %s", mergeCommitID, currentCommitID),
-		Author:       "Merge Author",
-		Timestamp:    time.Now(),
-		Files:        mergedFiles,
-		Committer:    "Merge Committer",
-		GPGSignature: "",
-	}
-
-	// Save the new merge commit
-	commitFile := filepath.Join(".gitx", "commits", newCommit.ID+".json")
-	file, err := os.Create(commitFile)
-	if err != nil {
-		return fmt.Errorf("error creating merge commit file: %v", err)
-	}
-	defer file.Close()
-
-	if err := json.NewEncoder(file).Encode(newCommit); err != nil {
-		return fmt.Errorf("error encoding merge commit: %v", err)
-	}
-
-	fmt.Printf("Created merge commit: %s\n", newCommit.ID)
-	return nil
+	return history, nil
 }
 
-// readFileContent reads the content of a file
-func readFileContent(filePath string) ([]byte, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var content []byte
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-	content = make([]byte, stat.Size())
-	_, err = file.Read(content)
-	if err != nil {
-		return nil, err
-	}
-	return content, nil
+// LogOptions configures LogHandler.
+type LogOptions struct {
+	// Signature, if set, describes the signature of a commit, which is printed after its ID.
+	// An empty description prints nothing.
+	Signature func(commit *models.Commit) (string, error)
 }
 
-// SquashCommits manually squashes the specified range of commits into a single commit.
-func SquashCommits(baseCommit, targetCommit string) error {
-	// Implement the squash logic here
-	// For example, I could use a diffing algorithm to generate a single commit that represents the changes between baseCommit and targetCommit.
-
-	fmt.Printf("Manually squashed commits from %s to %s\n", baseCommit, targetCommit)
-	return nil
-}
-
-// Stash saves the changes in the working directory to a temporary location.
-func Stash() error {
-	// Create a temporary directory to store the stashed changes
-	tempDir, err := os.MkdirTemp("", "stashed_changes")
+// LogHandler writes the commit history to w.
+func LogHandler(repo *models.Repository, w io.Writer, opts LogOptions) error {
+	history, err := CommitLog(repo)
 	if err != nil {
-		return fmt.Errorf("failed to create temporary directory: %v", err)
+		return err
 	}
 
-	// Walk through the working directory and copy all files to the temporary directory
-	err = copyDir(".", tempDir)
-	if err != nil {
-		return fmt.Errorf("failed to stash changes: %v", err)
+	for _, commit := range history {
+		signature := ""
+		if opts.Signature != nil {
+			if signature, err = opts.Signature(commit); err != nil {
+				return err
+			}
+		}
+		displayCommit(w, commit, signature)
 	}
 
-	fmt.Printf("Stashed changes in %s\n", tempDir)
 	return nil
 }
 
-// LogHandler displays the commit history
-func LogHandler() {
-	commitsDir := ".gitx/commits"
-
-	// Read the commits directory
-	files, err := os.ReadDir(commitsDir)
-	if err != nil {
-		log.Fatalf("Error reading commits directory: %v", err)
-	}
-
-	// Iterate over the commit files
-	for _, file := range files {
-		if !file.IsDir() {
-			commitFile := filepath.Join(commitsDir, file.Name())
-			displayCommit(commitFile)
-		}
-	}
-}
-
-// displayCommit reads and prints commit details
-func displayCommit(commitFile string) {
-	file, err := os.Open(commitFile)
-	if err != nil {
-		log.Fatalf("Error opening commit file: %v", err)
-	}
-	defer file.Close()
-
-	var commit models.Commit
-	if err := json.NewDecoder(file).Decode(&commit); err != nil {
-		log.Fatalf("Error decoding commit file: %v", err)
+// displayCommit writes commit details to w.
+func displayCommit(w io.Writer, commit *models.Commit, signature string) {
+	fmt.Fprintln(w, "Commit:", commit.ID)
+	if signature != "" {
+		fmt.Fprintln(w, signature)
 	}
-
-	// Print commit details
-	fmt.Println("Commit:", commit.ID)
-	fmt.Println("Author:", commit.Author)
-	fmt.Println("Date:", commit.Timestamp)
-	fmt.Println("Message:", commit.Message)
-	fmt.Println("-------------------------------")
+	fmt.Fprintln(w, "Author:", commit.Author)
+	fmt.Fprintln(w, "Date:", commit.Timestamp)
+	fmt.Fprintln(w, "Message:", commit.Message)
+	fmt.Fprintln(w, "-------------------------------")
 }
 
-// copyDir copies the contents of a directory to another directory.
-func copyDir(src, dst string) error {
-	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+// copyDir copies the contents of the working tree, except the .gitx directory, to a directory of the store.
+func copyDir(repo *models.Repository, dst string) error {
+	err := fs.WalkDir(repo.WorkTree, ".", func(filePath string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if filePath == ".gitx" {
+			return fs.SkipDir
+		}
 
-		relPath, err := filepath.Rel(src, path)
+		info, err := entry.Info()
 		if err != nil {
 			return err
 		}
 
-		dstPath := filepath.Join(dst, relPath)
+		dstPath := path.Join(dst, filePath)
 
 		if info.IsDir() {
-			if err := os.MkdirAll(dstPath, info.Mode()); err != nil {
+			if err := repo.Store.MkdirAll(dstPath, info.Mode().Perm()); err != nil {
 				return err
 			}
 		} else {
-			srcContent, err := os.ReadFile(path)
+			srcContent, err := repo.WorkTree.ReadFile(filePath)
 			if err != nil {
 				return err
 			}
 
-			if err := os.WriteFile(dstPath, srcContent, info.Mode()); err != nil {
+			if err := repo.Store.WriteFile(dstPath, srcContent, info.Mode().Perm()); err != nil {
 				return err
 			}
 		}
@@ -655,101 +621,106 @@ func copyDir(src, dst string) error {
 	return nil
 }
 
-// CatFile displays the content of an object in the repository.
-func CatFile(objectID string) error {
-	// Retrieve the object by its ID
-	object, err := getObjectByID(objectID)
+// ReflogHandler writes the reflog history to w, oldest entry first.
+func ReflogHandler(repo *models.Repository, w io.Writer) error {
+	// Check if the reflog directory exists
+	if _, err := repo.Store.Stat(reflogDir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reflog directory does not exist: %v", err)
+	}
+
+	entries, err := ReadReflog(repo)
 	if err != nil {
 		return err
 	}
-
-	// Print the content of each file
-	fmt.Println("Files content:")
-	for filePath, hash := range object.Files {
-		// Fetch the content of the file using its hash
-		fileContent, err := readFileContent(hash)
-		if err != nil {
-			fmt.Printf("Error fetching content for file %s: %v\n", filePath, err)
-			continue
-		}
-		// Print the file path and its content
-		fmt.Printf("File: %s\nContent:\n%s\n", filePath, fileContent)
+	for _, reflog := range entries {
+		displayReflog(w, reflog)
 	}
-
 	return nil
 }
 
-// getObjectByID retrieves the object from the repository by its ID.
-func getObjectByID(_ string) (*models.Commit, error) {
-	// Placeholder for retrieving the object by ID
-	// You would typically fetch the object from the repository storage by its ID
-	// and return the object's content along with other metadata.
-	return nil, fmt.Errorf("getObjectByID: not implemented")
-}
-
-// ReflogHandler displays the reflog history.
-func ReflogHandler() error {
-	reflogDir := ".gitx/reflog"
-
-	// Check if the reflog directory exists
-	if _, err := os.Stat(reflogDir); os.IsNotExist(err) {
-		return fmt.Errorf("reflog directory does not exist: %v", err)
+// ReadReflog returns the entries of the reflog in the order they were written. A repository
+// without a reflog has no entries.
+func ReadReflog(repo *models.Repository) ([]*models.Reflog, error) {
+	files, err := repo.Store.ReadDir(reflogDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
 	}
-
-	// Read the reflog directory
-	files, err := os.ReadDir(reflogDir)
 	if err != nil {
-		return fmt.Errorf("error reading reflog directory: %v", err)
+		return nil, fmt.Errorf("error reading reflog directory: %v", err)
 	}
 
-	// Iterate over the reflog files
+	var entries []*models.Reflog
 	for _, file := range files {
-		if !file.IsDir() {
-			reflogFile := filepath.Join(reflogDir, file.Name())
-			if err := displayReflog(reflogFile); err != nil {
-				log.Printf("Error displaying reflog file %s: %v", reflogFile, err)
-			}
+		if file.IsDir() {
+			continue
 		}
+		reflogFile := path.Join(reflogDir, file.Name())
+		data, err := repo.Store.ReadFile(reflogFile)
+		if err != nil {
+			return nil, fmt.Errorf("error opening reflog file %s: %v", reflogFile, err)
+		}
+		var reflog models.Reflog
+		if err := json.Unmarshal(data, &reflog); err != nil {
+			return nil, fmt.Errorf("error decoding reflog file %s: %v", reflogFile, err)
+		}
+		entries = append(entries, &reflog)
 	}
-
-	return nil
+	return entries, nil
 }
 
-// displayReflog reads and prints reflog details
-func displayReflog(reflogFile string) error {
-	file, err := os.Open(reflogFile)
-	if err != nil {
-		return fmt.Errorf("error opening reflog file %s: %v", reflogFile, err)
+// AppendReflog records that ref moved from oldID to newID. Entries are stored one per file
+// under reflog/, named by their timestamp so they sort in the order they were written.
+func AppendReflog(repo *models.Repository, ref, oldID, newID, message string) error {
+	if err := repo.Store.MkdirAll(reflogDir, fs.ModePerm); err != nil {
+		return fmt.Errorf("error creating reflog directory: %w", err)
 	}
-	defer file.Close()
 
-	var reflog models.Reflog
-	if err := json.NewDecoder(file).Decode(&reflog); err != nil {
-		return fmt.Errorf("error decoding reflog file %s: %v", reflogFile, err)
+	// Moving a ref must not fail for lack of an identity, so the entry is then left without one
+	author, _ := GetCurrentUser(repo, RoleCommitter)
+	entry := models.Reflog{
+		ID:        newID,
+		OldID:     oldID,
+		Ref:       ref,
+		Author:    author,
+		Timestamp: time.Now(),
+		Message:   message,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error serializing reflog entry: %w", err)
 	}
 
-	// Print reflog details
-	fmt.Println("Reflog:", reflog.ID)
-	fmt.Println("Author:", reflog.Author)
-	fmt.Println("Date:", reflog.Timestamp)
-	fmt.Println("Message:", reflog.Message)
-	fmt.Println("-------------------------------")
-
+	reflogFile := path.Join(reflogDir, fmt.Sprintf("%020d", entry.Timestamp.UnixNano()))
+	if err := repo.Store.WriteFile(reflogFile, data, 0644); err != nil {
+		return fmt.Errorf("error writing reflog entry: %w", err)
+	}
 	return nil
 }
 
+// displayReflog writes reflog details to w.
+func displayReflog(w io.Writer, reflog *models.Reflog) {
+	fmt.Fprintln(w, "Reflog:", reflog.ID)
+	if reflog.Ref != "" {
+		fmt.Fprintln(w, "Ref:", reflog.Ref)
+	}
+	fmt.Fprintln(w, "Author:", reflog.Author)
+	fmt.Fprintln(w, "Date:", reflog.Timestamp)
+	fmt.Fprintln(w, "Message:", reflog.Message)
+	fmt.Fprintln(w, "-------------------------------")
+}
+
 // CreateBranchRef creates a reference file for a branch
-func CreateBranchRef(branchName, commitID string) error {
-	branchRefPath := filepath.Join(".gitx", "refs", "heads", branchName)
-	return os.WriteFile(branchRefPath, []byte(commitID), 0644)
+func CreateBranchRef(repo *models.Repository, branchName, commitID string) error {
+	branchRefPath := path.Join("refs", "heads", branchName)
+	return repo.Store.WriteFile(branchRefPath, []byte(commitID), 0644)
 }
 
 // ReadBranchRef reads the commit ID from the branch reference file
-func ReadBranchRef(branchName string) (string, error) {
-	branchRefPath := filepath.Join(".gitx", "refs", "heads", branchName)
-	content, err := os.ReadFile(branchRefPath)
+func ReadBranchRef(repo *models.Repository, branchName string) (string, error) {
+	branchRefPath := path.Join("refs", "heads", branchName)
+	content, err := repo.Store.ReadFile(branchRefPath)
 	if err != nil {
 		return "", err
 	}
-	return string(content), nil
+	return strings.TrimSpace(string(content)), nil
 }
@@ -0,0 +1,71 @@
+package vcs_operations_test
+
+import (
+	"GitX/models"
+	"GitX/utils/vcs_operations"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListBranches(t *testing.T) {
+	repo := newTestRepo(t)
+	commitFile(t, repo, "a.txt", "a\n")
+	if err := vcs_operations.CreateBranch(repo, "topic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vcs_operations.CreateBranch(repo, "topic"); !errors.Is(err, models.ErrBranchExists) {
+		t.Errorf("creating topic twice returns %v, want ErrBranchExists", err)
+	}
+
+	var out strings.Builder
+	if err := vcs_operations.ListBranches(repo, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\033[32m* main\033[0m\ntopic\n"; out.String() != want {
+		t.Errorf("branches = %q, want %q", out.String(), want)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commitFile(t, repo, "a.txt", "a\n")
+	second := commitFile(t, repo, "b.txt", "b\n")
+
+	var out strings.Builder
+	if err := vcs_operations.LogHandler(repo, &out, vcs_operations.LogOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	log := out.String()
+	if !strings.HasPrefix(log, "Commit: "+second.ID+"\n") {
+		t.Errorf("log does not start with the last commit:\n%s", log)
+	}
+	if strings.Index(log, first.ID) < strings.Index(log, second.ID) {
+		t.Errorf("log lists %s before %s:\n%s", first.ID, second.ID, log)
+	}
+}
+
+func TestReflogHandler(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := vcs_operations.ReflogHandler(repo, &strings.Builder{}); err == nil {
+		t.Error("ReflogHandler succeeds without a reflog")
+	}
+
+	for i, message := range []string{"first", "second"} {
+		data, err := json.Marshal(models.Reflog{ID: message + "-id", Message: message})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeStore(t, repo, "reflog/"+strconv.Itoa(i), string(data))
+	}
+	var out strings.Builder
+	if err := vcs_operations.ReflogHandler(repo, &out); err != nil {
+		t.Fatal(err)
+	}
+	reflog := out.String()
+	if !strings.HasPrefix(reflog, "Reflog: first-id\n") || !strings.Contains(reflog, "Message: second\n") {
+		t.Errorf("reflog =\n%s", reflog)
+	}
+}